@@ -0,0 +1,266 @@
+package api
+
+import (
+	"dday-backend/controllers"
+	"dday-backend/global/auth"
+	"dday-backend/models"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AuthController struct {
+	*controllers.Controller
+	users models.UserStore
+}
+
+func NewAuthController(users models.UserStore) *AuthController {
+	return &AuthController{users: users}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *AuthController) with(c *fiber.Ctx) *AuthController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+const invalidPassword = "Password must be 8 to 72 bytes long"
+
+// validPassword reports whether password is long enough to resist guessing
+// online; share link passwords follow the same rule as accounts.
+func validPassword(password string) bool {
+	// bcrypt only looks at the first 72 bytes.
+	return utf8.RuneCountInString(password) >= 8 && len(password) <= 72
+}
+
+func (ctrl *AuthController) Signup(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Name     string `json:"name"`
+		Timezone string `json:"timezone"`
+	}
+
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+
+	email := normalizeEmail(req.Email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > 255 {
+		return ctrl.BadRequest("Invalid email")
+	}
+
+	if !validPassword(req.Password) {
+		return ctrl.BadRequest(invalidPassword)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = email[:strings.Index(email, "@")]
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		return ctrl.BadRequest("Name must be at most 100 characters")
+	}
+
+	timezone := strings.TrimSpace(req.Timezone)
+	if _, err := models.LoadLocation(timezone); timezone != "" && err != nil {
+		return ctrl.BadRequest("Invalid timezone")
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to create user")
+	}
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Email:        email,
+		Name:         name,
+		PasswordHash: hash,
+		Timezone:     timezone,
+	}
+
+	if err := ctrl.users.Create(user); err != nil {
+		if errors.Is(err, models.ErrEmailTaken) {
+			return ctrl.Conflict("Email is already registered")
+		}
+		return ctrl.InternalServerError("Failed to create user")
+	}
+
+	tokens, err := ctrl.issueTokens(user.ID)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to issue tokens")
+	}
+	tokens["user"] = user
+
+	return ctrl.Created(tokens)
+}
+
+func (ctrl *AuthController) Login(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+
+	// Unknown emails still pay for a password check, so response times do
+	// not reveal which accounts exist.
+	hash := ""
+	user, err := ctrl.users.GetByEmail(normalizeEmail(req.Email))
+	if err == nil {
+		hash = user.PasswordHash
+	}
+	if !auth.CheckPassword(hash, req.Password) {
+		return ctrl.Unauthorized("Invalid email or password")
+	}
+
+	tokens, err := ctrl.issueTokens(user.ID)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to issue tokens")
+	}
+	tokens["user"] = user
+
+	return ctrl.Success(tokens)
+}
+
+// Refresh trades a refresh token for a new access token and a new refresh
+// token; the old refresh token stops working.
+func (ctrl *AuthController) Refresh(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := ctrl.Body(&req); err != nil || req.RefreshToken == "" {
+		return ctrl.BadRequest("Refresh token is required")
+	}
+
+	refreshToken, refreshHash, refreshExpiresAt, err := auth.NewRefreshToken()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to issue tokens")
+	}
+
+	userID, err := ctrl.users.RotateRefreshToken(auth.HashRefreshToken(req.RefreshToken), refreshHash, refreshExpiresAt)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidRefreshToken) {
+			return ctrl.Unauthorized("Invalid or expired refresh token")
+		}
+		return ctrl.InternalServerError("Failed to refresh tokens")
+	}
+
+	accessToken, accessExpiresAt, err := auth.IssueAccessToken(userID)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to issue tokens")
+	}
+
+	return ctrl.Success(tokenResponse(accessToken, accessExpiresAt, refreshToken, refreshExpiresAt))
+}
+
+func (ctrl *AuthController) Logout(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := ctrl.Body(&req); err != nil || req.RefreshToken == "" {
+		return ctrl.BadRequest("Refresh token is required")
+	}
+
+	if err := ctrl.users.RevokeRefreshToken(auth.HashRefreshToken(req.RefreshToken)); err != nil {
+		return ctrl.InternalServerError("Failed to log out")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"message": "Logged out",
+	})
+}
+
+func (ctrl *AuthController) Me(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	return ctrl.Success(ctrl.GetUser())
+}
+
+// UpdateMe changes the authenticated user's name or timezone. An empty
+// timezone goes back to the server's.
+func (ctrl *AuthController) UpdateMe(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req struct {
+		Name     *string `json:"name"`
+		Timezone *string `json:"timezone"`
+	}
+
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+
+	user := *ctrl.GetUser()
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+		if user.Name == "" {
+			return ctrl.BadRequest("Name is required")
+		}
+		if utf8.RuneCountInString(user.Name) > 100 {
+			return ctrl.BadRequest("Name must be at most 100 characters")
+		}
+	}
+	if req.Timezone != nil {
+		user.Timezone = strings.TrimSpace(*req.Timezone)
+		if _, err := models.LoadLocation(user.Timezone); user.Timezone != "" && err != nil {
+			return ctrl.BadRequest("Invalid timezone")
+		}
+	}
+
+	if err := ctrl.users.Update(&user); err != nil {
+		return ctrl.InternalServerError("Failed to update user")
+	}
+
+	return ctrl.Success(&user)
+}
+
+func (ctrl *AuthController) issueTokens(userID string) (fiber.Map, error) {
+	accessToken, accessExpiresAt, err := auth.IssueAccessToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshHash, refreshExpiresAt, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctrl.users.SaveRefreshToken(userID, refreshHash, refreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return tokenResponse(accessToken, accessExpiresAt, refreshToken, refreshExpiresAt), nil
+}
+
+func tokenResponse(accessToken string, accessExpiresAt time.Time, refreshToken string, refreshExpiresAt time.Time) fiber.Map {
+	return fiber.Map{
+		"token_type":               "Bearer",
+		"access_token":             accessToken,
+		"expires_in":               int(time.Until(accessExpiresAt).Round(time.Second).Seconds()),
+		"refresh_token":            refreshToken,
+		"refresh_token_expires_at": refreshExpiresAt,
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
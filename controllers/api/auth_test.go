@@ -0,0 +1,144 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSignup(t *testing.T) {
+	app := newTestApp(t)
+	signup(t, app, "taken@example.com")
+
+	tests := []struct {
+		name   string
+		body   fiber.Map
+		status int
+		error  string
+	}{
+		{"invalid email", fiber.Map{"email": "nobody", "password": testPassword}, 400, "Invalid email"},
+		{"short password", fiber.Map{"email": "new@example.com", "password": "1234567"}, 400, "Password must be 8 to 72 bytes long"},
+		{"taken email", fiber.Map{"email": "Taken@Example.com", "password": testPassword}, 409, "Email is already registered"},
+		{"invalid timezone", fiber.Map{"email": "new@example.com", "password": testPassword, "timezone": "Mars/Olympus"}, 400, "Invalid timezone"},
+		{"ok", fiber.Map{"email": "new@example.com", "password": testPassword}, 201, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out errorResponse
+			if status := send(t, app, "POST", "/api/v1/auth/signup", "", tt.body, &out); status != tt.status || out.Error != tt.error {
+				t.Errorf("status %d %q, want %d %q", status, out.Error, tt.status, tt.error)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	app := newTestApp(t)
+	signup(t, app, "user@example.com")
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		status   int
+	}{
+		{"wrong password", "user@example.com", "wrong password", 401},
+		{"unknown email", "nobody@example.com", testPassword, 401},
+		{"ok", " USER@example.com ", testPassword, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out tokens
+			body := fiber.Map{"email": tt.email, "password": tt.password}
+			if status := send(t, app, "POST", "/api/v1/auth/login", "", body, &out); status != tt.status {
+				t.Fatalf("status %d, want %d", status, tt.status)
+			}
+			if tt.status == 200 && (out.AccessToken == "" || out.RefreshToken == "") {
+				t.Errorf("tokens missing: %+v", out)
+			}
+		})
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	app := newTestApp(t)
+	user := signup(t, app, "user@example.com")
+
+	for _, token := range []string{"", "not-a-token", user.RefreshToken} {
+		if status := send(t, app, "GET", "/api/v1/me", token, nil, nil); status != 401 {
+			t.Errorf("token %q: status %d, want 401", token, status)
+		}
+	}
+
+	var me struct {
+		Email string `json:"email"`
+	}
+	if status := send(t, app, "GET", "/api/v1/me", user.AccessToken, nil, &me); status != 200 || me.Email != "user@example.com" {
+		t.Errorf("status %d %+v, want 200 user@example.com", status, me)
+	}
+}
+
+func refresh(t *testing.T, app *fiber.App, refreshToken string) (int, tokens) {
+	t.Helper()
+	var out tokens
+	status := send(t, app, "POST", "/api/v1/auth/refresh", "", fiber.Map{"refresh_token": refreshToken}, &out)
+	return status, out
+}
+
+func TestRefreshRotation(t *testing.T) {
+	app := newTestApp(t)
+	first := signup(t, app, "user@example.com").RefreshToken
+
+	status, second := refresh(t, app, first)
+	if status != 200 || second.RefreshToken == "" || second.RefreshToken == first {
+		t.Fatalf("refresh: status %d %+v, want 200 with a new token", status, second)
+	}
+	if status := send(t, app, "GET", "/api/v1/me", second.AccessToken, nil, nil); status != 200 {
+		t.Errorf("new access token: status %d, want 200", status)
+	}
+
+	// Reusing a rotated token looks like theft, so the token it was
+	// traded for stops working too.
+	if status, _ := refresh(t, app, first); status != 401 {
+		t.Errorf("reused token: status %d, want 401", status)
+	}
+	if status, _ := refresh(t, app, second.RefreshToken); status != 401 {
+		t.Errorf("token issued before the reuse: status %d, want 401", status)
+	}
+
+	if status, _ := refresh(t, app, "unknown"); status != 401 {
+		t.Errorf("unknown token: status %d, want 401", status)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	app := newTestApp(t)
+	user := signup(t, app, "user@example.com")
+
+	if status := send(t, app, "POST", "/api/v1/auth/logout", "", fiber.Map{"refresh_token": user.RefreshToken}, nil); status != 200 {
+		t.Fatalf("logout: status %d, want 200", status)
+	}
+	if status, _ := refresh(t, app, user.RefreshToken); status != 401 {
+		t.Errorf("refresh after logout: status %d, want 401", status)
+	}
+}
+
+func TestCategoriesRequireAdmin(t *testing.T) {
+	app := newTestApp(t)
+	user := signup(t, app, "user@example.com")
+	admin := signup(t, app, adminEmail)
+	body := fiber.Map{"name": "여행", "color": "#ff0000"}
+
+	if status := send(t, app, "POST", "/api/v1/categories", "", body, nil); status != 401 {
+		t.Errorf("anonymous: status %d, want 401", status)
+	}
+	if status := send(t, app, "POST", "/api/v1/categories", user.AccessToken, body, nil); status != 403 {
+		t.Errorf("user: status %d, want 403", status)
+	}
+	if status := send(t, app, "POST", "/api/v1/categories", admin.AccessToken, body, nil); status != 201 {
+		t.Errorf("admin: status %d, want 201", status)
+	}
+	if status := send(t, app, "GET", "/api/v1/categories", "", nil, nil); status != 200 {
+		t.Errorf("list: status %d, want 200", status)
+	}
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"dday-backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// exportBatchSize is how many D-Days an export reads from the store at a
+	// time.
+	exportBatchSize = 500
+
+	// maxImportRows caps the rows of one import, which go in one transaction.
+	maxImportRows = 5000
+
+	csvContentType = "text/csv; charset=utf-8"
+
+	// utf8BOM starts CSV exports so spreadsheet apps read Hangul correctly.
+	utf8BOM = "\ufeff"
+)
+
+// exportColumns are the CSV columns of an export. ImportDdays reads columns by
+// these names and ignores the ones a new D-Day does not take.
+var exportColumns = []string{
+	"id", "title", "target_date", "category", "memo", "is_important",
+	"recurrence", "recurrence_interval", "calendar_type", "lunar_date",
+	"is_leap_month", "tags", "created_at", "updated_at",
+}
+
+// importRow is one row of an import, or why it could not be read.
+type importRow struct {
+	req ddayRequest
+	err string
+}
+
+// rowError is the reason a row of an import was rejected. Row counts from 1,
+// not counting the CSV header.
+type rowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ExportDdays streams every D-Day GetDdays would list, unpaged, as CSV or
+// JSON. Rows are read in batches so the export never holds them all, except
+// for fuzzy searches, whose ranking needs every match.
+func (ctrl *DdayController) ExportDdays(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	format := ctrl.Query("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "csv" && format != "json" {
+		return ctrl.BadRequest("Invalid format. Use csv or json")
+	}
+
+	builder, err := ctrl.listQuery()
+	if err != nil {
+		return ctrl.listFailed(err)
+	}
+
+	fuzzy := ctrl.GetSearch() != "" && ctrl.Query("mode") == "fuzzy"
+	if !fuzzy {
+		builder.After(nil, exportBatchSize)
+	}
+	query, err := builder.Build()
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
+
+	// The stream is written after this handler returns, so it must not
+	// reach the request through ctrl.
+	manager, userID, today := ctrl.manager, ctrl.GetUserID(), ctrl.Today()
+	each := func(fn func(*models.DDay) error) error {
+		for {
+			ddays, err := manager.GetAll(userID, query)
+			if err != nil {
+				return err
+			}
+			for i := range ddays {
+				ddays[i].SetToday(today)
+				if err := fn(&ddays[i]); err != nil {
+					return err
+				}
+			}
+			if fuzzy || len(ddays) < exportBatchSize {
+				return nil
+			}
+
+			last := models.CursorKeyOf(ddays[len(ddays)-1], query.Sort())
+			if query, err = builder.After(&last, exportBatchSize).Build(); err != nil {
+				return err
+			}
+		}
+	}
+
+	write, contentType := writeJSON, fiber.MIMEApplicationJSONCharsetUTF8
+	if format == "csv" {
+		write, contentType = writeCSV, csvContentType
+	}
+
+	ctrl.Attachment("ddays." + format)
+	return ctrl.Stream(contentType, func(w *bufio.Writer) {
+		if err := write(w, each); err != nil {
+			// The status is already sent; the client sees a cut-off file.
+			log.Printf("D-Day export failed: %v", err)
+		}
+	})
+}
+
+// writeJSON writes the D-Days each yields as a JSON array.
+func writeJSON(w *bufio.Writer, each func(func(*models.DDay) error) error) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+	first := true
+	err := each(func(dday *models.DDay) error {
+		body, err := json.Marshal(dday)
+		if err != nil {
+			return err
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		_, err = w.Write(body)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.WriteString("]\n"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeCSV writes the D-Days each yields as CSV rows under exportColumns.
+func writeCSV(w *bufio.Writer, each func(func(*models.DDay) error) error) error {
+	if _, err := w.WriteString(utf8BOM); err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(exportColumns); err != nil {
+		return err
+	}
+	err := each(func(dday *models.DDay) error {
+		return out.Write([]string{
+			dday.ID,
+			dday.Title,
+			dday.TargetDate,
+			dday.Category,
+			dday.Memo,
+			strconv.FormatBool(dday.IsImportant),
+			dday.Recurrence,
+			strconv.Itoa(dday.RecurrenceInterval),
+			dday.CalendarType,
+			dday.LunarDate,
+			strconv.FormatBool(dday.IsLeapMonth),
+			strings.Join(dday.Tags, ","),
+			dday.CreatedAt.UTC().Format(time.RFC3339),
+			dday.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ImportDdays creates D-Days from a CSV or JSON file laid out like an export,
+// sent as the "file" field of a form or as the body. Each row is checked the
+// way CreateDday checks a request. If any row fails, nothing is imported and
+// the errors of every failing row are returned; otherwise all rows are
+// created in one transaction.
+func (ctrl *DdayController) ImportDdays(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	format := ctrl.Query("format")
+	if format == "" {
+		format = "json"
+	}
+
+	body, err := ctrl.Upload("file")
+	if err != nil {
+		return ctrl.BadRequest("File is required")
+	}
+
+	var rows []importRow
+	switch format {
+	case "csv":
+		rows, err = csvRows(body)
+	case "json":
+		rows, err = jsonRows(body)
+	default:
+		return ctrl.BadRequest("Invalid format. Use csv or json")
+	}
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
+	if len(rows) == 0 {
+		return ctrl.BadRequest("Nothing to import")
+	}
+	if len(rows) > maxImportRows {
+		return ctrl.BadRequest(fmt.Sprintf("A file may hold at most %d rows", maxImportRows))
+	}
+
+	ddays := make([]*models.DDay, 0, len(rows))
+	var rowErrors []rowError
+	for i, row := range rows {
+		message := row.err
+		if message == "" {
+			var dday *models.DDay
+			dday, message, err = ctrl.newDday(row.req)
+			if err != nil {
+				return ctrl.InternalServerError("Failed to load categories")
+			}
+			if message == "" {
+				ddays = append(ddays, dday)
+				continue
+			}
+		}
+		rowErrors = append(rowErrors, rowError{Row: i + 1, Error: message})
+	}
+	if len(rowErrors) > 0 {
+		return ctrl.Status(400).JSON(fiber.Map{
+			"error":  "Some rows cannot be imported",
+			"errors": rowErrors,
+		})
+	}
+
+	tx, err := ctrl.manager.Begin()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to import D-Days")
+	}
+	defer tx.Rollback()
+
+	imported := make([]models.DDay, 0, len(ddays))
+	for _, dday := range ddays {
+		dday.ID = uuid.New().String()
+		dday.CreatedAt = time.Now().UTC()
+		if err := ctrl.manager.CreateWithTx(tx, ctrl.GetUserID(), dday); err != nil {
+			return ctrl.InternalServerError("Failed to import D-Days")
+		}
+		saved, err := ctrl.manager.GetByIDWithTx(tx, ctrl.GetUserID(), dday.ID)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to import D-Days")
+		}
+		imported = append(imported, *saved)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ctrl.InternalServerError("Failed to import D-Days")
+	}
+
+	return ctrl.Created(fiber.Map{
+		"data":     ctrl.Countdown(imported),
+		"imported": len(imported),
+	})
+}
+
+// jsonRows reads a JSON array of D-Days. A row that is not an object of the
+// expected fields fails on its own.
+func jsonRows(body []byte) ([]importRow, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, importError("Invalid JSON file. Send an array of D-Days")
+	}
+
+	rows := make([]importRow, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &rows[i].req); err != nil {
+			rows[i].err = "Invalid row"
+		}
+	}
+	return rows, nil
+}
+
+// csvRows reads CSV rows under a header naming their columns. Columns other
+// than those of ddayRequest are ignored, and missing ones read as empty.
+func csvRows(body []byte) ([]importRow, error) {
+	in := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte(utf8BOM))))
+	in.FieldsPerRecord = -1
+
+	header, err := in.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, importError("Invalid CSV file")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, importError("CSV header must include a title column")
+	}
+
+	var rows []importRow
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, importError("Invalid CSV file")
+		}
+		if len(rows) == maxImportRows {
+			return nil, importError(fmt.Sprintf("A file may hold at most %d rows", maxImportRows))
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rows = append(rows, csvRow(field))
+	}
+}
+
+// csvRow maps the fields of a CSV record onto a request, failing the row
+// when a boolean or number column cannot be read.
+func csvRow(field func(string) string) importRow {
+	row := importRow{req: ddayRequest{
+		Title:        field("title"),
+		TargetDate:   field("target_date"),
+		Category:     field("category"),
+		Memo:         field("memo"),
+		Recurrence:   field("recurrence"),
+		CalendarType: field("calendar_type"),
+		LunarDate:    field("lunar_date"),
+	}}
+	if value := field("tags"); value != "" {
+		row.req.Tags = strings.Split(value, ",")
+	}
+
+	var err error
+	if value := field("is_important"); value != "" {
+		if row.req.IsImportant, err = strconv.ParseBool(value); err != nil {
+			row.err = "Invalid is_important. Use true or false"
+			return row
+		}
+	}
+	if value := field("is_leap_month"); value != "" {
+		if row.req.IsLeapMonth, err = strconv.ParseBool(value); err != nil {
+			row.err = "Invalid is_leap_month. Use true or false"
+			return row
+		}
+	}
+	if value := field("recurrence_interval"); value != "" {
+		if row.req.RecurrenceInterval, err = strconv.Atoi(value); err != nil {
+			row.err = invalidRecurrence
+			return row
+		}
+	}
+	return row
+}
@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestImportDdaysIsAllOrNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		rows   []int
+	}{
+		{"csv", "csv", "title,target_date,category,is_important\n" +
+			"시험,2025-06-01,학업,true\n" +
+			"생일,2025-13-01,개인,false\n" +
+			"여행,2025-08-01,개인,false\n" +
+			"회의,2025-09-01,없는 카테고리,false\n", []int{2, 4}},
+		{"csv bad boolean", "csv", "title,is_important\n시험,maybe\n", []int{1}},
+		{"json", "json", `[{"title":"시험","target_date":"2025-06-01"},{"title":""},"oops"]`, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			user := signup(t, app, "user@example.com")
+
+			var out struct {
+				Errors []struct {
+					Row   int    `json:"row"`
+					Error string `json:"error"`
+				} `json:"errors"`
+			}
+			if status := send(t, app, "POST", "/api/v1/ddays/import?format="+tt.format, user.AccessToken, []byte(tt.file), &out); status != 400 {
+				t.Fatalf("status %d, want 400", status)
+			}
+			var rows []int
+			for _, e := range out.Errors {
+				rows = append(rows, e.Row)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("failed rows %v, want %v", rows, tt.rows)
+			}
+			if n := countDdays(t, app, user.AccessToken); n != 0 {
+				t.Errorf("have %d D-Days after a failed import, want 0", n)
+			}
+		})
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			app := newTestApp(t)
+			from := signup(t, app, "from@example.com")
+			to := signup(t, app, "to@example.com")
+			createDday(t, app, from.AccessToken, "시험, 기말", "2025-06-01")
+			createDday(t, app, from.AccessToken, "생일", "2025-03-01")
+
+			var file []byte
+			if status := send(t, app, "GET", "/api/v1/ddays/export?format="+format, from.AccessToken, nil, &file); status != 200 {
+				t.Fatalf("export: status %d, want 200", status)
+			}
+
+			var out struct {
+				Imported int            `json:"imported"`
+				Data     []ddayResponse `json:"data"`
+			}
+			if status := send(t, app, "POST", "/api/v1/ddays/import?format="+format, to.AccessToken, file, &out); status != 201 {
+				t.Fatalf("import: status %d, want 201", status)
+			}
+			var titles []string
+			for _, dday := range out.Data {
+				titles = append(titles, dday.Title)
+			}
+			sort.Strings(titles)
+			if out.Imported != 2 || len(titles) != 2 || titles[0] != "생일" || titles[1] != "시험, 기말" {
+				t.Errorf("imported %d %q, want 2 [생일 시험, 기말]", out.Imported, titles)
+			}
+			if n := countDdays(t, app, from.AccessToken); n != 2 {
+				t.Errorf("exporting user has %d D-Days, want 2", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"database/sql"
+	"dday-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxBatchOperations caps the operations of one batch, which run in one
+// transaction.
+const maxBatchOperations = 100
+
+const (
+	batchCreate = "create"
+	batchUpdate = "update"
+	batchDelete = "delete"
+)
+
+// batchOperation is one entry of a batch. Update takes the same data as
+// UpdateDday and replaces the whole D-Day. A non-zero Version makes an update
+// or delete conditional, like If-Match on the single-item routes.
+type batchOperation struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+
+	dday *models.DDay
+}
+
+// batchResult is the outcome of one operation. When the batch fails, the
+// failing operation carries its own status and every other one 424 Failed
+// Dependency, as none of them were saved.
+type batchResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	Status int          `json:"status"`
+	ID     string       `json:"id,omitempty"`
+	Data   *models.DDay `json:"data,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// batchFailure is why an operation stopped the batch.
+type batchFailure struct {
+	status  int
+	message string
+}
+
+func (f *batchFailure) Error() string {
+	return f.message
+}
+
+// BatchDdays applies a list of create, update and delete operations in one
+// transaction: either all of them are saved or none is.
+func (ctrl *DdayController) BatchDdays(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req struct {
+		Operations []batchOperation `json:"operations"`
+	}
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+	ops := req.Operations
+	if len(ops) == 0 {
+		return ctrl.BadRequest("Operations are required")
+	}
+	if len(ops) > maxBatchOperations {
+		return ctrl.BadRequest(fmt.Sprintf("A batch may hold at most %d operations", maxBatchOperations))
+	}
+
+	// Everything that needs no D-Day is checked before the transaction,
+	// which in the memory store holds the lock categories use too.
+	for i := range ops {
+		if err := ctrl.prepare(&ops[i]); err != nil {
+			return ctrl.batchFailed(ops, i, err)
+		}
+	}
+
+	tx, err := ctrl.manager.Begin()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to apply batch")
+	}
+	defer tx.Rollback()
+
+	results := make([]batchResult, len(ops))
+	for i := range ops {
+		result, err := ctrl.apply(tx, &ops[i])
+		if err != nil {
+			return ctrl.batchFailed(ops, i, err)
+		}
+		result.Index = i
+		results[i] = result
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ctrl.InternalServerError("Failed to apply batch")
+	}
+
+	today := ctrl.Today()
+	for _, result := range results {
+		if result.Data != nil {
+			result.Data.SetToday(today)
+		}
+	}
+	return ctrl.Success(fiber.Map{
+		"results": results,
+	})
+}
+
+// prepare checks an operation on its own and, for create and update, builds
+// the D-Day it writes.
+func (ctrl *DdayController) prepare(op *batchOperation) error {
+	switch op.Op {
+	case batchCreate:
+	case batchUpdate, batchDelete:
+		if op.ID == "" {
+			return &batchFailure{400, "ID is required"}
+		}
+	default:
+		return &batchFailure{400, "Invalid op. Use create, update or delete"}
+	}
+	if op.Op == batchDelete {
+		return nil
+	}
+
+	var data ddayRequest
+	if len(op.Data) == 0 || json.Unmarshal(op.Data, &data) != nil {
+		return &batchFailure{400, "Invalid data"}
+	}
+	dday, message, err := ctrl.newDday(data)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		return &batchFailure{400, message}
+	}
+	op.dday = dday
+	return nil
+}
+
+// apply runs a prepared operation inside tx.
+func (ctrl *DdayController) apply(tx models.Tx, op *batchOperation) (batchResult, error) {
+	userID := ctrl.GetUserID()
+	result := batchResult{Op: op.Op, ID: op.ID, Status: 200}
+
+	if op.Op == batchCreate {
+		op.dday.ID = uuid.New().String()
+		op.dday.CreatedAt = time.Now().UTC()
+		if err := ctrl.manager.CreateWithTx(tx, userID, op.dday); err != nil {
+			return result, err
+		}
+		saved, err := ctrl.manager.GetByIDWithTx(tx, userID, op.dday.ID)
+		if err != nil {
+			return result, err
+		}
+		result.Status, result.ID, result.Data = 201, saved.ID, saved
+		return result, nil
+	}
+
+	existing, err := ctrl.manager.GetByIDWithTx(tx, userID, op.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, &batchFailure{404, "D-Day not found"}
+	}
+	if err != nil {
+		return result, err
+	}
+	if op.Version > 0 && op.Version != existing.Version {
+		return result, &batchFailure{412, "D-Day has been modified"}
+	}
+
+	switch op.Op {
+	case batchUpdate:
+		op.dday.ID = op.ID
+		op.dday.CreatedAt = existing.CreatedAt
+		op.dday.Version = op.Version
+		err = ctrl.manager.UpdateWithTx(tx, userID, op.ID, op.dday)
+	case batchDelete:
+		err = ctrl.manager.DeleteWithTx(tx, userID, op.ID, op.Version)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, &batchFailure{404, "D-Day not found"}
+	}
+	if errors.Is(err, models.ErrVersionConflict) {
+		return result, &batchFailure{412, "D-Day has been modified"}
+	}
+	if errors.Is(err, models.ErrForbidden) && op.Op == batchDelete {
+		return result, &batchFailure{403, "Owner role required"}
+	}
+	if errors.Is(err, models.ErrForbidden) {
+		return result, &batchFailure{403, "Editor role required"}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if op.Op == batchUpdate {
+		if result.Data, err = ctrl.manager.GetByIDWithTx(tx, userID, op.ID); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// batchFailed answers a batch that stopped at operation index, with the
+// failing operation's status.
+func (ctrl *DdayController) batchFailed(ops []batchOperation, index int, err error) error {
+	failure := &batchFailure{500, "Failed to apply batch"}
+	errors.As(err, &failure)
+
+	results := make([]batchResult, len(ops))
+	for i, op := range ops {
+		results[i] = batchResult{Index: i, Op: op.Op, ID: op.ID, Status: 424, Error: "Not applied"}
+	}
+	results[index].Status, results[index].Error = failure.status, failure.message
+
+	return ctrl.Status(failure.status).JSON(fiber.Map{
+		"error":   fmt.Sprintf("Operation %d failed: %s", index, failure.message),
+		"index":   index,
+		"results": results,
+	})
+}
@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type batchResponse struct {
+	Error   string `json:"error"`
+	Index   int    `json:"index"`
+	Results []struct {
+		Index  int           `json:"index"`
+		Status int           `json:"status"`
+		ID     string        `json:"id"`
+		Data   *ddayResponse `json:"data"`
+		Error  string        `json:"error"`
+	} `json:"results"`
+}
+
+func TestBatchDdays(t *testing.T) {
+	app := newTestApp(t)
+	user := signup(t, app, "user@example.com")
+	keep := createDday(t, app, user.AccessToken, "시험", "2025-06-01")
+	drop := createDday(t, app, user.AccessToken, "생일", "2025-03-01")
+
+	ops := []fiber.Map{
+		{"op": "create", "data": fiber.Map{"title": "여행", "target_date": "2025-08-01", "category": "개인"}},
+		{"op": "update", "id": keep, "version": 1, "data": fiber.Map{"title": "기말고사", "target_date": "2025-06-02", "category": "학업"}},
+		{"op": "delete", "id": drop},
+	}
+	var out batchResponse
+	if status := send(t, app, "POST", "/api/v1/ddays/batch", user.AccessToken, fiber.Map{"operations": ops}, &out); status != 200 {
+		t.Fatalf("status %d %q, want 200", status, out.Error)
+	}
+
+	statuses := []int{201, 200, 200}
+	if len(out.Results) != len(statuses) {
+		t.Fatalf("got %d results, want %d", len(out.Results), len(statuses))
+	}
+	for i, result := range out.Results {
+		if result.Index != i || result.Status != statuses[i] {
+			t.Errorf("result %d = %+v, want status %d", i, result, statuses[i])
+		}
+	}
+	if data := out.Results[1].Data; data == nil || data.Title != "기말고사" || data.Version != 2 {
+		t.Errorf("updated D-Day = %+v, want 기말고사 at version 2", data)
+	}
+	if n := countDdays(t, app, user.AccessToken); n != 2 {
+		t.Errorf("have %d D-Days, want 2", n)
+	}
+}
+
+func TestBatchDdaysRollsBack(t *testing.T) {
+	create := fiber.Map{"op": "create", "data": fiber.Map{"title": "여행", "target_date": "2025-08-01", "category": "개인"}}
+
+	tests := []struct {
+		name   string
+		op     func(id string) fiber.Map
+		status int
+	}{
+		{"invalid op", func(id string) fiber.Map { return fiber.Map{"op": "move", "id": id} }, 400},
+		{"invalid data", func(id string) fiber.Map {
+			return fiber.Map{"op": "update", "id": id, "data": fiber.Map{"title": "시험", "target_date": "내일"}}
+		}, 400},
+		{"missing D-Day", func(string) fiber.Map { return fiber.Map{"op": "delete", "id": "missing"} }, 404},
+		{"stale version", func(id string) fiber.Map { return fiber.Map{"op": "delete", "id": id, "version": 7} }, 412},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			user := signup(t, app, "user@example.com")
+			id := createDday(t, app, user.AccessToken, "시험", "2025-06-01")
+			deleted := createDday(t, app, user.AccessToken, "생일", "2025-03-01")
+
+			ops := []fiber.Map{create, {"op": "delete", "id": deleted}, tt.op(id), create}
+			var out batchResponse
+			if status := send(t, app, "POST", "/api/v1/ddays/batch", user.AccessToken, fiber.Map{"operations": ops}, &out); status != tt.status {
+				t.Fatalf("status %d %q, want %d", status, out.Error, tt.status)
+			}
+			if out.Index != 2 || len(out.Results) != len(ops) {
+				t.Fatalf("index %d with %d results, want 2 with %d", out.Index, len(out.Results), len(ops))
+			}
+			for i, result := range out.Results {
+				want := 424
+				if i == 2 {
+					want = tt.status
+				}
+				if result.Status != want || result.Error == "" || result.Data != nil {
+					t.Errorf("result %d = %+v, want an unsaved status %d", i, result, want)
+				}
+			}
+
+			// The create and delete before the failure were undone.
+			var dday ddayResponse
+			if status := send(t, app, "GET", "/api/v1/ddays/"+deleted, user.AccessToken, nil, &dday); status != 200 || dday.Version != 1 {
+				t.Errorf("D-Day deleted earlier in the batch: status %d version %d, want 200 1", status, dday.Version)
+			}
+			if n := countDdays(t, app, user.AccessToken); n != 2 {
+				t.Errorf("have %d D-Days, want 2", n)
+			}
+		})
+	}
+}
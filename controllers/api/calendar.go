@@ -0,0 +1,67 @@
+package api
+
+import (
+	"dday-backend/controllers"
+	"dday-backend/models/lunar"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarController struct {
+	*controllers.Controller
+}
+
+func NewCalendarController() *CalendarController {
+	return &CalendarController{}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *CalendarController) with(c *fiber.Ctx) *CalendarController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+// Convert converts ?solar=YYYY-MM-DD to the lunar calendar, or
+// ?lunar=YYYY-MM-DD&leap=true to the solar one.
+func (ctrl *CalendarController) Convert(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	solarValue, lunarValue := ctrl.Query("solar"), ctrl.Query("lunar")
+
+	var solar time.Time
+	var date lunar.Date
+	var err error
+	switch {
+	case solarValue != "" && lunarValue != "":
+		return ctrl.BadRequest("Pass either solar or lunar, not both")
+	case solarValue != "":
+		if solar, err = time.Parse("2006-01-02", solarValue); err != nil {
+			return ctrl.BadRequest("Invalid solar date format. Use YYYY-MM-DD")
+		}
+		date, err = lunar.FromSolar(solar)
+	case lunarValue != "":
+		if date, err = lunar.Parse(lunarValue, ctrl.Query("leap") == "true"); err == nil {
+			solar, err = lunar.ToSolar(date)
+		}
+	default:
+		return ctrl.BadRequest("solar or lunar is required")
+	}
+
+	if errors.Is(err, lunar.ErrOutOfRange) {
+		return ctrl.BadRequest(fmt.Sprintf("Lunar dates must be between %d and %d", lunar.MinYear, lunar.MaxYear))
+	}
+	if err != nil {
+		return ctrl.BadRequest("No such lunar date")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"solar":         solar.Format("2006-01-02"),
+		"lunar":         date.String(),
+		"is_leap_month": date.Leap,
+		"leap_month":    lunar.LeapMonth(date.Year),
+	})
+}
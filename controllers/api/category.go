@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"dday-backend/controllers"
+	"dday-backend/models"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type CategoryController struct {
+	*controllers.Controller
+	manager models.CategoryStore
+}
+
+func NewCategoryController(store models.CategoryStore) *CategoryController {
+	return &CategoryController{manager: store}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *CategoryController) with(c *fiber.Ctx) *CategoryController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+type categoryRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+}
+
+// parse reads and validates the request body, returning an error message
+// for the client when it is invalid.
+func (ctrl *CategoryController) parse() (*models.Category, string) {
+	var req categoryRequest
+	if err := ctrl.Body(&req); err != nil {
+		return nil, "Invalid request body"
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "Name is required"
+	}
+	if utf8.RuneCountInString(name) > 50 {
+		return nil, "Name must be at most 50 characters"
+	}
+
+	if req.Color != "" && !colorPattern.MatchString(req.Color) {
+		return nil, "Invalid color. Use #RRGGBB"
+	}
+
+	icon := strings.TrimSpace(req.Icon)
+	if utf8.RuneCountInString(icon) > 50 {
+		return nil, "Icon must be at most 50 characters"
+	}
+
+	return &models.Category{Name: name, Color: req.Color, Icon: icon}, ""
+}
+
+func (ctrl *CategoryController) GetCategories(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	categories, err := ctrl.manager.GetAll()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch categories")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": categories,
+	})
+}
+
+func (ctrl *CategoryController) GetCategory(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.ParamsInt("id")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid category ID")
+	}
+
+	category, err := ctrl.manager.GetByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("Category not found")
+		}
+		return ctrl.InternalServerError("Failed to fetch category")
+	}
+
+	return ctrl.Success(category)
+}
+
+func (ctrl *CategoryController) CreateCategory(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	category, message := ctrl.parse()
+	if category == nil {
+		return ctrl.BadRequest(message)
+	}
+
+	if err := ctrl.manager.Create(category); err != nil {
+		if errors.Is(err, models.ErrCategoryExists) {
+			return ctrl.Conflict("Category name already exists")
+		}
+		return ctrl.InternalServerError("Failed to create category")
+	}
+
+	return ctrl.Created(category)
+}
+
+// UpdateCategory replaces a category; a new name is applied to all of its
+// D-Days as well.
+func (ctrl *CategoryController) UpdateCategory(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.ParamsInt("id")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid category ID")
+	}
+
+	category, message := ctrl.parse()
+	if category == nil {
+		return ctrl.BadRequest(message)
+	}
+
+	if err := ctrl.manager.Update(ctrl.GetUserID(), id, category); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctrl.NotFound("Category not found")
+		case errors.Is(err, models.ErrCategoryExists):
+			return ctrl.Conflict("Category name already exists")
+		}
+		return ctrl.InternalServerError("Failed to update category")
+	}
+
+	updated, err := ctrl.manager.GetByID(id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch updated category")
+	}
+
+	return ctrl.Success(updated)
+}
+
+// DeleteCategory removes a category after moving its D-Days to the category
+// named by reassignTo, or to the default category when it is omitted.
+func (ctrl *CategoryController) DeleteCategory(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.ParamsInt("id")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid category ID")
+	}
+
+	reassigned, err := ctrl.manager.Delete(ctrl.GetUserID(), id, strings.TrimSpace(ctrl.Query("reassignTo")))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctrl.NotFound("Category not found")
+		case errors.Is(err, models.ErrInvalidFallback):
+			return ctrl.BadRequest("Invalid reassignTo category")
+		case errors.Is(err, models.ErrLastCategory):
+			return ctrl.BadRequest("Cannot delete the last category")
+		}
+		return ctrl.InternalServerError("Failed to delete category")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"message":    "Category deleted",
+		"reassigned": reassigned,
+	})
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"database/sql"
 	"dday-backend/controllers"
 	"dday-backend/models"
-	"dday-backend/models/dday"
+	"dday-backend/models/hangul"
+	"dday-backend/models/lunar"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,214 +15,701 @@ import (
 	"github.com/google/uuid"
 )
 
+const invalidRecurrence = "Invalid recurrence. Use none, daily, weekly, monthly or yearly with an interval of 1 to 999"
+
+var invalidTags = fmt.Sprintf("Invalid tags. Use at most %d tags of 1 to %d characters without commas", models.MaxTags, models.MaxTagLength)
+
+// maxOccurrences caps how many dates one occurrences request expands.
+const maxOccurrences = 500
+
+// calendarError explains to the client why ResolveCalendar rejected a D-Day.
+func calendarError(err error) string {
+	switch {
+	case errors.Is(err, models.ErrInvalidCalendar):
+		return "Invalid calendar type. Use solar or lunar"
+	case errors.Is(err, models.ErrLunarRecurrence):
+		return "Lunar D-Days can only repeat yearly"
+	case errors.Is(err, lunar.ErrOutOfRange):
+		return fmt.Sprintf("Lunar dates must be between %d and %d", lunar.MinYear, lunar.MaxYear)
+	}
+	return "Invalid lunar date. Use an existing YYYY-MM-DD on the lunar calendar"
+}
+
 type DdayController struct {
 	*controllers.Controller
-	manager *models.DdayManager
+	manager    models.DdayStore
+	categories models.CategoryStore
+}
+
+func NewDdayController(store models.DdayStore, categories models.CategoryStore) *DdayController {
+	return &DdayController{manager: store, categories: categories}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *DdayController) with(c *fiber.Ctx) *DdayController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
 }
 
-func NewDdayController(c *fiber.Ctx) *DdayController {
-	return &DdayController{
-		Controller: controllers.NewController(c),
-		manager:    models.NewDdayManager(),
+// categoryFilter reports whether the list should be narrowed to category;
+// unknown categories are ignored, as they always have been.
+func (ctrl *DdayController) categoryFilter(category string) (bool, error) {
+	if category == "" {
+		return false, nil
 	}
+	return ctrl.categories.Exists(category)
 }
 
-func (ctrl *DdayController) GetDdays(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+// resolveCategory validates a requested category, substituting the default
+// for an empty one.
+func (ctrl *DdayController) resolveCategory(category string) (string, bool, error) {
+	if category == "" {
+		name, err := ctrl.categories.Default()
+		return name, err == nil, err
+	}
+	ok, err := ctrl.categories.Exists(category)
+	return category, ok, err
+}
 
-	page, pageSize := ctrl.GetPagination()
-	orderBy := ctrl.GetOrderBy()
+// errInvalidSearchMode rejects a search mode listQuery does not know.
+var errInvalidSearchMode = errors.New("invalid search mode")
+
+// listQuery reads the search, filter, status and sort parameters GetDdays
+// shares with ExportDdays.
+func (ctrl *DdayController) listQuery() (*models.QueryBuilder, error) {
 	search := ctrl.GetSearch()
 	category := ctrl.GetCategory()
 	isImportant := ctrl.GetIsImportant()
 
-	var args []interface{}
+	builder := models.NewQuery()
 
 	if search != "" {
-		args = append(args, models.NewCustom("(d_title LIKE ? OR d_memo LIKE ?)", "%"+search+"%", "%"+search+"%"))
+		switch ctrl.Query("mode") {
+		case "":
+			builder.Search(search)
+		case "chosung":
+			builder.Where(models.FieldTitleChosung, models.OpLike, hangul.Choseong(search))
+		case "fuzzy":
+			builder.Fuzzy(search)
+		default:
+			return nil, errInvalidSearchMode
+		}
 	}
 
-	if category != "" && dday.IsValidCategory(category) {
-		args = append(args, models.NewWhere("d_category", category, "="))
+	if ok, err := ctrl.categoryFilter(category); err != nil {
+		return nil, err
+	} else if ok {
+		builder.Where(models.FieldCategory, models.OpEq, category)
 	}
 
 	if isImportant != nil {
-		args = append(args, models.NewWhere("d_is_important", *isImportant, "="))
+		builder.Where(models.FieldIsImportant, models.OpEq, *isImportant)
+	}
+
+	if status := ctrl.Query("status"); status != "" {
+		builder.Status(status, ctrl.Today())
+	}
+
+	if tags := ctrl.Query("tags"); tags != "" {
+		mode := ctrl.Query("tagMode")
+		if mode == "" {
+			mode = models.TagModeAny
+		}
+		builder.Tags(strings.Split(tags, ","), mode)
+	}
+
+	if sort, ok := ctrl.GetSort(); ok {
+		builder.OrderBy(sort.Field, sort.Desc)
 	}
+	return builder, nil
+}
 
-	if orderBy != "" {
-		args = append(args, models.NewOrdering(orderBy))
+// listFailed answers a listQuery error.
+func (ctrl *DdayController) listFailed(err error) error {
+	if errors.Is(err, errInvalidSearchMode) {
+		return ctrl.BadRequest("Invalid search mode")
 	}
+	return ctrl.InternalServerError("Failed to load categories")
+}
 
-	args = append(args, models.NewPaging(page, pageSize))
+func (ctrl *DdayController) GetDdays(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
 
-	ddays, err := ctrl.manager.GetAll(args...)
+	page, pageSize := ctrl.GetPagination()
+
+	builder, err := ctrl.listQuery()
 	if err != nil {
-		return ctrl.InternalServerError("Failed to fetch D-Days")
+		return ctrl.listFailed(err)
 	}
 
-	totalCount, err := ctrl.manager.Count(args[:len(args)-1]...)
+	if cursor, ok := ctrl.GetCursor(); ok {
+		return ctrl.getDdaysAfter(builder, cursor, pageSize)
+	}
+
+	query, err := builder.Page(page, pageSize).Build()
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
+
+	ddays, err := ctrl.manager.GetAll(ctrl.GetUserID(), query)
 	if err != nil {
-		return ctrl.InternalServerError("Failed to count D-Days")
+		return ctrl.InternalServerError("Failed to fetch D-Days")
+	}
+
+	pagination := fiber.Map{
+		"page":     page,
+		"pageSize": pageSize,
+	}
+
+	if ctrl.GetIncludeCount(true) {
+		totalCount, err := ctrl.manager.Count(ctrl.GetUserID(), query)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to count D-Days")
+		}
+		pagination["totalCount"] = totalCount
+		pagination["totalPages"] = (totalCount + pageSize - 1) / pageSize
 	}
 
 	response := fiber.Map{
-		"data":       ddays,
-		"pagination": fiber.Map{
-			"page":       page,
-			"pageSize":   pageSize,
-			"totalCount": totalCount,
-			"totalPages": (totalCount + pageSize - 1) / pageSize,
-		},
+		"data":       ctrl.Countdown(ddays),
+		"pagination": pagination,
 	}
 
 	return ctrl.Success(response)
 }
 
-func (ctrl *DdayController) CreateDday(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+// getDdaysAfter serves GetDdays in keyset mode: the page after cursor (the
+// first page when cursor is empty) plus an opaque nextCursor.
+func (ctrl *DdayController) getDdaysAfter(builder *models.QueryBuilder, cursor string, pageSize int) error {
+	var after *models.CursorKey
+	if cursor != "" {
+		key, err := models.DecodeCursor(cursor)
+		if err != nil {
+			return ctrl.BadRequest("Invalid cursor")
+		}
+		after = key
+	}
 
-	var req struct {
-		Title       string `json:"title"`
-		TargetDate  string `json:"target_date"`
-		Category    string `json:"category"`
-		Memo        string `json:"memo"`
-		IsImportant bool   `json:"is_important"`
+	// Fetch one extra row to learn whether another page follows.
+	query, err := builder.After(after, pageSize+1).Build()
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return ctrl.BadRequest("Cursor does not match the requested ordering")
+	}
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
 	}
 
-	if err := ctrl.Body(&req); err != nil {
-		return ctrl.BadRequest("Invalid request body")
+	ddays, err := ctrl.manager.GetAll(ctrl.GetUserID(), query)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch D-Days")
+	}
+
+	hasMore := len(ddays) > pageSize
+	if hasMore {
+		ddays = ddays[:pageSize]
 	}
 
+	pagination := fiber.Map{
+		"pageSize":   pageSize,
+		"hasMore":    hasMore,
+		"nextCursor": nil,
+	}
+	if hasMore {
+		pagination["nextCursor"] = models.EncodeCursor(models.CursorKeyOf(ddays[len(ddays)-1], query.Sort()))
+	}
+
+	if ctrl.GetIncludeCount(false) {
+		totalCount, err := ctrl.manager.Count(ctrl.GetUserID(), query)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to count D-Days")
+		}
+		pagination["totalCount"] = totalCount
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data":       ctrl.Countdown(ddays),
+		"pagination": pagination,
+	})
+}
+
+// ddayRequest is the body of CreateDday and UpdateDday, and a row of
+// ImportDdays. Omitting tags on an update keeps the current ones.
+type ddayRequest struct {
+	Title              string   `json:"title"`
+	TargetDate         string   `json:"target_date"`
+	Category           string   `json:"category"`
+	Memo               string   `json:"memo"`
+	IsImportant        bool     `json:"is_important"`
+	Recurrence         string   `json:"recurrence"`
+	RecurrenceInterval int      `json:"recurrence_interval"`
+	CalendarType       string   `json:"calendar_type"`
+	LunarDate          string   `json:"lunar_date"`
+	IsLeapMonth        bool     `json:"is_leap_month"`
+	Tags               []string `json:"tags"`
+}
+
+// newDday validates req and builds the D-Day it describes, leaving the ID and
+// timestamps to the caller. A non-empty message explains to the client what
+// is wrong with req; err is a failure to load categories.
+func (ctrl *DdayController) newDday(req ddayRequest) (*models.DDay, string, error) {
 	if strings.TrimSpace(req.Title) == "" {
-		return ctrl.BadRequest("Title is required")
+		return nil, "Title is required", nil
 	}
 
-	if req.TargetDate == "" {
-		return ctrl.BadRequest("Target date is required")
+	// A lunar D-Day's target date is computed from its lunar date.
+	if req.CalendarType != models.CalendarLunar {
+		if req.TargetDate == "" {
+			return nil, "Target date is required", nil
+		}
+
+		if _, err := time.Parse("2006-01-02", req.TargetDate); err != nil {
+			return nil, "Invalid target date format. Use YYYY-MM-DD", nil
+		}
 	}
 
-	if _, err := time.Parse("2006-01-02", req.TargetDate); err != nil {
-		return ctrl.BadRequest("Invalid target date format. Use YYYY-MM-DD")
+	recurrence, interval, err := models.ParseRecurrence(req.Recurrence, req.RecurrenceInterval)
+	if err != nil {
+		return nil, invalidRecurrence, nil
 	}
 
-	if req.Category == "" {
-		req.Category = dday.GetDefaultCategory()
-	} else if !dday.IsValidCategory(req.Category) {
-		return ctrl.BadRequest("Invalid category")
+	var tags []string
+	if req.Tags != nil {
+		if tags, err = models.NormalizeTags(req.Tags); err != nil {
+			return nil, invalidTags, nil
+		}
+	}
+
+	category, ok, err := ctrl.resolveCategory(req.Category)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "Invalid category", nil
+	}
+
+	dday := &models.DDay{
+		Title:              strings.TrimSpace(req.Title),
+		TargetDate:         req.TargetDate,
+		Category:           category,
+		Memo:               strings.TrimSpace(req.Memo),
+		IsImportant:        req.IsImportant,
+		Recurrence:         recurrence,
+		RecurrenceInterval: interval,
+		CalendarType:       req.CalendarType,
+		LunarDate:          req.LunarDate,
+		IsLeapMonth:        req.IsLeapMonth,
+		Tags:               tags,
+	}
+	if err := dday.ResolveCalendar(); err != nil {
+		return nil, calendarError(err), nil
+	}
+	return dday, "", nil
+}
+
+func (ctrl *DdayController) CreateDday(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req ddayRequest
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
 	}
 
-	newDday := &models.DDay{
-		ID:          uuid.New().String(),
-		Title:       strings.TrimSpace(req.Title),
-		TargetDate:  req.TargetDate,
-		Category:    req.Category,
-		Memo:        strings.TrimSpace(req.Memo),
-		IsImportant: req.IsImportant,
-		CreatedAt:   time.Now(),
+	newDday, message, err := ctrl.newDday(req)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load categories")
 	}
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+	newDday.ID = uuid.New().String()
+	newDday.CreatedAt = time.Now().UTC()
 
-	if err := ctrl.manager.Create(newDday); err != nil {
+	if err := ctrl.manager.Create(ctrl.GetUserID(), newDday); err != nil {
 		return ctrl.InternalServerError("Failed to create D-Day")
 	}
 
-	return ctrl.Created(newDday)
+	saved, err := ctrl.manager.GetByID(ctrl.GetUserID(), newDday.ID)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch created D-Day")
+	}
+
+	saved.SetToday(ctrl.Today())
+	ctrl.SetETag(saved.ETag())
+	return ctrl.Created(saved)
 }
 
 func (ctrl *DdayController) GetDday(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	id := ctrl.Params("id")
 	if id == "" {
 		return ctrl.BadRequest("ID is required")
 	}
 
-	dday, err := ctrl.manager.GetByID(id)
+	dday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
 	if err != nil {
 		return ctrl.NotFound("D-Day not found")
 	}
 
+	etag := dday.ETag()
+	ctrl.SetETag(etag)
+	if ctrl.IfNoneMatch(etag) {
+		return ctrl.NotModified()
+	}
+
+	dday.SetToday(ctrl.Today())
 	return ctrl.Success(dday)
 }
 
 func (ctrl *DdayController) UpdateDday(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	id := ctrl.Params("id")
 	if id == "" {
 		return ctrl.BadRequest("ID is required")
 	}
 
-	existingDday, err := ctrl.manager.GetByID(id)
+	existingDday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
 	if err != nil {
 		return ctrl.NotFound("D-Day not found")
 	}
 
-	var req struct {
-		Title       string `json:"title"`
-		TargetDate  string `json:"target_date"`
-		Category    string `json:"category"`
-		Memo        string `json:"memo"`
-		IsImportant bool   `json:"is_important"`
+	if !ctrl.IfMatch(existingDday.ETag()) {
+		return ctrl.PreconditionFailed("D-Day has been modified")
 	}
 
+	var req ddayRequest
 	if err := ctrl.Body(&req); err != nil {
 		return ctrl.BadRequest("Invalid request body")
 	}
 
-	if strings.TrimSpace(req.Title) == "" {
-		return ctrl.BadRequest("Title is required")
+	updatedDday, message, err := ctrl.newDday(req)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load categories")
+	}
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+	updatedDday.ID = id
+	updatedDday.CreatedAt = existingDday.CreatedAt
+	if ctrl.HasIfMatch() {
+		updatedDday.Version = existingDday.Version
 	}
 
-	if req.TargetDate == "" {
-		return ctrl.BadRequest("Target date is required")
+	if err := ctrl.manager.Update(ctrl.GetUserID(), id, updatedDday); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return ctrl.PreconditionFailed("D-Day has been modified")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Editor role required")
+		}
+		return ctrl.InternalServerError("Failed to update D-Day")
 	}
 
-	if _, err := time.Parse("2006-01-02", req.TargetDate); err != nil {
-		return ctrl.BadRequest("Invalid target date format. Use YYYY-MM-DD")
+	saved, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch updated D-Day")
 	}
 
-	if req.Category == "" {
-		req.Category = dday.GetDefaultCategory()
-	} else if !dday.IsValidCategory(req.Category) {
-		return ctrl.BadRequest("Invalid category")
+	saved.SetToday(ctrl.Today())
+	ctrl.SetETag(saved.ETag())
+	return ctrl.Success(saved)
+}
+
+// PatchDday updates only the fields the request sends, as a merge patch or a
+// JSON Patch, so that starring a D-Day does not mean resending all of it.
+func (ctrl *DdayController) PatchDday(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.Params("id")
+	if id == "" {
+		return ctrl.BadRequest("ID is required")
 	}
 
-	updatedDday := &models.DDay{
-		ID:          id,
-		Title:       strings.TrimSpace(req.Title),
-		TargetDate:  req.TargetDate,
-		Category:    req.Category,
-		Memo:        strings.TrimSpace(req.Memo),
-		IsImportant: req.IsImportant,
-		CreatedAt:   existingDday.CreatedAt,
+	existingDday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.NotFound("D-Day not found")
 	}
 
-	if err := ctrl.manager.Update(id, updatedDday); err != nil {
-		return ctrl.InternalServerError("Failed to update D-Day")
+	if !ctrl.IfMatch(existingDday.ETag()) {
+		return ctrl.PreconditionFailed("D-Day has been modified")
+	}
+
+	fields, err := ctrl.PatchFields(existingDday)
+	if err != nil {
+		return ctrl.patchFailed(err)
+	}
+	patch, err := models.NewDdayPatch(existingDday, fields)
+	if err != nil {
+		return ctrl.patchFailed(err)
+	}
+
+	if patch.Category != nil {
+		category, ok, err := ctrl.resolveCategory(*patch.Category)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to load categories")
+		}
+		if !ok {
+			return ctrl.BadRequest("Invalid category")
+		}
+		patch.Category = &category
+	}
+
+	if !patch.IsEmpty() {
+		if ctrl.HasIfMatch() {
+			patch.Version = existingDday.Version
+		}
+		if err := ctrl.manager.Patch(ctrl.GetUserID(), id, patch); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ctrl.NotFound("D-Day not found")
+			}
+			if errors.Is(err, models.ErrVersionConflict) {
+				return ctrl.PreconditionFailed("D-Day has been modified")
+			}
+			if errors.Is(err, models.ErrForbidden) {
+				return ctrl.Forbidden("Editor role required")
+			}
+			return ctrl.InternalServerError("Failed to update D-Day")
+		}
+	}
+
+	saved, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch updated D-Day")
 	}
 
-	return ctrl.Success(updatedDday)
+	saved.SetToday(ctrl.Today())
+	ctrl.SetETag(saved.ETag())
+	return ctrl.Success(saved)
+}
+
+// patchFailed explains why a patch was rejected, in the words CreateDday
+// uses for the same mistakes.
+func (ctrl *DdayController) patchFailed(err error) error {
+	switch {
+	case errors.Is(err, models.ErrPatchTestFailed):
+		return ctrl.Conflict(err.Error())
+	case errors.Is(err, models.ErrInvalidPatch):
+		return ctrl.BadRequest(err.Error())
+	case errors.Is(err, models.ErrTitleRequired):
+		return ctrl.BadRequest("Title is required")
+	case errors.Is(err, models.ErrTargetDateRequired):
+		return ctrl.BadRequest("Target date is required")
+	case errors.Is(err, models.ErrInvalidTargetDate):
+		return ctrl.BadRequest("Invalid target date format. Use YYYY-MM-DD")
+	case errors.Is(err, models.ErrInvalidRecurrence):
+		return ctrl.BadRequest(invalidRecurrence)
+	case errors.Is(err, models.ErrInvalidTags):
+		return ctrl.BadRequest(invalidTags)
+	}
+	return ctrl.BadRequest(calendarError(err))
 }
 
 func (ctrl *DdayController) DeleteDday(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	id := ctrl.Params("id")
 	if id == "" {
 		return ctrl.BadRequest("ID is required")
 	}
 
-	_, err := ctrl.manager.GetByID(id)
+	existingDday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
 	if err != nil {
 		return ctrl.NotFound("D-Day not found")
 	}
 
-	if err := ctrl.manager.Delete(id); err != nil {
+	if !ctrl.IfMatch(existingDday.ETag()) {
+		return ctrl.PreconditionFailed("D-Day has been modified")
+	}
+
+	version := 0
+	if ctrl.HasIfMatch() {
+		version = existingDday.Version
+	}
+
+	if err := ctrl.manager.Delete(ctrl.GetUserID(), id, version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return ctrl.PreconditionFailed("D-Day has been modified")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Owner role required")
+		}
 		return ctrl.InternalServerError("Failed to delete D-Day")
 	}
 
 	return ctrl.Success(fiber.Map{
-		"message": "D-Day deleted successfully",
+		"message": "D-Day moved to trash",
 	})
-}
\ No newline at end of file
+}
+
+func (ctrl *DdayController) GetTrash(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	page, pageSize := ctrl.GetPagination()
+
+	builder := models.NewQuery().Trashed(models.OnlyTrashed).OrderBy(models.FieldDeletedAt, true)
+	if sort, ok := ctrl.GetSort(); ok {
+		builder.OrderBy(sort.Field, sort.Desc)
+	}
+
+	query, err := builder.Page(page, pageSize).Build()
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
+
+	ddays, err := ctrl.manager.GetAll(ctrl.GetUserID(), query)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch trash")
+	}
+
+	totalCount, err := ctrl.manager.Count(ctrl.GetUserID(), query)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to count trash")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": ctrl.Countdown(ddays),
+		"pagination": fiber.Map{
+			"page":       page,
+			"pageSize":   pageSize,
+			"totalCount": totalCount,
+			"totalPages": (totalCount + pageSize - 1) / pageSize,
+		},
+	})
+}
+
+func (ctrl *DdayController) RestoreDday(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.Params("id")
+	if id == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	if err := ctrl.manager.Restore(ctrl.GetUserID(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found in trash")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Owner role required")
+		}
+		return ctrl.InternalServerError("Failed to restore D-Day")
+	}
+
+	restored, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch restored D-Day")
+	}
+
+	restored.SetToday(ctrl.Today())
+	ctrl.SetETag(restored.ETag())
+	return ctrl.Success(restored)
+}
+
+func (ctrl *DdayController) GetRevisions(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.Params("id")
+	if id == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	revisions, err := ctrl.manager.GetRevisions(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch revisions")
+	}
+	if len(revisions) == 0 {
+		return ctrl.NotFound("D-Day not found")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": revisions,
+	})
+}
+
+func (ctrl *DdayController) RevertRevision(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.Params("id")
+	if id == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	revision := ctrl.ParamsInt("rev")
+	if revision <= 0 {
+		return ctrl.BadRequest("Invalid revision")
+	}
+
+	reverted, err := ctrl.manager.Revert(ctrl.GetUserID(), id, revision)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day or revision not found")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Editor role required")
+		}
+		return ctrl.InternalServerError("Failed to revert D-Day")
+	}
+
+	reverted.SetToday(ctrl.Today())
+	ctrl.SetETag(reverted.ETag())
+	return ctrl.Success(reverted)
+}
+
+// GetOccurrences expands a D-Day's recurrence between from and to
+// (YYYY-MM-DD, inclusive), which default to today and a year later.
+func (ctrl *DdayController) GetOccurrences(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.Params("id")
+	if id == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	from := ctrl.Today()
+	if value := ctrl.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return ctrl.BadRequest("Invalid from date format. Use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(1, 0, 0)
+	if value := ctrl.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return ctrl.BadRequest("Invalid to date format. Use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	if to.Before(from) {
+		return ctrl.BadRequest("to must not be before from")
+	}
+
+	dday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.NotFound("D-Day not found")
+	}
+
+	occurrences, truncated := dday.Occurrences(from, to, maxOccurrences)
+	dates := make([]string, len(occurrences))
+	for i, occurrence := range occurrences {
+		dates[i] = occurrence.Format("2006-01-02")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data":      dates,
+		"from":      from.Format("2006-01-02"),
+		"to":        to.Format("2006-01-02"),
+		"truncated": truncated,
+	})
+}
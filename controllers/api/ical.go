@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"dday-backend/controllers"
+	"dday-backend/global/auth"
+	"dday-backend/ical"
+	"dday-backend/models"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// ICalController exports D-Days as iCalendar, both as a download and as a
+// feed that calendar apps poll through a secret URL.
+type ICalController struct {
+	*controllers.Controller
+	manager    models.DdayStore
+	reminders  models.ReminderStore
+	categories models.CategoryStore
+	users      models.UserStore
+}
+
+func NewICalController(manager models.DdayStore, reminders models.ReminderStore, categories models.CategoryStore, users models.UserStore) *ICalController {
+	return &ICalController{manager: manager, reminders: reminders, categories: categories, users: users}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *ICalController) with(c *fiber.Ctx) *ICalController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+// Export downloads the authenticated user's D-Days as an .ics file.
+func (ctrl *ICalController) Export(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	body, err := ctrl.calendar(ctrl.GetUserID())
+	if err != nil {
+		return ctrl.InternalServerError("Failed to export D-Days")
+	}
+
+	ctrl.Attachment("ddays.ics")
+	return ctrl.Send(calendarContentType, body)
+}
+
+// Feed serves the D-Days of the user whose feed token is in the URL, so
+// calendar apps can subscribe without an Authorization header.
+func (ctrl *ICalController) Feed(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	user, err := ctrl.users.GetByFeedToken(auth.HashFeedToken(ctrl.Params("token")))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctrl.NotFound("Calendar feed not found")
+	}
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load calendar feed")
+	}
+
+	body, err := ctrl.calendar(user.ID)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load calendar feed")
+	}
+
+	return ctrl.Send(calendarContentType, body)
+}
+
+// CreateFeed issues a new feed URL for the authenticated user. The previous
+// URL, if any, stops working.
+func (ctrl *ICalController) CreateFeed(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	token, hash, err := auth.NewFeedToken()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to create calendar feed")
+	}
+	if err := ctrl.users.SetFeedToken(ctrl.GetUserID(), hash); err != nil {
+		return ctrl.InternalServerError("Failed to create calendar feed")
+	}
+
+	return ctrl.Created(fiber.Map{
+		"token": token,
+		"url":   ctrl.BaseURL() + "/api/v1/feed/" + token + ".ics",
+	})
+}
+
+func (ctrl *ICalController) DeleteFeed(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	if err := ctrl.users.SetFeedToken(ctrl.GetUserID(), ""); err != nil {
+		return ctrl.InternalServerError("Failed to delete calendar feed")
+	}
+
+	return ctrl.NoContent()
+}
+
+// calendar encodes userID's live D-Days, filtered by the category and
+// isImportant query parameters, with their reminders as alarms.
+func (ctrl *ICalController) calendar(userID string) ([]byte, error) {
+	builder := models.NewQuery()
+
+	if category := ctrl.GetCategory(); category != "" {
+		ok, err := ctrl.categories.Exists(category)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			builder.Where(models.FieldCategory, models.OpEq, category)
+		}
+	}
+	if isImportant := ctrl.GetIsImportant(); isImportant != nil {
+		builder.Where(models.FieldIsImportant, models.OpEq, *isImportant)
+	}
+
+	query, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	ddays, err := ctrl.manager.GetAll(userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	reminders, err := ctrl.reminders.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	byDday := make(map[string][]models.Reminder)
+	for _, reminder := range reminders {
+		byDday[reminder.DdayID] = append(byDday[reminder.DdayID], reminder)
+	}
+
+	cal := ical.Calendar{ProdID: models.ICalProdID, Name: "D-Day"}
+	for i := range ddays {
+		event, err := ddays[i].ICalEvent(byDday[ddays[i].ID])
+		if err != nil {
+			return nil, err
+		}
+		cal.Events = append(cal.Events, event)
+	}
+
+	var body bytes.Buffer
+	if err := cal.Encode(&body); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
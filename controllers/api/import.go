@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bytes"
+	"dday-backend/controllers"
+	"dday-backend/ical"
+	"dday-backend/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxImportItems caps the events of one import, which go in one transaction.
+const maxImportItems = 1000
+
+// ImportController brings D-Days in from other calendar apps in two steps:
+// a preview of what would be imported, then a confirm that inserts the
+// selected items together.
+type ImportController struct {
+	*controllers.Controller
+	manager    models.DdayStore
+	categories models.CategoryStore
+}
+
+func NewImportController(manager models.DdayStore, categories models.CategoryStore) *ImportController {
+	return &ImportController{manager: manager, categories: categories}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *ImportController) with(c *fiber.Ctx) *ImportController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+// importItem is one event of an uploaded file as it would be imported. A
+// duplicate has the title and date of an existing D-Day, named by
+// DuplicateOf, or of an earlier item, named by DuplicateOfIndex.
+type importItem struct {
+	Index              int      `json:"index"`
+	UID                string   `json:"uid,omitempty"`
+	Title              string   `json:"title,omitempty"`
+	TargetDate         string   `json:"target_date,omitempty"`
+	Category           string   `json:"category,omitempty"`
+	Memo               string   `json:"memo,omitempty"`
+	Recurrence         string   `json:"recurrence,omitempty"`
+	RecurrenceInterval int      `json:"recurrence_interval,omitempty"`
+	Duplicate          bool     `json:"duplicate"`
+	DuplicateOf        string   `json:"duplicate_of,omitempty"`
+	DuplicateOfIndex   *int     `json:"duplicate_of_index,omitempty"`
+	Warnings           []string `json:"warnings,omitempty"`
+	Error              string   `json:"error,omitempty"`
+
+	dday models.DDay
+}
+
+// PreviewICS parses an uploaded .ics file, sent as the "file" field of a
+// form or as the body, and lists what importing it would create.
+func (ctrl *ImportController) PreviewICS(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	items, err := ctrl.icsItems()
+	if err != nil {
+		return ctrl.importFailed(err)
+	}
+
+	summary := fiber.Map{"total": len(items), "importable": 0, "duplicates": 0, "invalid": 0}
+	for _, item := range items {
+		switch {
+		case item.Error != "":
+			summary["invalid"] = summary["invalid"].(int) + 1
+		case item.Duplicate:
+			summary["duplicates"] = summary["duplicates"].(int) + 1
+		default:
+			summary["importable"] = summary["importable"].(int) + 1
+		}
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data":    items,
+		"summary": summary,
+	})
+}
+
+// ConfirmICS imports the same file as PreviewICS in one transaction. The
+// select query parameter lists the indexes to import, duplicates included;
+// without it, every valid item that is not a duplicate is imported.
+func (ctrl *ImportController) ConfirmICS(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	items, err := ctrl.icsItems()
+	if err != nil {
+		return ctrl.importFailed(err)
+	}
+
+	var selected []*importItem
+	if value := strings.TrimSpace(ctrl.Query("select")); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || index < 0 || index >= len(items) {
+				return ctrl.BadRequest("Invalid selection")
+			}
+			if items[index].Error != "" {
+				return ctrl.BadRequest(fmt.Sprintf("Item %d cannot be imported: %s", index, items[index].Error))
+			}
+			selected = append(selected, &items[index])
+		}
+	} else {
+		for i := range items {
+			if items[i].Error == "" && !items[i].Duplicate {
+				selected = append(selected, &items[i])
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return ctrl.BadRequest("Nothing to import")
+	}
+
+	tx, err := ctrl.manager.Begin()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to import D-Days")
+	}
+	defer tx.Rollback()
+
+	imported := make([]models.DDay, 0, len(selected))
+	seen := make(map[int]bool, len(selected))
+	for _, item := range selected {
+		if seen[item.Index] {
+			continue
+		}
+		seen[item.Index] = true
+
+		dday := item.dday
+		dday.ID = uuid.New().String()
+		dday.CreatedAt = time.Now().UTC()
+		if err := ctrl.manager.CreateWithTx(tx, ctrl.GetUserID(), &dday); err != nil {
+			return ctrl.InternalServerError("Failed to import D-Days")
+		}
+		saved, err := ctrl.manager.GetByIDWithTx(tx, ctrl.GetUserID(), dday.ID)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to import D-Days")
+		}
+		imported = append(imported, *saved)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ctrl.InternalServerError("Failed to import D-Days")
+	}
+
+	return ctrl.Created(fiber.Map{
+		"data":     ctrl.Countdown(imported),
+		"imported": len(imported),
+	})
+}
+
+// importError is an upload that cannot be imported at all. Its message is
+// sent back as 400 Bad Request.
+type importError string
+
+func (e importError) Error() string {
+	return string(e)
+}
+
+func (ctrl *ImportController) importFailed(err error) error {
+	var message importError
+	if errors.As(err, &message) {
+		return ctrl.BadRequest(string(message))
+	}
+	return ctrl.InternalServerError("Failed to read the import")
+}
+
+// icsItems decodes the uploaded calendar and maps its events to D-Days,
+// marking duplicates.
+func (ctrl *ImportController) icsItems() ([]importItem, error) {
+	body, err := ctrl.Upload("file")
+	if err != nil {
+		return nil, importError("File is required")
+	}
+
+	loc := ctrl.Location()
+	cal, err := ical.Decode(bytes.NewReader(body), loc)
+	if err != nil {
+		return nil, importError("Invalid iCalendar file")
+	}
+	if len(cal.Events) > maxImportItems {
+		return nil, importError(fmt.Sprintf("A file may hold at most %d events", maxImportItems))
+	}
+
+	defaultCategory, err := ctrl.categories.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]importItem, len(cal.Events))
+	for i, event := range cal.Events {
+		item := &items[i]
+		item.Index, item.UID = i, event.UID
+
+		dday, warnings, err := models.DDayFromICal(event, loc)
+		if err != nil {
+			item.Error = err.Error()
+			continue
+		}
+
+		dday.Category = defaultCategory
+		for _, category := range event.Categories {
+			ok, err := ctrl.categories.Exists(category)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				dday.Category = category
+				break
+			}
+		}
+
+		item.dday = dday
+		item.Title, item.TargetDate, item.Category, item.Memo = dday.Title, dday.TargetDate, dday.Category, dday.Memo
+		item.Recurrence, item.RecurrenceInterval = dday.Recurrence, dday.RecurrenceInterval
+		item.Warnings = warnings
+	}
+
+	if err := ctrl.markDuplicates(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// markDuplicates flags the items whose title and date match one of the
+// user's D-Days or an earlier item. Titles match ignoring case.
+func (ctrl *ImportController) markDuplicates(items []importItem) error {
+	query, err := models.NewQuery().Build()
+	if err != nil {
+		return err
+	}
+	existing, err := ctrl.manager.GetAll(ctrl.GetUserID(), query)
+	if err != nil {
+		return err
+	}
+
+	key := func(title, date string) string {
+		return strings.ToLower(title) + "\x00" + date
+	}
+	ids := make(map[string]string, len(existing))
+	for _, dday := range existing {
+		ids[key(dday.Title, dday.TargetDate)] = dday.ID
+	}
+
+	indexes := make(map[string]int, len(items))
+	for i := range items {
+		item := &items[i]
+		if item.Error != "" {
+			continue
+		}
+		k := key(item.Title, item.TargetDate)
+		if id, ok := ids[k]; ok {
+			item.Duplicate, item.DuplicateOf = true, id
+		} else if index, ok := indexes[k]; ok {
+			item.Duplicate, item.DuplicateOfIndex = true, &index
+		} else {
+			indexes[k] = i
+		}
+	}
+	return nil
+}
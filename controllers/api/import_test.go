@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+)
+
+// testCalendar holds an event to import, one matching an existing D-Day, a
+// repeat of the first and one without a title.
+var testCalendar = []byte(strings.ReplaceAll(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//test//EN
+BEGIN:VEVENT
+UID:birthday
+SUMMARY:생일
+DTSTART;VALUE=DATE:20250301
+RRULE:FREQ=YEARLY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:exam
+SUMMARY:시험
+DTSTART;VALUE=DATE:20250601
+END:VEVENT
+BEGIN:VEVENT
+UID:birthday-again
+SUMMARY:생일
+DTSTART;VALUE=DATE:20250301
+END:VEVENT
+BEGIN:VEVENT
+UID:untitled
+DTSTART;VALUE=DATE:20250701
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n"))
+
+type importItem struct {
+	Index            int      `json:"index"`
+	Title            string   `json:"title"`
+	TargetDate       string   `json:"target_date"`
+	Recurrence       string   `json:"recurrence"`
+	Duplicate        bool     `json:"duplicate"`
+	DuplicateOf      string   `json:"duplicate_of"`
+	DuplicateOfIndex *int     `json:"duplicate_of_index"`
+	Warnings         []string `json:"warnings"`
+	Error            string   `json:"error"`
+}
+
+func TestPreviewICS(t *testing.T) {
+	app := newTestApp(t)
+	user := signup(t, app, "user@example.com")
+	examID := createDday(t, app, user.AccessToken, "시험", "2025-06-01")
+
+	var out struct {
+		Data    []importItem   `json:"data"`
+		Summary map[string]int `json:"summary"`
+	}
+	if status := send(t, app, "POST", "/api/v1/import/ics", user.AccessToken, testCalendar, &out); status != 200 {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if len(out.Data) != 4 {
+		t.Fatalf("got %d items, want 4", len(out.Data))
+	}
+
+	birthday, exam, again, untitled := out.Data[0], out.Data[1], out.Data[2], out.Data[3]
+	if birthday.Duplicate || birthday.Error != "" || birthday.TargetDate != "2025-03-01" || birthday.Recurrence != "yearly" || len(birthday.Warnings) == 0 {
+		t.Errorf("birthday = %+v, want a yearly 2025-03-01 import with a warning about COUNT", birthday)
+	}
+	if !exam.Duplicate || exam.DuplicateOf != examID {
+		t.Errorf("exam = %+v, want a duplicate of %s", exam, examID)
+	}
+	if !again.Duplicate || again.DuplicateOfIndex == nil || *again.DuplicateOfIndex != 0 {
+		t.Errorf("repeated birthday = %+v, want a duplicate of item 0", again)
+	}
+	if untitled.Error == "" {
+		t.Errorf("untitled = %+v, want an error", untitled)
+	}
+	want := map[string]int{"total": 4, "importable": 1, "duplicates": 2, "invalid": 1}
+	for key, n := range want {
+		if out.Summary[key] != n {
+			t.Errorf("summary %s = %d, want %d", key, out.Summary[key], n)
+		}
+	}
+
+	if n := countDdays(t, app, user.AccessToken); n != 1 {
+		t.Errorf("preview saved D-Days: have %d, want 1", n)
+	}
+}
+
+func TestConfirmICS(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		imported int
+	}{
+		{"importable only", "", 201, 1},
+		{"selected duplicates", "?select=0,1,2", 201, 3},
+		{"selected twice", "?select=0,0", 201, 1},
+		{"invalid selected", "?select=0,3", 400, 0},
+		{"out of range", "?select=4", 400, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			user := signup(t, app, "user@example.com")
+			createDday(t, app, user.AccessToken, "시험", "2025-06-01")
+
+			var out struct {
+				Imported int `json:"imported"`
+			}
+			if status := send(t, app, "POST", "/api/v1/import/ics/confirm"+tt.query, user.AccessToken, testCalendar, &out); status != tt.status || out.Imported != tt.imported {
+				t.Errorf("status %d imported %d, want %d %d", status, out.Imported, tt.status, tt.imported)
+			}
+			if n := countDdays(t, app, user.AccessToken); n != 1+tt.imported {
+				t.Errorf("have %d D-Days, want %d", n, 1+tt.imported)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"database/sql"
+	"dday-backend/controllers"
+	"dday-backend/global/auth"
+	"dday-backend/models"
+	"errors"
+	"net/mail"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const invalidRole = "Invalid role. Use viewer, editor or owner"
+
+// MemberController shares D-Days between accounts: owners invite others by
+// email or with a code, and manage the roles of those who joined.
+type MemberController struct {
+	*controllers.Controller
+	manager models.MemberStore
+}
+
+func NewMemberController(store models.MemberStore) *MemberController {
+	return &MemberController{manager: store}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *MemberController) with(c *fiber.Ctx) *MemberController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+type inviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+type acceptRequest struct {
+	Code string `json:"code"`
+}
+
+// createdInvitation is a new invitation with its code, which is only shown
+// once.
+type createdInvitation struct {
+	models.Invitation
+	Code string `json:"code"`
+}
+
+// memberFailed answers the errors every D-Day-scoped member call shares.
+func (ctrl *MemberController) memberFailed(err error, notFound, failed string) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ctrl.NotFound(notFound)
+	case errors.Is(err, models.ErrForbidden):
+		return ctrl.Forbidden("Owner role required")
+	case errors.Is(err, models.ErrCreatorRole):
+		return ctrl.BadRequest("The creator of a D-Day always stays an owner")
+	}
+	return ctrl.InternalServerError(failed)
+}
+
+func (ctrl *MemberController) GetMembers(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	members, err := ctrl.manager.Members(ctrl.GetUserID(), ddayID)
+	if err != nil {
+		return ctrl.memberFailed(err, "D-Day not found", "Failed to fetch members")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": members,
+	})
+}
+
+func (ctrl *MemberController) UpdateMember(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, memberID := ctrl.Params("id"), ctrl.Params("userId")
+	if ddayID == "" || memberID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	var req roleRequest
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+	if !models.ValidRole(req.Role) {
+		return ctrl.BadRequest(invalidRole)
+	}
+
+	if err := ctrl.manager.SetRole(ctrl.GetUserID(), ddayID, memberID, req.Role); err != nil {
+		return ctrl.memberFailed(err, "Member not found", "Failed to update member")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"user_id": memberID,
+		"role":    req.Role,
+	})
+}
+
+// RemoveMember takes a member off a D-Day. Members may remove themselves to
+// leave a D-Day shared with them.
+func (ctrl *MemberController) RemoveMember(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, memberID := ctrl.Params("id"), ctrl.Params("userId")
+	if ddayID == "" || memberID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	if err := ctrl.manager.RemoveMember(ctrl.GetUserID(), ddayID, memberID); err != nil {
+		return ctrl.memberFailed(err, "Member not found", "Failed to remove member")
+	}
+
+	return ctrl.NoContent()
+}
+
+func (ctrl *MemberController) GetInvitations(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	invitations, err := ctrl.manager.Invitations(ctrl.GetUserID(), ddayID)
+	if err != nil {
+		return ctrl.memberFailed(err, "D-Day not found", "Failed to fetch invitations")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": invitations,
+	})
+}
+
+// CreateInvitation invites someone to a D-Day with a role, viewer unless
+// given. With an email the invitation waits in that user's invitations;
+// either way its code, shown once, lets them join.
+func (ctrl *MemberController) CreateInvitation(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	var req inviteRequest
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if !models.ValidRole(req.Role) {
+		return ctrl.BadRequest(invalidRole)
+	}
+	email := normalizeEmail(req.Email)
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > 255 {
+			return ctrl.BadRequest("Invalid email")
+		}
+	}
+
+	code, hash, err := auth.NewInvitationCode()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to create invitation")
+	}
+
+	invitation := &models.Invitation{Email: email, Role: req.Role, CodeHash: hash}
+	if err := ctrl.manager.Invite(ctrl.GetUserID(), ddayID, invitation); err != nil {
+		return ctrl.memberFailed(err, "D-Day not found", "Failed to create invitation")
+	}
+
+	return ctrl.Created(createdInvitation{
+		Invitation: *invitation,
+		Code:       code,
+	})
+}
+
+func (ctrl *MemberController) CancelInvitation(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+	id := ctrl.ParamsInt("invitationId")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid invitation ID")
+	}
+
+	if err := ctrl.manager.CancelInvitation(ctrl.GetUserID(), ddayID, id); err != nil {
+		return ctrl.memberFailed(err, "Invitation not found", "Failed to cancel invitation")
+	}
+
+	return ctrl.NoContent()
+}
+
+// GetMyInvitations lists the invitations addressed to the caller's email.
+func (ctrl *MemberController) GetMyInvitations(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	invitations, err := ctrl.manager.Pending(ctrl.GetUser().Email)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch invitations")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": invitations,
+	})
+}
+
+// AcceptInvitation joins the D-Day of an invitation addressed to the
+// caller's email.
+func (ctrl *MemberController) AcceptInvitation(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.ParamsInt("invitationId")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid invitation ID")
+	}
+
+	invitation, err := ctrl.manager.AcceptByID(ctrl.GetUser(), id)
+	return ctrl.accepted(invitation, err)
+}
+
+// AcceptCode joins the D-Day of the invitation with the code in the body.
+func (ctrl *MemberController) AcceptCode(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	var req acceptRequest
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+	if req.Code == "" {
+		return ctrl.BadRequest("Code is required")
+	}
+
+	invitation, err := ctrl.manager.AcceptByCode(ctrl.GetUser(), auth.HashInvitationCode(req.Code))
+	return ctrl.accepted(invitation, err)
+}
+
+func (ctrl *MemberController) accepted(invitation *models.Invitation, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctrl.NotFound("Invitation not found or expired")
+	}
+	if err != nil {
+		return ctrl.InternalServerError("Failed to accept invitation")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"dday_id":    invitation.DdayID,
+		"dday_title": invitation.DdayTitle,
+		"role":       invitation.Role,
+	})
+}
+
+func (ctrl *MemberController) DeclineInvitation(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.ParamsInt("invitationId")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid invitation ID")
+	}
+
+	if err := ctrl.manager.Decline(ctrl.GetUser().Email, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("Invitation not found")
+		}
+		return ctrl.InternalServerError("Failed to decline invitation")
+	}
+
+	return ctrl.NoContent()
+}
@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// join makes the user holding token a member of the D-Day id with role,
+// inviting them as the owner and accepting the code.
+func join(t *testing.T, app *fiber.App, owner, token, id, role string) {
+	t.Helper()
+	var invitation struct {
+		Code string `json:"code"`
+	}
+	if status := send(t, app, "POST", "/api/v1/ddays/"+id+"/invitations", owner, fiber.Map{"role": role}, &invitation); status != 201 {
+		t.Fatalf("invite %s: status %d", role, status)
+	}
+	if status := send(t, app, "POST", "/api/v1/invitations/accept", token, fiber.Map{"code": invitation.Code}, nil); status != 200 {
+		t.Fatalf("accept %s: status %d", role, status)
+	}
+}
+
+func userID(t *testing.T, app *fiber.App, token string) string {
+	t.Helper()
+	var me struct {
+		ID string `json:"id"`
+	}
+	send(t, app, "GET", "/api/v1/me", token, nil, &me)
+	return me.ID
+}
+
+func TestRoles(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com").AccessToken
+	editor := signup(t, app, "editor@example.com").AccessToken
+	viewer := signup(t, app, "viewer@example.com").AccessToken
+	stranger := signup(t, app, "stranger@example.com").AccessToken
+	id := createDday(t, app, owner, "시험", "2025-06-01")
+	join(t, app, owner, editor, id, "editor")
+	join(t, app, owner, viewer, id, "viewer")
+
+	path := "/api/v1/ddays/" + id
+	update := fiber.Map{"title": "기말고사", "target_date": "2025-06-02", "category": "학업"}
+	batch := fiber.Map{"operations": []fiber.Map{{"op": "update", "id": id, "data": update}}}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		want   map[string]int
+	}{
+		{"get", "GET", path, nil, map[string]int{"viewer": 200, "editor": 200, "stranger": 404}},
+		{"members", "GET", path + "/members", nil, map[string]int{"viewer": 200, "editor": 200, "stranger": 404}},
+		{"update", "PUT", path, update, map[string]int{"viewer": 403, "editor": 200, "stranger": 404}},
+		{"patch", "PATCH", path, fiber.Map{"memo": "3층"}, map[string]int{"viewer": 403, "editor": 200, "stranger": 404}},
+		{"batch update", "POST", "/api/v1/ddays/batch", batch, map[string]int{"viewer": 403, "editor": 200, "stranger": 404}},
+		{"share", "POST", path + "/shares", fiber.Map{}, map[string]int{"viewer": 403, "editor": 403, "stranger": 404}},
+		{"invite", "POST", path + "/invitations", fiber.Map{}, map[string]int{"viewer": 403, "editor": 403, "stranger": 404}},
+		{"delete", "DELETE", path, nil, map[string]int{"viewer": 403, "editor": 403, "stranger": 404}},
+	}
+	tokens := map[string]string{"viewer": viewer, "editor": editor, "stranger": stranger}
+	for _, tt := range tests {
+		for _, who := range []string{"viewer", "editor", "stranger"} {
+			t.Run(tt.name+" as "+who, func(t *testing.T) {
+				if status := send(t, app, tt.method, tt.path, tokens[who], tt.body, nil); status != tt.want[who] {
+					t.Errorf("status %d, want %d", status, tt.want[who])
+				}
+			})
+		}
+	}
+
+	var list struct {
+		Data []struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		} `json:"data"`
+	}
+	send(t, app, "GET", "/api/v1/ddays", viewer, nil, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != id || list.Data[0].Role != "viewer" {
+		t.Errorf("viewer's list = %+v, want the shared D-Day as viewer", list.Data)
+	}
+
+	if status := send(t, app, "DELETE", path, owner, nil, nil); status != 200 {
+		t.Errorf("delete as owner: status %d, want 200", status)
+	}
+}
+
+func TestChangeMembers(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com").AccessToken
+	member := signup(t, app, "member@example.com").AccessToken
+	id := createDday(t, app, owner, "시험", "2025-06-01")
+	join(t, app, owner, member, id, "viewer")
+
+	path := "/api/v1/ddays/" + id
+	members := path + "/members/" + userID(t, app, member)
+	update := fiber.Map{"title": "기말고사", "target_date": "2025-06-02", "category": "학업"}
+
+	if status := send(t, app, "PUT", members, member, fiber.Map{"role": "owner"}, nil); status != 403 {
+		t.Errorf("viewer promoting themselves: status %d, want 403", status)
+	}
+	if status := send(t, app, "PUT", members, owner, fiber.Map{"role": "editor"}, nil); status != 200 {
+		t.Fatalf("promote: status %d, want 200", status)
+	}
+	if status := send(t, app, "PUT", path, member, update, nil); status != 200 {
+		t.Errorf("update as promoted editor: status %d, want 200", status)
+	}
+	if status := send(t, app, "PUT", path+"/members/"+userID(t, app, owner), owner, fiber.Map{"role": "viewer"}, nil); status != 400 {
+		t.Errorf("demoting the creator: status %d, want 400", status)
+	}
+
+	if status := send(t, app, "DELETE", members, owner, nil, nil); status != 204 {
+		t.Fatalf("remove: status %d, want 204", status)
+	}
+	if status := send(t, app, "GET", path, member, nil, nil); status != 404 {
+		t.Errorf("get after removal: status %d, want 404", status)
+	}
+}
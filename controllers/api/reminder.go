@@ -0,0 +1,197 @@
+package api
+
+import (
+	"database/sql"
+	"dday-backend/controllers"
+	"dday-backend/models"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReminderController struct {
+	*controllers.Controller
+	manager models.ReminderStore
+}
+
+func NewReminderController(store models.ReminderStore) *ReminderController {
+	return &ReminderController{manager: store}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *ReminderController) with(c *fiber.Ctx) *ReminderController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+type reminderRequest struct {
+	DaysBefore *int  `json:"days_before"`
+	IsActive   *bool `json:"is_active"`
+}
+
+// parse reads and validates the request body, returning an error message
+// for the client when it is invalid. An omitted is_active keeps isActive.
+func (ctrl *ReminderController) parse(isActive bool) (*models.Reminder, string) {
+	var req reminderRequest
+	if err := ctrl.Body(&req); err != nil {
+		return nil, "Invalid request body"
+	}
+
+	if req.DaysBefore == nil {
+		return nil, "days_before is required"
+	}
+	if *req.DaysBefore < 0 || *req.DaysBefore > models.MaxReminderDaysBefore {
+		return nil, fmt.Sprintf("days_before must be between 0 and %d", models.MaxReminderDaysBefore)
+	}
+
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return &models.Reminder{DaysBefore: *req.DaysBefore, IsActive: isActive}, ""
+}
+
+// ids reads the D-Day and reminder IDs from the route, returning an error
+// message for the client when they are invalid.
+func (ctrl *ReminderController) ids(withReminder bool) (string, int, string) {
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return "", 0, "ID is required"
+	}
+	if !withReminder {
+		return ddayID, 0, ""
+	}
+
+	id := ctrl.ParamsInt("reminderId")
+	if id <= 0 {
+		return "", 0, "Invalid reminder ID"
+	}
+	return ddayID, id, ""
+}
+
+func (ctrl *ReminderController) GetReminders(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, _, message := ctrl.ids(false)
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+
+	reminders, err := ctrl.manager.List(ctrl.GetUserID(), ddayID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		return ctrl.InternalServerError("Failed to fetch reminders")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": reminders,
+	})
+}
+
+func (ctrl *ReminderController) GetReminder(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, id, message := ctrl.ids(true)
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+
+	reminder, err := ctrl.manager.Get(ctrl.GetUserID(), ddayID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("Reminder not found")
+		}
+		return ctrl.InternalServerError("Failed to fetch reminder")
+	}
+
+	return ctrl.Success(reminder)
+}
+
+func (ctrl *ReminderController) CreateReminder(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, _, message := ctrl.ids(false)
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+
+	reminder, message := ctrl.parse(true)
+	if reminder == nil {
+		return ctrl.BadRequest(message)
+	}
+
+	if err := ctrl.manager.Create(ctrl.GetUserID(), ddayID, reminder); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctrl.NotFound("D-Day not found")
+		case errors.Is(err, models.ErrReminderExists):
+			return ctrl.Conflict("A reminder for that day already exists")
+		}
+		return ctrl.InternalServerError("Failed to create reminder")
+	}
+
+	return ctrl.Created(reminder)
+}
+
+func (ctrl *ReminderController) UpdateReminder(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, id, message := ctrl.ids(true)
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+
+	existing, err := ctrl.manager.Get(ctrl.GetUserID(), ddayID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("Reminder not found")
+		}
+		return ctrl.InternalServerError("Failed to fetch reminder")
+	}
+
+	reminder, message := ctrl.parse(existing.IsActive)
+	if reminder == nil {
+		return ctrl.BadRequest(message)
+	}
+
+	if err := ctrl.manager.Update(ctrl.GetUserID(), ddayID, id, reminder); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ctrl.NotFound("Reminder not found")
+		case errors.Is(err, models.ErrReminderExists):
+			return ctrl.Conflict("A reminder for that day already exists")
+		}
+		return ctrl.InternalServerError("Failed to update reminder")
+	}
+
+	updated, err := ctrl.manager.Get(ctrl.GetUserID(), ddayID, id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch updated reminder")
+	}
+
+	return ctrl.Success(updated)
+}
+
+func (ctrl *ReminderController) DeleteReminder(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID, id, message := ctrl.ids(true)
+	if message != "" {
+		return ctrl.BadRequest(message)
+	}
+
+	if err := ctrl.manager.Delete(ctrl.GetUserID(), ddayID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("Reminder not found")
+		}
+		return ctrl.InternalServerError("Failed to delete reminder")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"message": "Reminder deleted",
+	})
+}
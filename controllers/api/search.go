@@ -0,0 +1,113 @@
+package api
+
+import (
+	"dday-backend/models"
+	"dday-backend/models/hangul"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchDdays ranks D-Days by how well their title and memo match q and
+// returns the matched fragments highlighted.
+func (ctrl *DdayController) SearchDdays(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	keyword := strings.TrimSpace(ctrl.Query("q"))
+	if keyword == "" {
+		return ctrl.BadRequest("Search query is required")
+	}
+
+	page, pageSize := ctrl.GetPagination()
+	category := ctrl.GetCategory()
+	isImportant := ctrl.GetIsImportant()
+
+	builder := models.NewQuery().Search(keyword)
+
+	if ok, err := ctrl.categoryFilter(category); err != nil {
+		return ctrl.InternalServerError("Failed to load categories")
+	} else if ok {
+		builder.Where(models.FieldCategory, models.OpEq, category)
+	}
+
+	if isImportant != nil {
+		builder.Where(models.FieldIsImportant, models.OpEq, *isImportant)
+	}
+
+	query, err := builder.Page(page, pageSize).Build()
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
+
+	results, err := ctrl.manager.Search(ctrl.GetUserID(), query)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to search D-Days")
+	}
+
+	totalCount, err := ctrl.manager.Count(ctrl.GetUserID(), query)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to count D-Days")
+	}
+
+	today := ctrl.Today()
+	for i := range results {
+		results[i].SetToday(today)
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": results,
+		"pagination": fiber.Map{
+			"page":       page,
+			"pageSize":   pageSize,
+			"totalCount": totalCount,
+			"totalPages": (totalCount + pageSize - 1) / pageSize,
+		},
+	})
+}
+
+// Autocomplete suggests D-Days with a title word starting with q. A query of
+// bare consonants such as "ㅈㅇ" matches initial consonants; anything else
+// is compared jamo by jamo, so a half-typed syllable still matches.
+func (ctrl *DdayController) Autocomplete(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	keyword := strings.TrimSpace(ctrl.Query("q"))
+	if keyword == "" {
+		return ctrl.BadRequest("Search query is required")
+	}
+
+	limit := ctrl.Queryi("limit")
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	builder := models.NewQuery()
+	if hangul.IsChoseong(keyword) {
+		builder.Where(models.FieldTitleChosung, models.OpWordPrefix, hangul.Choseong(keyword))
+	} else {
+		builder.Where(models.FieldTitleJamo, models.OpWordPrefix, hangul.Decompose(keyword))
+	}
+
+	query, err := builder.OrderBy(models.FieldTitle, false).Page(1, limit).Build()
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
+
+	ddays, err := ctrl.manager.GetAll(ctrl.GetUserID(), query)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch suggestions")
+	}
+
+	suggestions := make([]fiber.Map, len(ddays))
+	for i, d := range ddays {
+		suggestions[i] = fiber.Map{
+			"id":          d.ID,
+			"title":       d.Title,
+			"target_date": d.TargetDate,
+		}
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": suggestions,
+	})
+}
@@ -0,0 +1,156 @@
+package api_test
+
+import (
+	"bytes"
+	"dday-backend/global/config"
+	"dday-backend/models"
+	"dday-backend/router"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	adminEmail   = "admin@example.com"
+	testPassword = "correct horse"
+)
+
+// newTestApp serves every route from a fresh memory store, which is dropped
+// when t ends.
+func newTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	seoul, err := time.LoadLocation("Asia/Seoul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AppConfig = &config.Config{
+		Server:   config.ServerConfig{Timezone: "Asia/Seoul", Location: seoul},
+		Database: config.DatabaseConfig{Driver: models.DriverMemory},
+		Auth: config.AuthConfig{
+			JWTSecret:             "test-secret",
+			AccessTokenTTLMinutes: 15,
+			RefreshTokenTTLDays:   14,
+			AdminEmails:           []string{adminEmail},
+		},
+	}
+	if err := models.InitDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		models.Close()
+		models.DB = nil
+	})
+
+	app := fiber.New()
+	router.SetupRoutes(app)
+	return app
+}
+
+// newRequest builds a request as the user holding token, with body sent as
+// JSON unless it is already a []byte.
+func newRequest(method, path, token string, body interface{}) *http.Request {
+	var reader io.Reader
+	contentType := fiber.MIMEApplicationJSON
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		reader, contentType = bytes.NewReader(body), fiber.MIMETextPlain
+	default:
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	return req
+}
+
+// do sends req to app, reads the response into out unless it is nil, as JSON
+// unless out is a *[]byte, and returns the status.
+func do(t *testing.T, app *fiber.App, req *http.Request, out interface{}) int {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	switch out := out.(type) {
+	case nil:
+	case *[]byte:
+		*out, err = io.ReadAll(resp.Body)
+	default:
+		err = json.NewDecoder(resp.Body).Decode(out)
+	}
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+	}
+	return resp.StatusCode
+}
+
+// send is do for a request built by newRequest.
+func send(t *testing.T, app *fiber.App, method, path, token string, body, out interface{}) int {
+	t.Helper()
+	return do(t, app, newRequest(method, path, token, body), out)
+}
+
+type tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// signup registers email and returns its tokens.
+func signup(t *testing.T, app *fiber.App, email string) tokens {
+	t.Helper()
+	var out tokens
+	body := fiber.Map{"email": email, "password": testPassword}
+	if status := send(t, app, "POST", "/api/v1/auth/signup", "", body, &out); status != 201 {
+		t.Fatalf("signup %s: status %d", email, status)
+	}
+	return out
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type ddayResponse struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Version  int    `json:"version"`
+}
+
+// createDday saves a D-Day titled title on date as the user holding token and
+// returns its ID.
+func createDday(t *testing.T, app *fiber.App, token, title, date string) string {
+	t.Helper()
+	var out ddayResponse
+	body := fiber.Map{"title": title, "target_date": date, "category": "개인"}
+	if status := send(t, app, "POST", "/api/v1/ddays", token, body, &out); status != 201 {
+		t.Fatalf("create %s: status %d", title, status)
+	}
+	return out.ID
+}
+
+// countDdays returns how many D-Days the user holding token has.
+func countDdays(t *testing.T, app *fiber.App, token string) int {
+	t.Helper()
+	var out struct {
+		Data []ddayResponse `json:"data"`
+	}
+	if status := send(t, app, "GET", "/api/v1/ddays?pageSize=100", token, nil, &out); status != 200 {
+		t.Fatalf("list: status %d", status)
+	}
+	return len(out.Data)
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"database/sql"
+	"dday-backend/controllers"
+	"dday-backend/global/auth"
+	"dday-backend/models"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sharePasswordHeader carries the password of a protected share link.
+const sharePasswordHeader = "X-Share-Password"
+
+// ShareController hands out public links to single D-Days and serves them to
+// visitors without an account.
+type ShareController struct {
+	*controllers.Controller
+	manager models.ShareStore
+}
+
+func NewShareController(store models.ShareStore) *ShareController {
+	return &ShareController{manager: store}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *ShareController) with(c *fiber.Ctx) *ShareController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+type shareRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password"`
+	ShowMemo  bool       `json:"show_memo"`
+}
+
+// createdShare is a new share with its token, which is only shown once.
+type createdShare struct {
+	models.Share
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func (ctrl *ShareController) GetShares(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	shares, err := ctrl.manager.List(ctrl.GetUserID(), ddayID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Owner role required")
+		}
+		return ctrl.InternalServerError("Failed to fetch shares")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": shares,
+	})
+}
+
+// CreateShare mints a share link to a D-Day, optionally expiring at
+// expires_at and protected by a password.
+func (ctrl *ShareController) CreateShare(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	var req shareRequest
+	if err := ctrl.Body(&req); err != nil {
+		return ctrl.BadRequest("Invalid request body")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(controllers.Now()) {
+		return ctrl.BadRequest("expires_at must be in the future")
+	}
+
+	share := &models.Share{ExpiresAt: req.ExpiresAt, ShowMemo: req.ShowMemo}
+	if req.Password != "" {
+		if !validPassword(req.Password) {
+			return ctrl.BadRequest(invalidPassword)
+		}
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to create share")
+		}
+		share.PasswordHash = hash
+	}
+
+	token, hash, err := auth.NewShareToken()
+	if err != nil {
+		return ctrl.InternalServerError("Failed to create share")
+	}
+	share.TokenHash = hash
+
+	if err := ctrl.manager.Create(ctrl.GetUserID(), ddayID, share); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Owner role required")
+		}
+		return ctrl.InternalServerError("Failed to create share")
+	}
+
+	return ctrl.Created(createdShare{
+		Share: *share,
+		Token: token,
+		URL:   ctrl.BaseURL() + "/s/" + token,
+	})
+}
+
+// RevokeShare stops a share link from working. The share stays listed, with
+// its view count, as revoked.
+func (ctrl *ShareController) RevokeShare(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	ddayID := ctrl.Params("id")
+	if ddayID == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+	id := ctrl.ParamsInt("shareId")
+	if id <= 0 {
+		return ctrl.BadRequest("Invalid share ID")
+	}
+
+	if err := ctrl.manager.Revoke(ctrl.GetUserID(), ddayID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("Share not found")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Owner role required")
+		}
+		return ctrl.InternalServerError("Failed to revoke share")
+	}
+
+	return ctrl.NoContent()
+}
+
+// ViewShare shows the D-Day behind a share token to anyone holding it, with
+// the days remaining counted in the visitor's timezone. A protected share
+// needs its password in the X-Share-Password header. Every view that gets
+// through is counted.
+func (ctrl *ShareController) ViewShare(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	share, dday, err := ctrl.manager.GetByToken(auth.HashShareToken(ctrl.Params("token")))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ctrl.NotFound("Share not found")
+	}
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load share")
+	}
+
+	switch {
+	case share.RevokedAt != nil:
+		return ctrl.Gone("Share has been revoked")
+	case share.Expired(controllers.Now()):
+		return ctrl.Gone("Share has expired")
+	}
+
+	if share.HasPassword {
+		password := ctrl.Get(sharePasswordHeader)
+		if password == "" {
+			return ctrl.Unauthorized("Password required")
+		}
+		if !auth.CheckPassword(share.PasswordHash, password) {
+			return ctrl.Unauthorized("Invalid password")
+		}
+	}
+
+	if err := ctrl.manager.RecordView(share.ID); err != nil {
+		return ctrl.InternalServerError("Failed to load share")
+	}
+
+	dday.SetToday(ctrl.Today())
+	return ctrl.Success(share.View(dday))
+}
@@ -0,0 +1,171 @@
+package api_test
+
+import (
+	"dday-backend/controllers"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type shareResponse struct {
+	ID        int    `json:"id"`
+	Token     string `json:"token"`
+	ViewCount int    `json:"view_count"`
+}
+
+// createShare shares the D-Day id as the user holding token, failing t unless
+// the share is created.
+func createShare(t *testing.T, app *fiber.App, token, id string, body fiber.Map) shareResponse {
+	t.Helper()
+	var out shareResponse
+	if status := send(t, app, "POST", "/api/v1/ddays/"+id+"/shares", token, body, &out); status != 201 {
+		t.Fatalf("share %s: status %d", id, status)
+	}
+	return out
+}
+
+// viewShare opens the share link token with password, if any.
+func viewShare(t *testing.T, app *fiber.App, token, password string, out interface{}) int {
+	t.Helper()
+	req := newRequest("GET", "/s/"+token, "", nil)
+	if password != "" {
+		req.Header.Set("X-Share-Password", password)
+	}
+	return do(t, app, req, out)
+}
+
+func TestCreateShare(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com")
+	other := signup(t, app, "other@example.com")
+	id := createDday(t, app, owner.AccessToken, "시험", "2025-06-01")
+
+	tests := []struct {
+		name   string
+		token  string
+		body   fiber.Map
+		status int
+	}{
+		{"short password", owner.AccessToken, fiber.Map{"password": "1234567"}, 400},
+		{"long password", owner.AccessToken, fiber.Map{"password": strings.Repeat("a", 73)}, 400},
+		{"past expiry", owner.AccessToken, fiber.Map{"expires_at": time.Now().Add(-time.Hour)}, 400},
+		{"someone else's D-Day", other.AccessToken, fiber.Map{}, 404},
+		{"ok", owner.AccessToken, fiber.Map{"password": "12345678"}, 201},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := send(t, app, "POST", "/api/v1/ddays/"+id+"/shares", tt.token, tt.body, nil); status != tt.status {
+				t.Errorf("status %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestViewShare(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com")
+	var dday ddayResponse
+	body := fiber.Map{"title": "시험", "target_date": "2025-06-01", "category": "학업", "memo": "3층 강의실"}
+	if status := send(t, app, "POST", "/api/v1/ddays", owner.AccessToken, body, &dday); status != 201 {
+		t.Fatalf("create: status %d", status)
+	}
+	share := createShare(t, app, owner.AccessToken, dday.ID, fiber.Map{"password": "open sesame"})
+
+	tests := []struct {
+		name     string
+		password string
+		status   int
+		error    string
+	}{
+		{"no password", "", 401, "Password required"},
+		{"wrong password", "open sesame!", 401, "Invalid password"},
+		{"ok", "open sesame", 200, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out struct {
+				errorResponse
+				Title string `json:"title"`
+				Memo  string `json:"memo"`
+			}
+			if status := viewShare(t, app, share.Token, tt.password, &out); status != tt.status || out.Error != tt.error {
+				t.Fatalf("status %d %q, want %d %q", status, out.Error, tt.status, tt.error)
+			}
+			if tt.status == 200 && (out.Title != "시험" || out.Memo != "") {
+				t.Errorf("view = %+v, want the title without the memo", out)
+			}
+		})
+	}
+
+	if status := viewShare(t, app, "unknown", "", nil); status != 404 {
+		t.Errorf("unknown token: status %d, want 404", status)
+	}
+
+	// Only the view that got through is counted.
+	var shares struct {
+		Data []shareResponse `json:"data"`
+	}
+	send(t, app, "GET", "/api/v1/ddays/"+dday.ID+"/shares", owner.AccessToken, nil, &shares)
+	if len(shares.Data) != 1 || shares.Data[0].ViewCount != 1 {
+		t.Errorf("shares = %+v, want one with 1 view", shares.Data)
+	}
+}
+
+func TestViewShareExpired(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com")
+	id := createDday(t, app, owner.AccessToken, "시험", "2025-06-01")
+	share := createShare(t, app, owner.AccessToken, id, fiber.Map{"expires_at": time.Now().Add(time.Hour), "show_memo": true})
+
+	if status := viewShare(t, app, share.Token, "", nil); status != 200 {
+		t.Fatalf("before expiry: status %d, want 200", status)
+	}
+
+	t.Cleanup(func() { controllers.Now = time.Now })
+	controllers.Now = func() time.Time { return time.Now().Add(time.Hour) }
+	if status := viewShare(t, app, share.Token, "", nil); status != 410 {
+		t.Errorf("after expiry: status %d, want 410", status)
+	}
+}
+
+func TestViewShareRevoked(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com")
+	id := createDday(t, app, owner.AccessToken, "시험", "2025-06-01")
+	share := createShare(t, app, owner.AccessToken, id, fiber.Map{})
+
+	path := "/api/v1/ddays/" + id + "/shares/"
+	if status := send(t, app, "DELETE", path+"999", owner.AccessToken, nil, nil); status != 404 {
+		t.Errorf("revoke unknown share: status %d, want 404", status)
+	}
+	if status := send(t, app, "DELETE", path+strconv.Itoa(share.ID), owner.AccessToken, nil, nil); status != 204 {
+		t.Fatalf("revoke: status %d, want 204", status)
+	}
+	var out errorResponse
+	if status := viewShare(t, app, share.Token, "", &out); status != 410 || out.Error != "Share has been revoked" {
+		t.Errorf("status %d %q, want 410 Share has been revoked", status, out.Error)
+	}
+}
+
+func TestViewShareThrottlesGuesses(t *testing.T) {
+	app := newTestApp(t)
+	owner := signup(t, app, "owner@example.com")
+	id := createDday(t, app, owner.AccessToken, "시험", "2025-06-01")
+	attacked := createShare(t, app, owner.AccessToken, id, fiber.Map{"password": "open sesame"})
+	other := createShare(t, app, owner.AccessToken, id, fiber.Map{"password": "open sesame"})
+
+	for i := 0; i < 10; i++ {
+		if status := viewShare(t, app, attacked.Token, "guess", nil); status != 401 {
+			t.Fatalf("guess %d: status %d, want 401", i, status)
+		}
+	}
+	if status := viewShare(t, app, attacked.Token, "open sesame", nil); status != 429 {
+		t.Errorf("right password after 10 guesses: status %d, want 429", status)
+	}
+	if status := viewShare(t, app, other.Token, "open sesame", nil); status != 200 {
+		t.Errorf("another link: status %d, want 200", status)
+	}
+}
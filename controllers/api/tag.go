@@ -0,0 +1,39 @@
+package api
+
+import (
+	"dday-backend/controllers"
+	"dday-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TagController struct {
+	*controllers.Controller
+	manager models.TagStore
+}
+
+func NewTagController(store models.TagStore) *TagController {
+	return &TagController{manager: store}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *TagController) with(c *fiber.Ctx) *TagController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+// GetTags lists the user's tags with how many D-Days carry each, most used
+// first. Tags of trashed D-Days are not counted.
+func (ctrl *TagController) GetTags(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	tags, err := ctrl.manager.List(ctrl.GetUserID())
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch tags")
+	}
+
+	return ctrl.Success(fiber.Map{
+		"data": tags,
+	})
+}
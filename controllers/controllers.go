@@ -1,12 +1,25 @@
 package controllers
 
 import (
+	"bufio"
+	"dday-backend/global/config"
+	"dday-backend/middleware"
+	"dday-backend/models"
+	"encoding/json"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// Now is the clock behind Today, replaceable to pin the date.
+var Now = time.Now
+
+// Controller wraps a single request. Route handlers are shared by every
+// request, so each handler builds its own Controller rather than storing one
+// on the handler.
 type Controller struct {
 	c *fiber.Ctx
 }
@@ -67,6 +80,35 @@ func (ctrl *Controller) Body(out interface{}) error {
 	return ctrl.c.BodyParser(out)
 }
 
+// Upload returns the file uploaded as field of a multipart form, or the raw
+// request body when the request is not a form.
+func (ctrl *Controller) Upload(field string) ([]byte, error) {
+	if !strings.HasPrefix(ctrl.c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return ctrl.c.Body(), nil
+	}
+
+	header, err := ctrl.c.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// PatchFields reads the body of a PATCH to current: a JSON Patch (RFC 6902)
+// when sent as application/json-patch+json, else a JSON merge patch
+// (RFC 7396).
+func (ctrl *Controller) PatchFields(current *models.DDay) (map[string]json.RawMessage, error) {
+	if strings.HasPrefix(ctrl.c.Get(fiber.HeaderContentType), models.MIMEJSONPatch) {
+		return models.JSONPatchFields(current, ctrl.c.Body())
+	}
+	return models.MergePatchFields(ctrl.c.Body())
+}
+
 func (ctrl *Controller) JSON(data interface{}) error {
 	return ctrl.c.JSON(data)
 }
@@ -89,10 +131,59 @@ func (ctrl *Controller) Created(data interface{}) error {
 	return ctrl.c.Status(201).JSON(data)
 }
 
+// Send responds with a body that is not JSON.
+func (ctrl *Controller) Send(contentType string, body []byte) error {
+	ctrl.c.Set(fiber.HeaderContentType, contentType)
+	return ctrl.c.Send(body)
+}
+
+// Stream responds with a body that write produces after the handler has
+// returned, so it is sent as it is written. write must not use the request's
+// fiber.Ctx, which is recycled by then.
+func (ctrl *Controller) Stream(contentType string, write func(w *bufio.Writer)) error {
+	ctrl.c.Set(fiber.HeaderContentType, contentType)
+	ctrl.c.Context().SetBodyStreamWriter(write)
+	return nil
+}
+
+// Attachment asks the client to save the response as filename.
+func (ctrl *Controller) Attachment(filename string) {
+	ctrl.c.Attachment(filename)
+}
+
+// BaseURL is the scheme and host the request was made to.
+func (ctrl *Controller) BaseURL() string {
+	return ctrl.c.BaseURL()
+}
+
 func (ctrl *Controller) NoContent() error {
 	return ctrl.c.SendStatus(204)
 }
 
+func (ctrl *Controller) NotModified() error {
+	return ctrl.c.SendStatus(304)
+}
+
+func (ctrl *Controller) Conflict(message string) error {
+	return ctrl.Error(409, message)
+}
+
+func (ctrl *Controller) PreconditionFailed(message string) error {
+	return ctrl.Error(412, message)
+}
+
+func (ctrl *Controller) Gone(message string) error {
+	return ctrl.Error(410, message)
+}
+
+func (ctrl *Controller) Unauthorized(message string) error {
+	return ctrl.Error(401, message)
+}
+
+func (ctrl *Controller) Forbidden(message string) error {
+	return ctrl.Error(403, message)
+}
+
 func (ctrl *Controller) BadRequest(message string) error {
 	return ctrl.Error(400, message)
 }
@@ -122,33 +213,37 @@ func (ctrl *Controller) GetPagination() (page int, pageSize int) {
 	return page, pageSize
 }
 
-func (ctrl *Controller) GetOrderBy() string {
-	orderBy := ctrl.Query("orderBy")
-	if orderBy == "" {
-		return ""
-	}
-
-	// Map API column names to database column names
-	columnMapping := map[string]string{
-		"id":           "d_id",
-		"title":        "d_title",
-		"target_date":  "d_target_date",
-		"category":     "d_category",
-		"is_important": "d_is_important",
-		"created_at":   "d_created_at",
-		"updated_at":   "d_updated_at",
+// GetCursor returns the cursor query parameter and whether it was sent at all;
+// an empty "cursor=" asks for the first page in keyset mode.
+func (ctrl *Controller) GetCursor() (string, bool) {
+	if !ctrl.c.Context().QueryArgs().Has("cursor") {
+		return "", false
 	}
+	return strings.TrimSpace(ctrl.Query("cursor")), true
+}
 
-	direction := ctrl.Query("direction")
-	if direction != "ASC" && direction != "DESC" {
-		direction = "ASC"
+// GetIncludeCount reads the count query parameter, which lets list clients
+// skip the COUNT(*) query.
+func (ctrl *Controller) GetIncludeCount(defaultValue bool) bool {
+	switch ctrl.Query("count") {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
 	}
+	return defaultValue
+}
 
-	if dbColumn, exists := columnMapping[strings.ToLower(orderBy)]; exists {
-		return dbColumn + " " + direction
+// GetSort reads the orderBy/direction query parameters. The field is not
+// checked here; QueryBuilder.OrderBy rejects fields that cannot be sorted.
+func (ctrl *Controller) GetSort() (models.Sort, bool) {
+	orderBy := strings.ToLower(strings.TrimSpace(ctrl.Query("orderBy")))
+	if orderBy == "" {
+		return models.Sort{}, false
 	}
 
-	return ""
+	direction := ctrl.Query("direction")
+	return models.Sort{Field: models.Field(orderBy), Desc: direction == "DESC"}, true
 }
 
 func (ctrl *Controller) GetSearch() string {
@@ -175,4 +270,94 @@ func (ctrl *Controller) GetIsImportant() *bool {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// GetUser returns the authenticated user, or nil on routes without
+// middleware.RequireAuth.
+func (ctrl *Controller) GetUser() *models.User {
+	return middleware.CurrentUser(ctrl.c)
+}
+
+// GetUserID returns the authenticated user's ID, which scopes every D-Day
+// store call.
+func (ctrl *Controller) GetUserID() string {
+	if user := ctrl.GetUser(); user != nil {
+		return user.ID
+	}
+	return ""
+}
+
+// Location is the timezone the request asked for with X-Timezone or tz,
+// else the authenticated user's, else the server's.
+func (ctrl *Controller) Location() *time.Location {
+	if loc := middleware.RequestLocation(ctrl.c); loc != nil {
+		return loc
+	}
+	if user := ctrl.GetUser(); user != nil {
+		return user.Location(config.AppConfig.Server.Location)
+	}
+	return config.AppConfig.Server.Location
+}
+
+// Today is the current date in the request's Location, as midnight UTC like
+// the parsed target dates it is compared with.
+func (ctrl *Controller) Today() time.Time {
+	now := Now().In(ctrl.Location())
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Countdown fills the fields of ddays computed relative to Today.
+func (ctrl *Controller) Countdown(ddays []models.DDay) []models.DDay {
+	today := ctrl.Today()
+	for i := range ddays {
+		ddays[i].SetToday(today)
+	}
+	return ddays
+}
+
+func (ctrl *Controller) SetETag(etag string) {
+	ctrl.c.Set(fiber.HeaderETag, etag)
+}
+
+// IfNoneMatch reports whether If-None-Match matches etag, using the weak
+// comparison RFC 9110 prescribes for that header.
+func (ctrl *Controller) IfNoneMatch(etag string) bool {
+	return matchETag(ctrl.Get(fiber.HeaderIfNoneMatch), etag, true)
+}
+
+// HasIfMatch reports whether the request carries an If-Match precondition.
+func (ctrl *Controller) HasIfMatch() bool {
+	return ctrl.Get(fiber.HeaderIfMatch) != ""
+}
+
+// IfMatch reports whether the If-Match precondition holds for etag. A request
+// without If-Match always passes.
+func (ctrl *Controller) IfMatch(etag string) bool {
+	header := ctrl.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return true
+	}
+	return matchETag(header, etag, false)
+}
+
+func matchETag(header, etag string, weak bool) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
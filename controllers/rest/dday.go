@@ -1,8 +1,10 @@
 package rest
 
 import (
+	"database/sql"
 	"dday-backend/controllers"
 	"dday-backend/models"
+	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,116 +13,314 @@ import (
 
 type DdayController struct {
 	*controllers.Controller
-	manager *models.DdayManager
+	manager    models.DdayStore
+	categories models.CategoryStore
 }
 
-func NewDdayController(c *fiber.Ctx) *DdayController {
-	return &DdayController{
-		Controller: controllers.NewController(c),
-		manager:    models.NewDdayManager(),
+func NewDdayController(store models.DdayStore, categories models.CategoryStore) *DdayController {
+	return &DdayController{manager: store, categories: categories}
+}
+
+// with returns a copy of the controller bound to the request c.
+func (ctrl *DdayController) with(c *fiber.Ctx) *DdayController {
+	bound := *ctrl
+	bound.Controller = controllers.NewController(c)
+	return &bound
+}
+
+// resolveCategory validates a requested category, substituting the default
+// for an empty one.
+func (ctrl *DdayController) resolveCategory(category string) (string, bool, error) {
+	if category == "" {
+		name, err := ctrl.categories.Default()
+		return name, err == nil, err
 	}
+	ok, err := ctrl.categories.Exists(category)
+	return category, ok, err
 }
 
 func (ctrl *DdayController) List(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	page, pageSize := ctrl.GetPagination()
-	orderBy := ctrl.GetOrderBy()
-
-	var args []interface{}
 
-	if orderBy != "" {
-		args = append(args, models.NewOrdering(orderBy))
+	builder := models.NewQuery()
+	if sort, ok := ctrl.GetSort(); ok {
+		builder.OrderBy(sort.Field, sort.Desc)
 	}
 
-	args = append(args, models.NewPaging(page, pageSize))
+	query, err := builder.Page(page, pageSize).Build()
+	if err != nil {
+		return ctrl.BadRequest(err.Error())
+	}
 
-	ddays, err := ctrl.manager.GetAll(args...)
+	ddays, err := ctrl.manager.GetAll(ctrl.GetUserID(), query)
 	if err != nil {
 		return ctrl.InternalServerError("Failed to fetch D-Days")
 	}
 
-	return ctrl.Success(ddays)
+	return ctrl.Success(ctrl.Countdown(ddays))
 }
 
 func (ctrl *DdayController) Get(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	id := ctrl.Params("id")
 	if id == "" {
 		return ctrl.BadRequest("ID is required")
 	}
 
-	dday, err := ctrl.manager.GetByID(id)
+	dday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
 	if err != nil {
 		return ctrl.NotFound("D-Day not found")
 	}
 
+	etag := dday.ETag()
+	ctrl.SetETag(etag)
+	if ctrl.IfNoneMatch(etag) {
+		return ctrl.NotModified()
+	}
+
+	dday.SetToday(ctrl.Today())
 	return ctrl.Success(dday)
 }
 
 func (ctrl *DdayController) Create(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	var dday models.DDay
 	if err := ctrl.Body(&dday); err != nil {
 		return ctrl.BadRequest("Invalid request body")
 	}
 
+	recurrence, interval, err := models.ParseRecurrence(dday.Recurrence, dday.RecurrenceInterval)
+	if err != nil {
+		return ctrl.BadRequest("Invalid recurrence")
+	}
+	dday.Recurrence, dday.RecurrenceInterval = recurrence, interval
+	category, ok, err := ctrl.resolveCategory(dday.Category)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load categories")
+	}
+	if !ok {
+		return ctrl.BadRequest("Invalid category")
+	}
+	dday.Category = category
+	if err := dday.ResolveCalendar(); err != nil {
+		return ctrl.BadRequest("Invalid calendar")
+	}
+	if dday.Tags != nil {
+		if dday.Tags, err = models.NormalizeTags(dday.Tags); err != nil {
+			return ctrl.BadRequest("Invalid tags")
+		}
+	}
+
 	dday.ID = uuid.New().String()
-	dday.CreatedAt = time.Now()
+	dday.CreatedAt = time.Now().UTC()
 
-	if err := ctrl.manager.Create(&dday); err != nil {
+	if err := ctrl.manager.Create(ctrl.GetUserID(), &dday); err != nil {
 		return ctrl.InternalServerError("Failed to create D-Day")
 	}
 
-	return ctrl.Created(dday)
+	saved, err := ctrl.manager.GetByID(ctrl.GetUserID(), dday.ID)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch created D-Day")
+	}
+
+	saved.SetToday(ctrl.Today())
+	ctrl.SetETag(saved.ETag())
+	return ctrl.Created(saved)
 }
 
 func (ctrl *DdayController) Update(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	id := ctrl.Params("id")
 	if id == "" {
 		return ctrl.BadRequest("ID is required")
 	}
 
-	existingDday, err := ctrl.manager.GetByID(id)
+	existingDday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
 	if err != nil {
 		return ctrl.NotFound("D-Day not found")
 	}
 
+	if !ctrl.IfMatch(existingDday.ETag()) {
+		return ctrl.PreconditionFailed("D-Day has been modified")
+	}
+
 	var updatedDday models.DDay
 	if err := ctrl.Body(&updatedDday); err != nil {
 		return ctrl.BadRequest("Invalid request body")
 	}
 
+	recurrence, interval, err := models.ParseRecurrence(updatedDday.Recurrence, updatedDday.RecurrenceInterval)
+	if err != nil {
+		return ctrl.BadRequest("Invalid recurrence")
+	}
+	updatedDday.Recurrence, updatedDday.RecurrenceInterval = recurrence, interval
+	category, ok, err := ctrl.resolveCategory(updatedDday.Category)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to load categories")
+	}
+	if !ok {
+		return ctrl.BadRequest("Invalid category")
+	}
+	updatedDday.Category = category
+	if err := updatedDday.ResolveCalendar(); err != nil {
+		return ctrl.BadRequest("Invalid calendar")
+	}
+	if updatedDday.Tags != nil {
+		if updatedDday.Tags, err = models.NormalizeTags(updatedDday.Tags); err != nil {
+			return ctrl.BadRequest("Invalid tags")
+		}
+	}
+
 	updatedDday.ID = id
 	updatedDday.CreatedAt = existingDday.CreatedAt
+	updatedDday.Version = 0
+	if ctrl.HasIfMatch() {
+		updatedDday.Version = existingDday.Version
+	}
 
-	if err := ctrl.manager.Update(id, &updatedDday); err != nil {
+	if err := ctrl.manager.Update(ctrl.GetUserID(), id, &updatedDday); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return ctrl.PreconditionFailed("D-Day has been modified")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Editor role required")
+		}
 		return ctrl.InternalServerError("Failed to update D-Day")
 	}
 
-	return ctrl.Success(updatedDday)
+	saved, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch updated D-Day")
+	}
+
+	saved.SetToday(ctrl.Today())
+	ctrl.SetETag(saved.ETag())
+	return ctrl.Success(saved)
+}
+
+// Patch updates only the fields the request sends, as a merge patch or a
+// JSON Patch.
+func (ctrl *DdayController) Patch(c *fiber.Ctx) error {
+	ctrl = ctrl.with(c)
+
+	id := ctrl.Params("id")
+	if id == "" {
+		return ctrl.BadRequest("ID is required")
+	}
+
+	existingDday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.NotFound("D-Day not found")
+	}
+
+	if !ctrl.IfMatch(existingDday.ETag()) {
+		return ctrl.PreconditionFailed("D-Day has been modified")
+	}
+
+	fields, err := ctrl.PatchFields(existingDday)
+	if err != nil {
+		return ctrl.patchFailed(err)
+	}
+	patch, err := models.NewDdayPatch(existingDday, fields)
+	if err != nil {
+		return ctrl.patchFailed(err)
+	}
+
+	if patch.Category != nil {
+		category, ok, err := ctrl.resolveCategory(*patch.Category)
+		if err != nil {
+			return ctrl.InternalServerError("Failed to load categories")
+		}
+		if !ok {
+			return ctrl.BadRequest("Invalid category")
+		}
+		patch.Category = &category
+	}
+
+	if !patch.IsEmpty() {
+		if ctrl.HasIfMatch() {
+			patch.Version = existingDday.Version
+		}
+		if err := ctrl.manager.Patch(ctrl.GetUserID(), id, patch); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ctrl.NotFound("D-Day not found")
+			}
+			if errors.Is(err, models.ErrVersionConflict) {
+				return ctrl.PreconditionFailed("D-Day has been modified")
+			}
+			if errors.Is(err, models.ErrForbidden) {
+				return ctrl.Forbidden("Editor role required")
+			}
+			return ctrl.InternalServerError("Failed to update D-Day")
+		}
+	}
+
+	saved, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
+	if err != nil {
+		return ctrl.InternalServerError("Failed to fetch updated D-Day")
+	}
+
+	saved.SetToday(ctrl.Today())
+	ctrl.SetETag(saved.ETag())
+	return ctrl.Success(saved)
+}
+
+func (ctrl *DdayController) patchFailed(err error) error {
+	switch {
+	case errors.Is(err, models.ErrPatchTestFailed):
+		return ctrl.Conflict(err.Error())
+	case errors.Is(err, models.ErrInvalidRecurrence):
+		return ctrl.BadRequest("Invalid recurrence")
+	case errors.Is(err, models.ErrInvalidPatch), errors.Is(err, models.ErrTitleRequired),
+		errors.Is(err, models.ErrTargetDateRequired), errors.Is(err, models.ErrInvalidTargetDate),
+		errors.Is(err, models.ErrInvalidTags):
+		return ctrl.BadRequest(err.Error())
+	}
+	return ctrl.BadRequest("Invalid calendar")
 }
 
 func (ctrl *DdayController) Delete(c *fiber.Ctx) error {
-	ctrl.Controller = controllers.NewController(c)
+	ctrl = ctrl.with(c)
 
 	id := ctrl.Params("id")
 	if id == "" {
 		return ctrl.BadRequest("ID is required")
 	}
 
-	_, err := ctrl.manager.GetByID(id)
+	existingDday, err := ctrl.manager.GetByID(ctrl.GetUserID(), id)
 	if err != nil {
 		return ctrl.NotFound("D-Day not found")
 	}
 
-	if err := ctrl.manager.Delete(id); err != nil {
+	if !ctrl.IfMatch(existingDday.ETag()) {
+		return ctrl.PreconditionFailed("D-Day has been modified")
+	}
+
+	version := 0
+	if ctrl.HasIfMatch() {
+		version = existingDday.Version
+	}
+
+	if err := ctrl.manager.Delete(ctrl.GetUserID(), id, version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ctrl.NotFound("D-Day not found")
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return ctrl.PreconditionFailed("D-Day has been modified")
+		}
+		if errors.Is(err, models.ErrForbidden) {
+			return ctrl.Forbidden("Owner role required")
+		}
 		return ctrl.InternalServerError("Failed to delete D-Day")
 	}
 
 	return ctrl.NoContent()
-}
\ No newline at end of file
+}
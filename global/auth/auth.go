@@ -0,0 +1,169 @@
+// Package auth hashes passwords and issues the tokens used to log in: short
+// lived JWT access tokens and opaque refresh tokens stored by hash.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dday-backend/global/config"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const issuer = "dday-backend"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+var (
+	secretOnce sync.Once
+	secret     []byte
+)
+
+func signingKey() []byte {
+	secretOnce.Do(func() {
+		if key := config.AppConfig.Auth.JWTSecret; key != "" {
+			secret = []byte(key)
+			return
+		}
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Failed to generate JWT secret: %v", err)
+		}
+		log.Println("JWT_SECRET is not set; using a random key, so tokens will not survive a restart")
+	})
+	return secret
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword reports whether password matches hash. An empty hash is
+// compared against a dummy so unknown accounts take as long as known ones.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dday-backend"), bcrypt.DefaultCost)
+
+// IssueAccessToken signs an access token for userID.
+func IssueAccessToken(userID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.AppConfig.Auth.AccessTokenTTLMinutes) * time.Minute)
+
+	claims := jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   userID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey())
+	return token, expiresAt, err
+}
+
+// ParseAccessToken verifies an access token and returns its user ID.
+func ParseAccessToken(token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return signingKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil || claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	return claims.Subject, nil
+}
+
+// NewRefreshToken returns a random refresh token for the client, the hash to
+// store in its place and its expiry.
+func NewRefreshToken() (string, string, time.Time, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	expiresAt := time.Now().AddDate(0, 0, config.AppConfig.Auth.RefreshTokenTTLDays)
+	return token, HashRefreshToken(token), expiresAt, nil
+}
+
+func HashRefreshToken(token string) string {
+	return hashToken(token)
+}
+
+// NewFeedToken returns a random token for a calendar feed URL and the hash
+// to store in its place. Feed tokens last until they are replaced.
+func NewFeedToken() (string, string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashFeedToken(token), nil
+}
+
+func HashFeedToken(token string) string {
+	return hashToken(token)
+}
+
+// NewShareToken returns a random token for a D-Day share link and the hash to
+// store in its place.
+func NewShareToken() (string, string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashShareToken(token), nil
+}
+
+func HashShareToken(token string) string {
+	return hashToken(token)
+}
+
+// NewInvitationCode returns a random code, short enough to type, for
+// joining a D-Day and the hash to store in its place.
+func NewInvitationCode() (string, string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	// 10 of 32 symbols: 50 bits, enough for a code that expires in a week.
+	code := make([]byte, len(raw))
+	for i, b := range raw {
+		code[i] = invitationAlphabet[b%32]
+	}
+	return string(code), HashInvitationCode(string(code)), nil
+}
+
+// HashInvitationCode hashes a code as typed, ignoring case, spaces and
+// dashes.
+func HashInvitationCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	return hashToken(code)
+}
+
+// invitationAlphabet leaves out 0, 1, I and O, which are easily confused.
+const invitationAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,19 +4,30 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
+	Trash    TrashConfig
+	Auth     AuthConfig
+	Reminder ReminderConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// Timezone is the IANA zone in which users' days begin and end, and
+	// Location the zone it names.
+	Timezone string
+	Location *time.Location
 }
 
 type DatabaseConfig struct {
+	Driver       string
+	Path         string
 	Host         string
 	Port         string
 	User         string
@@ -25,6 +36,36 @@ type DatabaseConfig struct {
 	MaxOpenConns int
 	MaxIdleConns int
 	MaxLifetime  int
+	AutoMigrate  bool
+	// OwnerlessOwner is the email of the account that receives D-Days
+	// created before D-Days had owners.
+	OwnerlessOwner string
+}
+
+// TrashConfig controls how long soft-deleted D-Days are kept. A
+// RetentionDays of 0 or less disables the purge job.
+type TrashConfig struct {
+	RetentionDays        int
+	PurgeIntervalMinutes int
+}
+
+// ReminderConfig controls the reminder scheduler. An IntervalMinutes of 0 or
+// less disables it. Notifier picks how reminders are delivered: "log" or
+// "webhook", which POSTs each one as JSON to WebhookURL.
+type ReminderConfig struct {
+	IntervalMinutes int
+	Notifier        string
+	WebhookURL      string
+}
+
+// AuthConfig signs and expires login tokens. An empty JWTSecret makes the
+// server generate a random one at startup, which logs everyone out on restart.
+// AdminEmails lists the accounts allowed to manage the shared categories.
+type AuthConfig struct {
+	JWTSecret             string
+	AccessTokenTTLMinutes int
+	RefreshTokenTTLDays   int
+	AdminEmails           []string
 }
 
 var AppConfig *Config
@@ -32,23 +73,50 @@ var AppConfig *Config
 func LoadConfig() {
 	AppConfig = &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:     getEnv("PORT", "8080"),
+			Env:      getEnv("ENV", "development"),
+			Timezone: getEnv("TIMEZONE", "Asia/Seoul"),
 		},
 		Database: DatabaseConfig{
-			Host:         getEnv("DB_HOST", "localhost"),
-			Port:         getEnv("DB_PORT", "3306"),
-			User:         getEnv("DB_USER", "root"),
-			Password:     getEnv("DB_PASSWORD", ""),
-			Name:         getEnv("DB_NAME", "dday"),
-			MaxOpenConns: getEnvInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns: getEnvInt("DB_MAX_IDLE_CONNS", 25),
-			MaxLifetime:  getEnvInt("DB_CONN_MAX_LIFETIME", 300),
+			Driver:         getEnv("DB_DRIVER", "mysql"),
+			Path:           getEnv("DB_PATH", "dday.db"),
+			Host:           getEnv("DB_HOST", "localhost"),
+			Port:           getEnv("DB_PORT", "3306"),
+			User:           getEnv("DB_USER", "root"),
+			Password:       getEnv("DB_PASSWORD", ""),
+			Name:           getEnv("DB_NAME", "dday"),
+			MaxOpenConns:   getEnvInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:   getEnvInt("DB_MAX_IDLE_CONNS", 25),
+			MaxLifetime:    getEnvInt("DB_CONN_MAX_LIFETIME", 300),
+			AutoMigrate:    getEnvBool("DB_AUTO_MIGRATE", true),
+			OwnerlessOwner: getEnv("DB_ASSIGN_OWNERLESS_TO", ""),
 		},
+		Trash: TrashConfig{
+			RetentionDays:        getEnvInt("TRASH_RETENTION_DAYS", 30),
+			PurgeIntervalMinutes: getEnvInt("TRASH_PURGE_INTERVAL_MINUTES", 60),
+		},
+		Auth: AuthConfig{
+			JWTSecret:             getEnv("JWT_SECRET", ""),
+			AccessTokenTTLMinutes: getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15),
+			RefreshTokenTTLDays:   getEnvInt("REFRESH_TOKEN_TTL_DAYS", 14),
+			AdminEmails:           getEnvList("ADMIN_EMAILS"),
+		},
+		Reminder: ReminderConfig{
+			IntervalMinutes: getEnvInt("REMINDER_INTERVAL_MINUTES", 5),
+			Notifier:        getEnv("NOTIFIER", "log"),
+			WebhookURL:      getEnv("NOTIFIER_WEBHOOK_URL", ""),
+		},
+	}
+
+	loc, err := time.LoadLocation(AppConfig.Server.Timezone)
+	if err != nil {
+		log.Fatal("Invalid TIMEZONE:", err)
 	}
+	AppConfig.Server.Location = loc
 
-	log.Printf("Config loaded - Port: %s, DB: %s@%s:%s/%s",
+	log.Printf("Config loaded - Port: %s, Driver: %s, DB: %s@%s:%s/%s",
 		AppConfig.Server.Port,
+		AppConfig.Database.Driver,
 		AppConfig.Database.User,
 		AppConfig.Database.Host,
 		AppConfig.Database.Port,
@@ -69,4 +137,24 @@ func getEnvInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
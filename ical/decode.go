@@ -0,0 +1,210 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrNotCalendar = errors.New("not an iCalendar file")
+
+const (
+	dateTimeLayout    = "20060102T150405"
+	utcDateTimeLayout = "20060102T150405Z"
+)
+
+// Decode reads the VEVENTs of an iCalendar stream, ignoring other
+// components and properties it has no use for.
+//
+// Decoded events set AllDay when DTSTART is a DATE. Timed events keep their
+// start in Date: UTC for a "Z" time, the TZID zone when it loads, and loc
+// for floating times and unknown zones. RDATEs are read the same way.
+func Decode(r io.Reader, loc *time.Location) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+	seen := false
+	var stack []string
+	var event *Event
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		name, params, value, ok := splitLine(line)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			if len(stack) == 0 && component != "VCALENDAR" {
+				return nil, ErrNotCalendar
+			}
+			seen = true
+			stack = append(stack, component)
+			if component == "VEVENT" && len(stack) == 2 {
+				event = &Event{}
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, ErrNotCalendar
+			}
+			if len(stack) == 2 && stack[1] == "VEVENT" && event != nil {
+				cal.Events = append(cal.Events, *event)
+				event = nil
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if len(stack) == 0 {
+			return nil, ErrNotCalendar
+		}
+		if len(stack) == 1 && name == "X-WR-CALNAME" {
+			cal.Name = unescapeText(value)
+		}
+		if len(stack) == 1 && name == "PRODID" {
+			cal.ProdID = value
+		}
+		// Properties of nested components such as VALARM are skipped.
+		if event == nil || len(stack) != 2 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			event.UID = value
+		case "SUMMARY":
+			event.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(value)
+		case "CATEGORIES":
+			for _, category := range splitText(value) {
+				if category = strings.TrimSpace(category); category != "" {
+					event.Categories = append(event.Categories, category)
+				}
+			}
+		case "DTSTART":
+			if date, allDay, err := parseDate(value, params, loc); err == nil {
+				event.Date, event.AllDay = date, allDay
+			}
+		case "RRULE":
+			event.RRule = value
+		case "RDATE":
+			for _, part := range strings.Split(value, ",") {
+				if date, _, err := parseDate(part, params, loc); err == nil {
+					event.RDates = append(event.RDates, date)
+				}
+			}
+		}
+	}
+
+	if !seen {
+		return nil, ErrNotCalendar
+	}
+	return cal, nil
+}
+
+// unfold joins continuation lines, which start with a space or a tab, onto
+// the line before them.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitLine splits a content line into its upper-cased name, its parameters
+// and its value. A colon inside a quoted parameter value does not end the
+// parameters.
+func splitLine(line string) (string, map[string]string, string, bool) {
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if key, val, ok := strings.Cut(part, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+// parseDate reads a DATE or DATE-TIME value. DATEs come back at midnight
+// UTC.
+func parseDate(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		date, err := time.Parse(dateLayout, value)
+		return date, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcDateTimeLayout, value)
+		return t, false, err
+	}
+	if tzid := params["TZID"]; tzid != "" && tzid != "Local" {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	return t, false, err
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// unescapeText reverses escapeText.
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// splitText splits a list of TEXT values on the commas that are not escaped
+// and unescapes each value.
+func splitText(s string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, unescapeText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescapeText(s[start:]))
+}
@@ -0,0 +1,171 @@
+// Package ical writes and reads the parts of iCalendar (RFC 5545) that
+// D-Days map onto: all-day VEVENTs with recurrence rules and display alarms.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout  = "20060102"
+	stampLayout = "20060102T150405Z"
+
+	// maxLineOctets is the longest a content line may be before it is
+	// folded onto continuation lines.
+	maxLineOctets = 75
+)
+
+// Calendar is a VCALENDAR. Name is shown by calendar apps that honour
+// X-WR-CALNAME.
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Event is an all-day VEVENT on Date. RRule is the value of an RRULE such as
+// "FREQ=YEARLY;INTERVAL=1", and RDates are extra dates the event recurs on.
+// Events are always encoded as all-day; AllDay is only set by Decode.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Categories   []string
+	Date         time.Time
+	AllDay       bool
+	RRule        string
+	RDates       []time.Time
+	Alarms       []Alarm
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Alarm is a display alarm at the start of the day DaysBefore days ahead of
+// an event.
+type Alarm struct {
+	DaysBefore  int
+	Description string
+}
+
+// Encode writes cal to w with CRLF line endings, folding long lines.
+func (cal *Calendar) Encode(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", cal.ProdID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		e.line("X-WR-CALNAME", escapeText(cal.Name))
+	}
+	for i := range cal.Events {
+		e.event(&cal.Events[i])
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) event(ev *Event) {
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", ev.UID)
+	e.line("DTSTAMP", ev.LastModified.UTC().Format(stampLayout))
+	if !ev.Created.IsZero() {
+		e.line("CREATED", ev.Created.UTC().Format(stampLayout))
+	}
+	if !ev.LastModified.IsZero() {
+		e.line("LAST-MODIFIED", ev.LastModified.UTC().Format(stampLayout))
+	}
+	e.line("DTSTART;VALUE=DATE", ev.Date.Format(dateLayout))
+	e.line("DTEND;VALUE=DATE", ev.Date.AddDate(0, 0, 1).Format(dateLayout))
+	e.line("SUMMARY", escapeText(ev.Summary))
+	if ev.Description != "" {
+		e.line("DESCRIPTION", escapeText(ev.Description))
+	}
+	if len(ev.Categories) > 0 {
+		categories := make([]string, len(ev.Categories))
+		for i, category := range ev.Categories {
+			categories[i] = escapeText(category)
+		}
+		e.line("CATEGORIES", strings.Join(categories, ","))
+	}
+	if ev.RRule != "" {
+		e.line("RRULE", ev.RRule)
+	}
+	if len(ev.RDates) > 0 {
+		dates := make([]string, len(ev.RDates))
+		for i, date := range ev.RDates {
+			dates[i] = date.Format(dateLayout)
+		}
+		e.line("RDATE;VALUE=DATE", strings.Join(dates, ","))
+	}
+	e.line("TRANSP", "TRANSPARENT")
+	for _, alarm := range ev.Alarms {
+		e.line("BEGIN", "VALARM")
+		e.line("ACTION", "DISPLAY")
+		e.line("DESCRIPTION", escapeText(alarm.Description))
+		e.line("TRIGGER", trigger(alarm.DaysBefore))
+		e.line("END", "VALARM")
+	}
+	e.line("END", "VEVENT")
+}
+
+// trigger is the TRIGGER of an alarm daysBefore days ahead of the start.
+func trigger(daysBefore int) string {
+	if daysBefore == 0 {
+		return "PT0S"
+	}
+	return fmt.Sprintf("-P%dD", daysBefore)
+}
+
+// line writes a content line, folding it at maxLineOctets without splitting
+// a UTF-8 sequence.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		e.write(line[:cut], "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = maxLineOctets - 1
+	}
+	e.write(line, "\r\n")
+}
+
+func (e *encoder) write(s ...string) {
+	for _, part := range s {
+		if e.err != nil {
+			return
+		}
+		_, e.err = e.w.WriteString(part)
+	}
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"dday-backend/models"
+	"log"
+	"time"
+)
+
+// StartTrashPurge permanently deletes D-Days that have sat in the trash for
+// longer than retention, checking once at startup and then every interval.
+func StartTrashPurge(ctx context.Context, store models.DdayStore, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := store.Purge(time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Trash purge failed: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d D-Day(s) from trash", purged)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
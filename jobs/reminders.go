@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"dday-backend/models"
+	"dday-backend/notify"
+	"log"
+	"time"
+)
+
+// StartReminders sends the reminders that go off today, checking once at
+// startup and then every interval. A reminder goes off its days before ahead
+// of each occurrence of its D-Day, reckoned in its owner's timezone or, for
+// owners without one, in loc.
+func StartReminders(ctx context.Context, store models.ReminderStore, notifier notify.Notifier, loc *time.Location, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sent, err := sendReminders(ctx, store, notifier, loc, time.Now())
+			if err != nil {
+				log.Printf("Reminder check failed: %v", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d reminder(s)", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sendReminders notifies every reminder due today in its owner's timezone.
+// Each one is marked sent before it is handed to the notifier, so a crash or
+// a failed delivery loses that reminder rather than sending it twice.
+func sendReminders(ctx context.Context, store models.ReminderStore, notifier notify.Notifier, loc *time.Location, now time.Time) (int, error) {
+	// Timezones run from UTC-12 to UTC+14, so every owner's today is within
+	// a day of the UTC date.
+	utc := localDate(now, time.UTC)
+	earliest := utc.AddDate(0, 0, -1).Format("2006-01-02")
+	latest := utc.AddDate(0, 0, 1+models.MaxReminderDaysBefore).Format("2006-01-02")
+
+	scheduled, err := store.Scheduled(earliest, latest)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, s := range scheduled {
+		day := localDate(now, s.Owner.Location(loc))
+		today := day.Format("2006-01-02")
+		occurrence, ok := s.DueOccurrence(day)
+		if !ok {
+			continue
+		}
+
+		claimed, err := store.MarkSent(s.ID, today)
+		if err != nil {
+			log.Printf("Failed to mark reminder %d as sent: %v", s.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		err = notifier.Notify(ctx, notify.Notification{
+			ReminderID: s.ID,
+			UserID:     s.Owner.ID,
+			Email:      s.Owner.Email,
+			Name:       s.Owner.Name,
+			DdayID:     s.DDay.ID,
+			Title:      s.DDay.Title,
+			TargetDate: occurrence,
+			DaysBefore: s.DaysBefore,
+		})
+		if err != nil {
+			log.Printf("Reminder %d was not delivered: %v", s.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// localDate is the calendar date of now in loc, at midnight UTC.
+func localDate(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"dday-backend/global/config"
+	"dday-backend/jobs"
 	"dday-backend/models"
+	"dday-backend/notify"
 	"dday-backend/router"
 	"log"
+	"os"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,10 +18,38 @@ import (
 func main() {
 	config.LoadConfig()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Only admins may manage the shared categories; without one they could
+	// never be changed.
+	if len(config.AppConfig.Auth.AdminEmails) == 0 {
+		log.Fatal("ADMIN_EMAILS must name at least one account to manage categories")
+	}
+
 	if err := models.InitDatabase(); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
-	defer models.DB.Close()
+	defer models.Close()
+
+	if trash := config.AppConfig.Trash; trash.RetentionDays > 0 {
+		jobs.StartTrashPurge(context.Background(), models.NewDdayStore(),
+			time.Duration(trash.RetentionDays)*24*time.Hour,
+			time.Duration(trash.PurgeIntervalMinutes)*time.Minute)
+	}
+
+	if reminder := config.AppConfig.Reminder; reminder.IntervalMinutes > 0 {
+		notifier, err := notify.New(reminder.Notifier, reminder.WebhookURL)
+		if err != nil {
+			log.Fatal("Failed to set up notifications:", err)
+		}
+		jobs.StartReminders(context.Background(), models.NewReminderStore(), notifier, config.AppConfig.Server.Location,
+			time.Duration(reminder.IntervalMinutes)*time.Minute)
+	}
 
 	app := fiber.New(fiber.Config{
 		AppName: "D-Day Backend API v2.0",
@@ -26,4 +60,4 @@ func main() {
 	port := ":" + config.AppConfig.Server.Port
 	log.Printf("Server starting on port %s", port)
 	log.Fatal(app.Listen(port))
-}
\ No newline at end of file
+}
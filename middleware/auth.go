@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"dday-backend/global/auth"
+	"dday-backend/global/config"
+	"dday-backend/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const userKey = "user"
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer"
+// access token and stores the authenticated user for CurrentUser.
+func RequireAuth(users models.UserStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			return unauthorized(c, "Authentication required")
+		}
+
+		userID, err := auth.ParseAccessToken(strings.TrimSpace(token))
+		if err != nil {
+			return unauthorized(c, "Invalid or expired access token")
+		}
+
+		user, err := users.GetByID(userID)
+		if err != nil {
+			return unauthorized(c, "Invalid or expired access token")
+		}
+
+		c.Locals(userKey, user)
+		return c.Next()
+	}
+}
+
+// RequireAdmin rejects users whose email is not in ADMIN_EMAILS. It runs
+// after RequireAuth.
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := CurrentUser(c)
+		if user == nil {
+			return unauthorized(c, "Authentication required")
+		}
+		for _, email := range config.AppConfig.Auth.AdminEmails {
+			if strings.EqualFold(email, user.Email) {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin role required",
+		})
+	}
+}
+
+// CurrentUser returns the user stored by RequireAuth, or nil.
+func CurrentUser(c *fiber.Ctx) *models.User {
+	user, _ := c.Locals(userKey).(*models.User)
+	return user
+}
+
+func unauthorized(c *fiber.Ctx, message string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": message,
+	})
+}
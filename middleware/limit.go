@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// ShareAttempts throttles guessing at a share link: once max requests for
+// one token have failed within window, every request for it gets 429 until
+// the window passes. Views that get through are not counted, so visitors
+// who know the password are only held up while the link is under attack.
+func ShareAttempts(max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			// The limiter keeps the key, and route params point into a
+			// reused request buffer.
+			return strings.Clone(c.Params("token"))
+		},
+		SkipSuccessfulRequests: true,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many attempts. Try again later",
+			})
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"dday-backend/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const locationKey = "location"
+
+// Timezone reads the IANA zone, such as "Asia/Seoul", that the request
+// counts days in from the X-Timezone header or the tz query parameter and
+// stores it for RequestLocation. Unknown zones are rejected.
+func Timezone() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name := c.Get("X-Timezone")
+		if name == "" {
+			name = c.Query("tz")
+		}
+		if name == "" {
+			return c.Next()
+		}
+
+		loc, err := models.LoadLocation(name)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid timezone",
+			})
+		}
+
+		c.Locals(locationKey, loc)
+		return c.Next()
+	}
+}
+
+// RequestLocation returns the zone stored by Timezone, or nil when the
+// request named none.
+func RequestLocation(c *fiber.Ctx) *time.Location {
+	loc, _ := c.Locals(locationKey).(*time.Location)
+	return loc
+}
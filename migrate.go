@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"dday-backend/models"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const migrateUsage = "usage: dday-backend migrate up | down [steps] | status | assign-owner <email>"
+
+// runMigrate handles `dday-backend migrate <command>`.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if err := models.OpenDatabase(); err != nil {
+		return err
+	}
+	defer models.Close()
+
+	migrator, err := models.NewMigrator()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("applied   %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+		filled, err := models.BackfillTitleKeys()
+		if filled > 0 {
+			fmt.Printf("indexed   %d D-Day titles\n", filled)
+		}
+		if err != nil {
+			return err
+		}
+		ownerless, err := models.CountOwnerlessDdays()
+		if ownerless > 0 {
+			fmt.Printf("ownerless %d D-Days (run `migrate assign-owner <email>`)\n", ownerless)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted  %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("nothing to roll back")
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	case "assign-owner":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		assigned, err := models.AssignOwnerlessDdays(args[1])
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no account is registered with %s", args[1])
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("assigned  %d D-Days to %s\n", assigned, args[1])
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"dday-backend/models/lunar"
+	"errors"
+	"time"
+)
+
+// Calendar types. A lunar D-Day keeps its date on the Korean lunar calendar
+// in LunarDate and IsLeapMonth, and TargetDate holds the matching solar
+// date so it sorts and filters alongside solar D-Days.
+const (
+	CalendarSolar = "solar"
+	CalendarLunar = "lunar"
+)
+
+var (
+	ErrInvalidCalendar = errors.New("invalid calendar type")
+	ErrLunarRecurrence = errors.New("lunar D-Days can only repeat yearly")
+)
+
+// ResolveCalendar validates the D-Day's calendar fields, defaulting an empty
+// type to CalendarSolar, and sets TargetDate from LunarDate for a lunar
+// D-Day. Invalid lunar dates return lunar.ErrInvalidDate or
+// lunar.ErrOutOfRange. The recurrence must be set first, since lunar D-Days
+// only repeat yearly.
+func (d *DDay) ResolveCalendar() error {
+	switch d.CalendarType {
+	case "", CalendarSolar:
+		d.CalendarType, d.LunarDate, d.IsLeapMonth = CalendarSolar, "", false
+		return nil
+	case CalendarLunar:
+	default:
+		return ErrInvalidCalendar
+	}
+
+	if d.IsRecurring() && d.Recurrence != RecurrenceYearly {
+		return ErrLunarRecurrence
+	}
+
+	date, err := lunar.Parse(d.LunarDate, d.IsLeapMonth)
+	if err != nil {
+		return err
+	}
+	solar, err := lunar.ToSolar(date)
+	if err != nil {
+		return err
+	}
+	d.LunarDate = date.String()
+	d.TargetDate = solar.Format(dateLayout)
+	return nil
+}
+
+// nextLunarOccurrence finds the first yearly occurrence of a lunar D-Day on
+// or after from. There is none past lunar.MaxYear.
+func (d *DDay) nextLunarOccurrence(from time.Time) (time.Time, bool) {
+	date, err := lunar.Parse(d.LunarDate, d.IsLeapMonth)
+	if err != nil {
+		return time.Time{}, false
+	}
+	current, err := lunar.FromSolar(from)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	interval := max(d.RecurrenceInterval, 1)
+	year := date.Year
+	if current.Year > year {
+		year += (current.Year - year) / interval * interval
+	}
+	for ; year <= lunar.MaxYear; year += interval {
+		if next := lunarAnniversary(date, year); !next.Before(from) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// lunarAnniversary is the solar date on which date recurs in a lunar year.
+// A leap-month date falls in the regular month of the same number in years
+// without that leap month, and the 30th falls on the 29th of short months.
+func lunarAnniversary(date lunar.Date, year int) time.Time {
+	date.Year = year
+	if date.Leap && lunar.LeapMonth(year) != date.Month {
+		date.Leap = false
+	}
+	if days, err := lunar.MonthDays(year, date.Month, date.Leap); err == nil {
+		date.Day = min(date.Day, days)
+	}
+	solar, _ := lunar.ToSolar(date)
+	return solar
+}
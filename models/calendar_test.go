@@ -0,0 +1,104 @@
+package models
+
+import (
+	"dday-backend/models/lunar"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveCalendar(t *testing.T) {
+	tests := []struct {
+		name       string
+		dday       DDay
+		wantTarget string
+		wantLunar  string
+		err        error
+	}{
+		{
+			name:       "solar clears lunar fields",
+			dday:       DDay{TargetDate: "2024-05-01", LunarDate: "2024-01-01", IsLeapMonth: true},
+			wantTarget: "2024-05-01",
+		},
+		{
+			name:       "lunar new year",
+			dday:       DDay{CalendarType: CalendarLunar, LunarDate: "2024-01-01"},
+			wantTarget: "2024-02-10",
+			wantLunar:  "2024-01-01",
+		},
+		{
+			name:       "leap month",
+			dday:       DDay{CalendarType: CalendarLunar, LunarDate: "2023-02-15", IsLeapMonth: true},
+			wantTarget: "2023-04-05",
+			wantLunar:  "2023-02-15",
+		},
+		{
+			name: "leap month the year lacks",
+			dday: DDay{CalendarType: CalendarLunar, LunarDate: "2024-02-15", IsLeapMonth: true},
+			err:  lunar.ErrInvalidDate,
+		},
+		{
+			name: "lunar D-Days only repeat yearly",
+			dday: DDay{CalendarType: CalendarLunar, LunarDate: "2024-01-01", Recurrence: RecurrenceMonthly},
+			err:  ErrLunarRecurrence,
+		},
+		{
+			name: "unknown calendar",
+			dday: DDay{CalendarType: "julian"},
+			err:  ErrInvalidCalendar,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dday := tt.dday
+			err := dday.ResolveCalendar()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if dday.TargetDate != tt.wantTarget || dday.LunarDate != tt.wantLunar {
+				t.Errorf("target, lunar = %s, %q; want %s, %q", dday.TargetDate, dday.LunarDate, tt.wantTarget, tt.wantLunar)
+			}
+		})
+	}
+}
+
+func TestLunarOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		lunar    string
+		leap     bool
+		from, to string
+		want     []string
+	}{
+		{
+			// 2024 has no leap second month, so the regular one stands in.
+			name:  "leap month in a year without it",
+			lunar: "2023-02-15", leap: true,
+			from: "2023-01-01", to: "2024-12-31",
+			want: []string{"2023-04-05", "2024-03-24"},
+		},
+		{
+			// The twelfth month of lunar 2024 has 29 days.
+			name:  "30th in a short month",
+			lunar: "2023-12-30", leap: false,
+			from: "2024-01-01", to: "2025-12-31",
+			want: []string{"2024-02-09", "2025-01-28"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dday := DDay{CalendarType: CalendarLunar, LunarDate: tt.lunar, IsLeapMonth: tt.leap,
+				Recurrence: RecurrenceYearly, RecurrenceInterval: 1}
+			if err := dday.ResolveCalendar(); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := dday.Occurrences(mustDate(tt.from), mustDate(tt.to), 100)
+			if formatted := formatDates(got); !reflect.DeepEqual(formatted, tt.want) {
+				t.Errorf("Occurrences = %v, want %v", formatted, tt.want)
+			}
+		})
+	}
+}
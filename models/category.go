@@ -0,0 +1,354 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCategoryExists  = errors.New("category already exists")
+	ErrInvalidFallback = errors.New("invalid fallback category")
+	ErrLastCategory    = errors.New("cannot delete the last category")
+)
+
+const categoryCacheTTL = 30 * time.Second
+
+type Category struct {
+	ID        int       `json:"id" db:"cat_id"`
+	Name      string    `json:"name" db:"cat_name"`
+	Color     string    `json:"color" db:"cat_color"`
+	Icon      string    `json:"icon" db:"cat_icon"`
+	CreatedAt time.Time `json:"created_at" db:"cat_created_at"`
+}
+
+// CategoryStore manages the categories D-Days are filed under. D-Days refer
+// to categories by name, so Update cascades a rename to them and Delete
+// moves them to a fallback; both record a revision per D-Day touched,
+// trashed ones included.
+type CategoryStore interface {
+	// GetAll lists categories in creation order; the first is the default.
+	GetAll() ([]Category, error)
+	GetByID(id int) (*Category, error)
+	Exists(name string) (bool, error)
+	Default() (string, error)
+
+	// Create and Update return ErrCategoryExists when the name is taken.
+	Create(category *Category) error
+	Update(actor string, id int, category *Category) error
+	// Delete reassigns the category's D-Days to fallback, or to the default
+	// category when fallback is empty, and reports how many were moved.
+	Delete(actor string, id int, fallback string) (int, error)
+}
+
+// Categories is the CategoryStore selected by InitDatabase.
+var Categories CategoryStore
+
+func NewCategoryStore() CategoryStore {
+	return Categories
+}
+
+// CachedCategoryStore serves reads from a copy of the category list that is
+// refreshed after every write through it and at least every
+// categoryCacheTTL, so changes made by other instances show up eventually.
+type CachedCategoryStore struct {
+	store CategoryStore
+
+	mu         sync.Mutex
+	categories []Category
+	loadedAt   time.Time
+}
+
+func NewCachedCategoryStore(store CategoryStore) *CachedCategoryStore {
+	return &CachedCategoryStore{store: store}
+}
+
+func (c *CachedCategoryStore) load() ([]Category, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.categories != nil && time.Since(c.loadedAt) < categoryCacheTTL {
+		return c.categories, nil
+	}
+
+	categories, err := c.store.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		categories = []Category{}
+	}
+	c.categories = categories
+	c.loadedAt = time.Now()
+	return categories, nil
+}
+
+func (c *CachedCategoryStore) invalidate() {
+	c.mu.Lock()
+	c.categories = nil
+	c.mu.Unlock()
+}
+
+func (c *CachedCategoryStore) GetAll() ([]Category, error) {
+	categories, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return append([]Category(nil), categories...), nil
+}
+
+func (c *CachedCategoryStore) GetByID(id int) (*Category, error) {
+	categories, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.ID == id {
+			return &category, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (c *CachedCategoryStore) Exists(name string) (bool, error) {
+	categories, err := c.load()
+	if err != nil {
+		return false, err
+	}
+	for _, category := range categories {
+		if category.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *CachedCategoryStore) Default() (string, error) {
+	categories, err := c.load()
+	if err != nil {
+		return "", err
+	}
+	if len(categories) == 0 {
+		return "", sql.ErrNoRows
+	}
+	return categories[0].Name, nil
+}
+
+func (c *CachedCategoryStore) Create(category *Category) error {
+	defer c.invalidate()
+	return c.store.Create(category)
+}
+
+func (c *CachedCategoryStore) Update(actor string, id int, category *Category) error {
+	defer c.invalidate()
+	return c.store.Update(actor, id, category)
+}
+
+func (c *CachedCategoryStore) Delete(actor string, id int, fallback string) (int, error) {
+	defer c.invalidate()
+	return c.store.Delete(actor, id, fallback)
+}
+
+// CategoryManager is the SQL CategoryStore.
+type CategoryManager struct {
+	Conn  *Connection
+	ddays *DdayManager
+}
+
+func NewCategoryManager() *CategoryManager {
+	return &CategoryManager{Conn: DB, ddays: NewDdayManager()}
+}
+
+const categoryColumns = "cat_id, cat_name, COALESCE(cat_color, ''), COALESCE(cat_icon, ''), cat_created_at"
+
+func scanCategory(row rowScanner) (Category, error) {
+	var category Category
+	err := row.Scan(&category.ID, &category.Name, &category.Color, &category.Icon, &category.CreatedAt)
+	return category, err
+}
+
+func (m *CategoryManager) GetAll() ([]Category, error) {
+	rows, err := m.Conn.Query("SELECT " + categoryColumns + " FROM categories_tb ORDER BY cat_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (m *CategoryManager) GetByID(id int) (*Category, error) {
+	return m.getByID(m.Conn, id)
+}
+
+func (m *CategoryManager) getByID(q querier, id int) (*Category, error) {
+	category, err := scanCategory(q.QueryRow("SELECT "+categoryColumns+" FROM categories_tb WHERE cat_id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (m *CategoryManager) Exists(name string) (bool, error) {
+	var count int
+	err := m.Conn.QueryRow("SELECT COUNT(*) FROM categories_tb WHERE cat_name = ?", name).Scan(&count)
+	return count > 0, err
+}
+
+func (m *CategoryManager) Default() (string, error) {
+	var name string
+	err := m.Conn.QueryRow("SELECT cat_name FROM categories_tb ORDER BY cat_id LIMIT 1").Scan(&name)
+	return name, err
+}
+
+// nameTaken reports whether another category than id already uses name.
+func (m *CategoryManager) nameTaken(q querier, name string, id int) (bool, error) {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM categories_tb WHERE cat_name = ? AND cat_id <> ?", name, id).Scan(&count)
+	return count > 0, err
+}
+
+func (m *CategoryManager) Create(category *Category) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if taken, err := m.nameTaken(tx, category.Name, 0); err != nil {
+			return err
+		} else if taken {
+			return ErrCategoryExists
+		}
+
+		category.CreatedAt = utcNow()
+		query := "INSERT INTO categories_tb (cat_name, cat_color, cat_icon, cat_created_at) VALUES (?, ?, ?, ?)"
+		result, err := tx.Exec(query, category.Name, category.Color, category.Icon, category.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		category.ID = int(id)
+		return err
+	})
+}
+
+func (m *CategoryManager) Update(actor string, id int, category *Category) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		existing, err := m.getByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if taken, err := m.nameTaken(tx, category.Name, id); err != nil {
+			return err
+		} else if taken {
+			return ErrCategoryExists
+		}
+
+		query := "UPDATE categories_tb SET cat_name = ?, cat_color = ?, cat_icon = ? WHERE cat_id = ?"
+		if _, err := tx.Exec(query, category.Name, category.Color, category.Icon, id); err != nil {
+			return err
+		}
+
+		if existing.Name != category.Name {
+			if _, err := m.ddays.recategorize(tx, actor, existing.Name, category.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *CategoryManager) Delete(actor string, id int, fallback string) (int, error) {
+	var moved int
+	err := m.ddays.inTx(func(tx *sql.Tx) error {
+		existing, err := m.getByID(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if fallback == "" {
+			err := tx.QueryRow("SELECT cat_name FROM categories_tb WHERE cat_id <> ? ORDER BY cat_id LIMIT 1", id).Scan(&fallback)
+			if err == sql.ErrNoRows {
+				return ErrLastCategory
+			} else if err != nil {
+				return err
+			}
+		} else if fallback == existing.Name {
+			return ErrInvalidFallback
+		} else if taken, err := m.nameTaken(tx, fallback, id); err != nil {
+			return err
+		} else if !taken {
+			return ErrInvalidFallback
+		}
+
+		if moved, err = m.ddays.recategorize(tx, actor, existing.Name, fallback); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("DELETE FROM categories_tb WHERE cat_id = ?", id)
+		return err
+	})
+	return moved, err
+}
+
+// liveCategory returns name while it is still a category and the default
+// category otherwise, for snapshots taken before a rename or delete.
+func (m *DdayManager) liveCategory(q querier, name string) (string, error) {
+	var count int
+	if err := q.QueryRow("SELECT COUNT(*) FROM categories_tb WHERE cat_name = ?", name).Scan(&count); err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return name, nil
+	}
+	var fallback string
+	err := q.QueryRow("SELECT cat_name FROM categories_tb ORDER BY cat_id LIMIT 1").Scan(&fallback)
+	return fallback, err
+}
+
+// recategorize moves every D-Day filed under from, trashed ones included,
+// to category to.
+func (m *DdayManager) recategorize(tx *sql.Tx, actor, from, to string) (int, error) {
+	rows, err := tx.Query("SELECT "+ddayColumns+" FROM ddays_tb WHERE d_category = ?", from)
+	if err != nil {
+		return 0, err
+	}
+
+	var ddays []DDay
+	for rows.Next() {
+		dday, err := scanDday(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ddays = append(ddays, dday)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if err := m.loadTags(tx, ddays); err != nil {
+		return 0, err
+	}
+
+	query := "UPDATE ddays_tb SET d_category = ?, d_version = d_version + 1 WHERE d_category = ?"
+	if _, err := tx.Exec(query, to, from); err != nil {
+		return 0, err
+	}
+
+	for _, dday := range ddays {
+		dday.Category = to
+		if err := m.recordRevision(tx, dday.ID, RevisionUpdate, actor, SnapshotOf(&dday)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ddays), nil
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql"
+	"dday-backend/models/dday"
+	"strings"
+)
+
+// defaultCategories mirrors the rows seeded by migration 0001.
+var defaultCategories = []Category{
+	{Name: dday.CategoryPersonal, Color: "#007bff"},
+	{Name: dday.CategoryStudy, Color: "#28a745"},
+	{Name: dday.CategoryWork, Color: "#ffc107"},
+	{Name: dday.CategoryOther, Color: "#6c757d"},
+}
+
+// MemoryCategoryStore is the in-memory CategoryStore. It shares its memoryDB
+// with the MemoryDdayStore whose D-Days it recategorizes.
+type MemoryCategoryStore struct {
+	db    *memoryDB
+	ddays *MemoryDdayStore
+}
+
+func newMemoryCategoryStore(db *memoryDB) *MemoryCategoryStore {
+	now := utcNow()
+	categories := make([]Category, len(defaultCategories))
+	for i, category := range defaultCategories {
+		category.ID = db.nextID(&db.nextCategoryID)
+		category.CreatedAt = now
+		categories[i] = category
+	}
+	db.setCategories(categories)
+	return &MemoryCategoryStore{db: db, ddays: &MemoryDdayStore{db: db}}
+}
+
+func (m *MemoryCategoryStore) GetAll() ([]Category, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	return append([]Category(nil), m.db.categories...), nil
+}
+
+func (m *MemoryCategoryStore) GetByID(id int) (*Category, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if i := m.indexOf(id); i >= 0 {
+		category := m.db.categories[i]
+		return &category, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryCategoryStore) Exists(name string) (bool, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	return m.nameTaken(name, 0), nil
+}
+
+func (m *MemoryCategoryStore) Default() (string, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if len(m.db.categories) == 0 {
+		return "", sql.ErrNoRows
+	}
+	return m.db.categories[0].Name, nil
+}
+
+func (m *MemoryCategoryStore) Create(category *Category) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if m.nameTaken(category.Name, 0) {
+		return ErrCategoryExists
+	}
+
+	category.ID = m.db.nextID(&m.db.nextCategoryID)
+	category.CreatedAt = utcNow()
+	m.db.setCategories(append(m.db.categories, cloneCategory(*category)))
+	return nil
+}
+
+func (m *MemoryCategoryStore) Update(actor string, id int, category *Category) error {
+	return m.db.inTx(func() error {
+		i := m.indexOf(id)
+		if i < 0 {
+			return sql.ErrNoRows
+		}
+		if m.nameTaken(category.Name, id) {
+			return ErrCategoryExists
+		}
+
+		existing := m.db.categories[i]
+		updated := cloneCategory(*category)
+		updated.ID = existing.ID
+		updated.CreatedAt = existing.CreatedAt
+		categories := append([]Category(nil), m.db.categories...)
+		categories[i] = updated
+		m.db.setCategories(categories)
+
+		if existing.Name != updated.Name {
+			m.ddays.recategorize(actor, existing.Name, updated.Name)
+		}
+		return nil
+	})
+}
+
+func (m *MemoryCategoryStore) Delete(actor string, id int, fallback string) (int, error) {
+	var moved int
+	err := m.db.inTx(func() error {
+		i := m.indexOf(id)
+		if i < 0 {
+			return sql.ErrNoRows
+		}
+		existing := m.db.categories[i]
+
+		if fallback == "" {
+			if len(m.db.categories) == 1 {
+				return ErrLastCategory
+			}
+			fallback = m.db.categories[0].Name
+			if i == 0 {
+				fallback = m.db.categories[1].Name
+			}
+		} else if j := m.indexOfName(fallback); j < 0 || j == i {
+			return ErrInvalidFallback
+		} else {
+			// Use the stored name rather than the caller's string.
+			fallback = m.db.categories[j].Name
+		}
+
+		moved = m.ddays.recategorize(actor, existing.Name, fallback)
+		m.db.setCategories(append(m.db.categories[:i:i], m.db.categories[i+1:]...))
+		return nil
+	})
+	return moved, err
+}
+
+func (m *MemoryCategoryStore) indexOf(id int) int {
+	for i, category := range m.db.categories {
+		if category.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MemoryCategoryStore) indexOfName(name string) int {
+	for i, category := range m.db.categories {
+		if category.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// nameTaken reports whether another category than id already uses name.
+func (m *MemoryCategoryStore) nameTaken(name string, id int) bool {
+	for _, category := range m.db.categories {
+		if category.Name == name && category.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneCategory copies request-owned strings before they are kept.
+func cloneCategory(category Category) Category {
+	category.Name = strings.Clone(category.Name)
+	category.Color = strings.Clone(category.Color)
+	category.Icon = strings.Clone(category.Icon)
+	return category
+}
+
+// liveCategory mirrors DdayManager.liveCategory. The caller holds the
+// memoryDB lock.
+func (m *MemoryDdayStore) liveCategory(name string) string {
+	for _, category := range m.db.categories {
+		if category.Name == name {
+			return name
+		}
+	}
+	if len(m.db.categories) == 0 {
+		return name
+	}
+	return m.db.categories[0].Name
+}
+
+// recategorize moves every D-Day filed under from, trashed ones included, to
+// category to. The caller holds the memoryDB lock.
+func (m *MemoryDdayStore) recategorize(actor, from, to string) int {
+	moved := 0
+	for id, dday := range m.db.ddays {
+		if dday.Category != from {
+			continue
+		}
+		dday.Category = to
+		dday.Version++
+		dday.UpdatedAt = utcNow()
+		setRow(m.db, m.db.ddays, id, dday)
+		m.recordRevision(id, RevisionUpdate, actor, SnapshotOf(&dday))
+		moved++
+	}
+	return moved
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Statuses of a D-Day relative to today. A recurring D-Day is never past:
+// it counts down to its next occurrence.
+const (
+	StatusUpcoming = "upcoming"
+	StatusToday    = "today"
+	StatusPast     = "past"
+)
+
+// IsStatus reports whether status is one of the Status* values.
+func IsStatus(status string) bool {
+	return status == StatusUpcoming || status == StatusToday || status == StatusPast
+}
+
+// SetToday fills the fields computed relative to today, which must be a
+// date at midnight UTC.
+func (d *DDay) SetToday(today time.Time) {
+	d.NextOccurrence = nil
+	d.DaysRemaining = 0
+	d.Label = ""
+	d.Status = ""
+
+	start, err := time.Parse(dateLayout, d.TargetDate)
+	if err != nil {
+		return
+	}
+
+	if next, ok := d.nextOccurrence(start, today); ok {
+		formatted := next.Format(dateLayout)
+		d.NextOccurrence = &formatted
+		d.DaysRemaining = daysBetween(today, next)
+	} else {
+		d.DaysRemaining = daysBetween(today, start)
+	}
+
+	switch {
+	case d.DaysRemaining > 0:
+		d.Status, d.Label = StatusUpcoming, fmt.Sprintf("D-%d", d.DaysRemaining)
+	case d.DaysRemaining == 0:
+		d.Status, d.Label = StatusToday, "D-Day"
+	default:
+		d.Status, d.Label = StatusPast, fmt.Sprintf("D+%d", -d.DaysRemaining)
+	}
+}
+
+// statusOn is the Status SetToday would give the D-Day on today.
+func (d DDay) statusOn(today time.Time) string {
+	d.SetToday(today)
+	return d.Status
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor requests keyset pagination: at most Limit rows that sort strictly
+// after After in the active ordering, or the first Limit rows when After is
+// nil. Ties on the ordering field are broken by d_id.
+type Cursor struct {
+	After *CursorKey
+	Limit int
+}
+
+// CursorKey is the position of a row in a keyset ordering.
+type CursorKey struct {
+	Field Field       `json:"f"`
+	Desc  bool        `json:"d"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+// CursorKeyOf returns the position of dday under sort.
+func CursorKeyOf(dday DDay, sort Sort) CursorKey {
+	return CursorKey{Field: sort.Field, Desc: sort.Desc, Value: fieldValue(dday, sort.Field), ID: dday.ID}
+}
+
+// Matches reports whether the key was taken under the same ordering.
+func (k *CursorKey) Matches(sort Sort) bool {
+	return k.Field == sort.Field && k.Desc == sort.Desc
+}
+
+// EncodeCursor renders a key as the opaque string handed to clients.
+func EncodeCursor(key CursorKey) string {
+	body, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func DecodeCursor(s string) (*CursorKey, error) {
+	body, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var key CursorKey
+	if err := json.Unmarshal(body, &key); err != nil || key.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	spec, ok := ddayFields[key.Field]
+	if !ok || !spec.sortable {
+		return nil, ErrInvalidCursor
+	}
+
+	// JSON loses the Go type of the value; restore it from the field.
+	if spec.kind == kindTime {
+		raw, ok := key.Value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		key.Value = t
+	}
+	if spec.check(key.Value) != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &key, nil
+}
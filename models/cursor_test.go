@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 9, 30, 0, 123456789, time.UTC)
+	dday := DDay{ID: "d1", Title: "생일", TargetDate: "2024-05-01", IsImportant: true, UpdatedAt: updated}
+
+	for _, sort := range []Sort{
+		{Field: FieldTitle},
+		{Field: FieldTargetDate, Desc: true},
+		{Field: FieldIsImportant},
+		{Field: FieldUpdatedAt, Desc: true},
+		{Field: FieldDeletedAt},
+	} {
+		key := CursorKeyOf(dday, sort)
+		decoded, err := DecodeCursor(EncodeCursor(key))
+		if err != nil {
+			t.Errorf("%v: DecodeCursor error = %v", sort, err)
+			continue
+		}
+		if !decoded.Matches(sort) || decoded.ID != key.ID {
+			t.Errorf("%v: decoded %+v, want %+v", sort, decoded, key)
+		}
+		// Times must come back as times, to the nanosecond, or the next page
+		// would repeat or skip rows.
+		if want, ok := key.Value.(time.Time); ok {
+			if got, ok := decoded.Value.(time.Time); !ok || !got.Equal(want) {
+				t.Errorf("%v: value = %#v, want %v", sort, decoded.Value, want)
+			}
+		} else if decoded.Value != key.Value {
+			t.Errorf("%v: value = %#v, want %#v", sort, decoded.Value, key.Value)
+		}
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	encode := func(body string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(body))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"not json", encode(`{"f":`)},
+		{"missing id", encode(`{"f":"title","d":false,"v":"생일"}`)},
+		{"unknown field", encode(`{"f":"d_title","v":"생일","id":"d1"}`)},
+		{"unsortable field", encode(`{"f":"memo","v":"x","id":"d1"}`)},
+		{"string for a bool", encode(`{"f":"is_important","v":"true","id":"d1"}`)},
+		{"number for a string", encode(`{"f":"title","v":3,"id":"d1"}`)},
+		{"bad date", encode(`{"f":"target_date","v":"2024-02-30","id":"d1"}`)},
+		{"bad time", encode(`{"f":"updated_at","v":"yesterday","id":"d1"}`)},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+}
@@ -2,52 +2,68 @@ package models
 
 import (
 	"database/sql"
+	"dday-backend/global/config"
+	"dday-backend/models/hangul"
+	"dday-backend/models/migrations"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
 )
 
 type Connection struct {
 	*sql.DB
-}
-
-type Where struct {
-	Column  string
-	Value   interface{}
-	Compare string
-}
-
-type Custom struct {
-	Query string
-	Args  []interface{}
-}
-
-type Paging struct {
-	Page     int
-	PageSize int
-}
-
-type Ordering struct {
-	OrderBy string
+	Driver string
 }
 
 var DB *Connection
 
+// InitDatabase connects the configured store and, unless DB_AUTO_MIGRATE is
+// off, brings its schema up to date.
 func InitDatabase() error {
-	dbUser := getEnv("DB_USER", "root")
-	dbPassword := getEnv("DB_PASSWORD", "")
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "3306")
-	dbName := getEnv("DB_NAME", "dday")
+	if err := OpenDatabase(); err != nil {
+		return err
+	}
+	if DB == nil || !config.AppConfig.Database.AutoMigrate {
+		return nil
+	}
+	return Migrate()
+}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+// OpenDatabase connects the configured store without touching its schema.
+func OpenDatabase() error {
+	cfg := config.AppConfig.Database
+
+	switch cfg.Driver {
+	case DriverMemory:
+		// The memory stores share one memoryDB so cascades stay atomic.
+		db := newMemoryDB()
+		Store = &MemoryDdayStore{db: db}
+		Categories = NewCachedCategoryStore(newMemoryCategoryStore(db))
+		Users = &MemoryUserStore{db: db}
+		Reminders = newMemoryReminderStore(db)
+		Tags = &MemoryTagStore{db: db}
+		Shares = newMemoryShareStore(db)
+		Members = newMemoryMemberStore(db)
+		log.Println("Using in-memory store")
+		return nil
+	case DriverMySQL, DriverSQLite:
+	default:
+		return fmt.Errorf("unknown database driver: %s", cfg.Driver)
+	}
 
-	db, err := sql.Open("mysql", dsn)
+	db, err := sql.Open(cfg.Driver, dataSourceName(cfg))
 	if err != nil {
 		return fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -56,70 +72,174 @@ func InitDatabase() error {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	maxOpen, _ := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
-	maxIdle, _ := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "25"))
-	maxLifetime, _ := strconv.Atoi(getEnv("DB_CONN_MAX_LIFETIME", "300"))
+	if cfg.Driver == DriverSQLite {
+		// SQLite allows a single writer; serialize access through one connection.
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(time.Second * time.Duration(cfg.MaxLifetime))
+	}
 
-	db.SetMaxOpenConns(maxOpen)
-	db.SetMaxIdleConns(maxIdle)
-	db.SetConnMaxLifetime(time.Second * time.Duration(maxLifetime))
+	DB = &Connection{DB: db, Driver: cfg.Driver}
+	Store = NewDdayManager()
+	Categories = NewCachedCategoryStore(NewCategoryManager())
+	Users = NewUserManager()
+	Reminders = NewReminderManager()
+	Tags = NewTagManager()
+	Shares = NewShareManager()
+	Members = NewMemberManager()
+	log.Printf("Database connected successfully (%s)", cfg.Driver)
 
-	DB = &Connection{db}
-	log.Println("Database connected successfully")
+	return nil
+}
 
-	return createTables()
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	return DB.Close()
+}
+
+func dataSourceName(cfg config.DatabaseConfig) string {
+	if cfg.Driver == DriverSQLite {
+		return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite", cfg.Path)
+	}
+	// Timestamps are read and written in UTC, and the session time zone
+	// makes CURRENT_TIMESTAMP agree, whatever zone the server runs in.
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
+}
+
+// isDuplicateKey reports whether err is a write rejected by a unique index.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	return false
 }
 
 func (c *Connection) Begin() (*sql.Tx, error) {
 	return c.DB.Begin()
 }
 
-func createTables() error {
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS ddays_tb (
-		d_id VARCHAR(36) PRIMARY KEY,
-		d_title VARCHAR(255) NOT NULL,
-		d_target_date DATE NOT NULL,
-		d_category VARCHAR(50) NOT NULL DEFAULT '개인',
-		d_memo TEXT,
-		d_is_important BOOLEAN DEFAULT FALSE,
-		d_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		d_updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-		
-		INDEX idx_d_target_date (d_target_date),
-		INDEX idx_d_category (d_category),
-		INDEX idx_d_is_important (d_is_important),
-		INDEX idx_d_created_at (d_created_at)
-	);`
-
-	_, err := DB.Exec(createTableSQL)
+// Migrate applies every pending migration for the connected driver.
+func Migrate() error {
+	migrator, err := NewMigrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create table: %w", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	filled, err := BackfillTitleKeys()
+	if err != nil {
+		return fmt.Errorf("failed to index titles: %w", err)
+	}
+	if filled > 0 {
+		log.Printf("Indexed %d D-Day titles for Hangul search", filled)
 	}
 
-	log.Println("Tables created/verified successfully")
+	if email := config.AppConfig.Database.OwnerlessOwner; email != "" {
+		// The account may not have signed up yet on a fresh install.
+		assigned, err := AssignOwnerlessDdays(email)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Ownerless D-Days stay hidden until %s signs up and the server restarts", email)
+		} else if err != nil {
+			return fmt.Errorf("failed to assign ownerless D-Days: %w", err)
+		} else if assigned > 0 {
+			log.Printf("Assigned %d ownerless D-Days to %s", assigned, email)
+		}
+	} else if ownerless, err := CountOwnerlessDdays(); err != nil {
+		return err
+	} else if ownerless > 0 {
+		log.Printf("%d D-Days have no owner and are hidden; set DB_ASSIGN_OWNERLESS_TO or run `migrate assign-owner <email>`", ownerless)
+	}
+
+	log.Println("Database schema is up to date")
 	return nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// BackfillTitleKeys fills the Hangul search keys of rows written before they
+// existed. The keys are computed in Go, so a SQL migration cannot set them.
+func BackfillTitleKeys() (int, error) {
+	rows, err := DB.Query("SELECT d_id, d_title FROM ddays_tb WHERE d_title_chosung IS NULL")
+	if err != nil {
+		return 0, err
+	}
+
+	titles := map[string]string{}
+	for rows.Next() {
+		var id, title string
+		if err := rows.Scan(&id, &title); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		titles[id] = title
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(titles) == 0 {
+		return 0, nil
 	}
-	return defaultValue
-}
 
-func NewPaging(page, pageSize int) Paging {
-	return Paging{Page: page, PageSize: pageSize}
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for id, title := range titles {
+		_, err := tx.Exec("UPDATE ddays_tb SET d_title_chosung = ?, d_title_jamo = ? WHERE d_id = ?",
+			hangul.Choseong(title), hangul.Decompose(title), id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(titles), tx.Commit()
 }
 
-func NewOrdering(orderBy string) Ordering {
-	return Ordering{OrderBy: orderBy}
+// CountOwnerlessDdays counts the D-Days created before D-Days had owners
+// that no account has been given yet. No user can see them.
+func CountOwnerlessDdays() (int, error) {
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM ddays_tb WHERE d_user_id IS NULL").Scan(&count)
+	return count, err
 }
 
-func NewWhere(column string, value interface{}, compare string) Where {
-	return Where{Column: column, Value: value, Compare: compare}
+// AssignOwnerlessDdays gives every ownerless D-Day, trashed ones included, to
+// the account registered with email. It returns sql.ErrNoRows when there is
+// no such account.
+func AssignOwnerlessDdays(email string) (int64, error) {
+	owner, err := NewUserManager().GetByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := DB.Exec("UPDATE ddays_tb SET d_user_id = ? WHERE d_user_id IS NULL", owner.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func NewCustom(query string, args ...interface{}) Custom {
-	return Custom{Query: query, Args: args}
-}
\ No newline at end of file
+func NewMigrator() (*migrations.Migrator, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("the %s store has no schema to migrate", config.AppConfig.Database.Driver)
+	}
+	return migrations.New(DB.DB, DB.Driver)
+}
@@ -2,22 +2,51 @@ package models
 
 import (
 	"database/sql"
+	"dday-backend/models/hangul"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type DDay struct {
-	ID          string    `json:"id" db:"d_id"`
-	Title       string    `json:"title" db:"d_title"`
-	TargetDate  string    `json:"target_date" db:"d_target_date"`
-	Category    string    `json:"category" db:"d_category"`
-	Memo        string    `json:"memo" db:"d_memo"`
-	IsImportant bool      `json:"is_important" db:"d_is_important"`
-	CreatedAt   time.Time `json:"created_at" db:"d_created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"d_updated_at"`
+	ID                 string `json:"id" db:"d_id"`
+	UserID             string `json:"user_id" db:"d_user_id"`
+	Title              string `json:"title" db:"d_title"`
+	TargetDate         string `json:"target_date" db:"d_target_date"`
+	Category           string `json:"category" db:"d_category"`
+	Memo               string `json:"memo" db:"d_memo"`
+	IsImportant        bool   `json:"is_important" db:"d_is_important"`
+	Recurrence         string `json:"recurrence" db:"d_recurrence"`
+	RecurrenceInterval int    `json:"recurrence_interval" db:"d_recurrence_interval"`
+	CalendarType       string `json:"calendar_type" db:"d_calendar_type"`
+	LunarDate          string `json:"lunar_date,omitempty" db:"d_lunar_date"`
+	IsLeapMonth        bool   `json:"is_leap_month" db:"d_is_leap_month"`
+	// Tags are kept in dday_tags_tb and always listed, sorted. A nil Tags
+	// on Update leaves the stored tags alone.
+	Tags []string `json:"tags" db:"-"`
+	// Role is the caller's role on the D-Day: RoleOwner for their own, the
+	// membership role for one shared with them.
+	Role string `json:"role,omitempty" db:"-"`
+	// NextOccurrence, DaysRemaining, Label and Status are computed by
+	// SetToday, not stored. NextOccurrence is nil once a one-off D-Day has
+	// passed, and DaysRemaining is then negative.
+	NextOccurrence *string    `json:"next_occurrence" db:"-"`
+	DaysRemaining  int        `json:"days_remaining" db:"-"`
+	Label          string     `json:"label" db:"-"`
+	Status         string     `json:"status" db:"-"`
+	CreatedAt      time.Time  `json:"created_at" db:"d_created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"d_updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"d_deleted_at"`
+	Version        int        `json:"version" db:"d_version"`
 }
 
+// ETag is the strong entity tag for the D-Day's current version.
+func (d *DDay) ETag() string {
+	return `"` + strconv.Itoa(d.Version) + `"`
+}
+
+// DdayManager is the SQL DdayStore, shared by the MySQL and SQLite drivers.
 type DdayManager struct {
 	Conn *Connection
 }
@@ -26,21 +55,126 @@ func NewDdayManager() *DdayManager {
 	return &DdayManager{Conn: DB}
 }
 
-func (m *DdayManager) GetAll(args ...interface{}) ([]DDay, error) {
-	query := "SELECT d_id, d_title, d_target_date, d_category, d_memo, d_is_important, d_created_at, d_updated_at FROM ddays_tb"
-	whereClause, orderClause, limitClause, queryArgs := m.buildQuery(args...)
+// accessCondition limits a query to the D-Days a user created or is a
+// member of; it takes the user ID twice.
+const accessCondition = "(d_user_id = ? OR d_id IN (SELECT dm_dday_id FROM dday_members_tb WHERE dm_user_id = ?))"
 
-	if whereClause != "" {
-		query += " WHERE " + whereClause
+const ddayColumns = "d_id, d_user_id, d_title, d_target_date, d_category, d_memo, d_is_important, d_recurrence, d_recurrence_interval, d_calendar_type, d_lunar_date, d_is_leap_month, d_created_at, d_updated_at, d_deleted_at, d_version"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDday reads the ddayColumns of a row, followed by any extra columns
+// the query selected into extra.
+func scanDday(row rowScanner, extra ...interface{}) (DDay, error) {
+	var dday DDay
+	var userID, lunarDate sql.NullString
+	var deletedAt sql.NullTime
+	dest := []interface{}{&dday.ID, &userID, &dday.Title, dateColumn{&dday.TargetDate},
+		&dday.Category, &dday.Memo, &dday.IsImportant, &dday.Recurrence, &dday.RecurrenceInterval,
+		&dday.CalendarType, &lunarDate, &dday.IsLeapMonth, &dday.CreatedAt, &dday.UpdatedAt, &deletedAt, &dday.Version}
+	err := row.Scan(append(dest, extra...)...)
+	dday.UserID = userID.String
+	dday.LunarDate = lunarDate.String
+	if deletedAt.Valid {
+		dday.DeletedAt = &deletedAt.Time
 	}
-	if orderClause != "" {
-		query += " ORDER BY " + orderClause
-	} else {
-		query += " ORDER BY d_target_date ASC"
+	return dday, err
+}
+
+// dateColumn scans a DATE column as YYYY-MM-DD whether the driver returns
+// time.Time (MySQL parseTime, SQLite DATE affinity) or raw text.
+type dateColumn struct {
+	dest *string
+}
+
+func (d dateColumn) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d.dest = v.Format("2006-01-02")
+	case []byte:
+		*d.dest = string(v)
+	case string:
+		*d.dest = v
+	case nil:
+		*d.dest = ""
+	default:
+		return fmt.Errorf("unsupported date value %T", src)
 	}
-	if limitClause != "" {
-		query += " " + limitClause
+	if len(*d.dest) > 10 {
+		*d.dest = (*d.dest)[:10]
 	}
+	return nil
+}
+
+// nullString writes an empty string as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func sqlTx(tx Tx) (*sql.Tx, error) {
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transaction type %T", tx)
+	}
+	return sqlTx, nil
+}
+
+func (m *DdayManager) Begin() (Tx, error) {
+	return m.Conn.Begin()
+}
+
+func (m *DdayManager) GetAll(userID string, q *Query) ([]DDay, error) {
+	if q.inGo() {
+		ddays, err := m.matchInGo(userID, q)
+		if err != nil {
+			return nil, err
+		}
+		if q.cursor != nil {
+			if q.cursor.Limit > 0 && len(ddays) > q.cursor.Limit {
+				ddays = ddays[:q.cursor.Limit]
+			}
+			return ddays, nil
+		}
+		start, end := pageBounds(len(ddays), q.paging)
+		if start == end {
+			return nil, nil
+		}
+		return ddays[start:end], nil
+	}
+
+	return m.selectDdays(userID, q, m.buildLimit(q))
+}
+
+// matchInGo loads every D-Day passing q's SQL conditions and finishes the
+// query in Go: edit distance cannot be expressed in SQL, and neither can the
+// next occurrence of a recurring D-Day.
+func (m *DdayManager) matchInGo(userID string, q *Query) ([]DDay, error) {
+	ddays, err := m.selectDdays(userID, q, "")
+	if err != nil {
+		return nil, err
+	}
+	if q.status != "" {
+		matched := ddays[:0]
+		for _, dday := range ddays {
+			if dday.statusOn(q.today) == q.status {
+				matched = append(matched, dday)
+			}
+		}
+		ddays = matched
+	}
+	if q.fuzzy != "" {
+		ddays = rankFuzzy(ddays, q.fuzzy)
+	}
+	return ddays, nil
+}
+
+func (m *DdayManager) selectDdays(userID string, q *Query, limit string) ([]DDay, error) {
+	whereClause, queryArgs := m.buildWhere(userID, q, true)
+	query := "SELECT " + ddayColumns + " FROM ddays_tb WHERE " + whereClause
+	query += " ORDER BY " + m.buildOrder(q)
+	query += limit
 
 	rows, err := m.Conn.Query(query, queryArgs...)
 	if err != nil {
@@ -50,118 +184,512 @@ func (m *DdayManager) GetAll(args ...interface{}) ([]DDay, error) {
 
 	var ddays []DDay
 	for rows.Next() {
-		var dday DDay
-		err := rows.Scan(&dday.ID, &dday.Title, &dday.TargetDate,
-			&dday.Category, &dday.Memo, &dday.IsImportant,
-			&dday.CreatedAt, &dday.UpdatedAt)
+		dday, err := scanDday(rows)
 		if err != nil {
 			return nil, err
 		}
 		ddays = append(ddays, dday)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-	return ddays, nil
+	return ddays, m.decorate(m.Conn, userID, ddays)
 }
 
-func (m *DdayManager) GetByID(id string) (*DDay, error) {
-	var dday DDay
-	query := "SELECT d_id, d_title, d_target_date, d_category, d_memo, d_is_important, d_created_at, d_updated_at FROM ddays_tb WHERE d_id = ?"
+func (m *DdayManager) GetByID(userID, id string) (*DDay, error) {
+	return m.getByID(m.Conn, userID, id)
+}
+
+func (m *DdayManager) getByID(q querier, userID, id string) (*DDay, error) {
+	query := "SELECT " + ddayColumns + " FROM ddays_tb WHERE d_id = ? AND " + accessCondition + " AND d_deleted_at IS NULL"
 
-	err := m.Conn.QueryRow(query, id).Scan(&dday.ID, &dday.Title, &dday.TargetDate,
-		&dday.Category, &dday.Memo, &dday.IsImportant,
-		&dday.CreatedAt, &dday.UpdatedAt)
+	dday, err := scanDday(q.QueryRow(query, id, userID, userID))
 	if err != nil {
 		return nil, err
 	}
 
-	return &dday, nil
+	ddays := []DDay{dday}
+	if err := m.decorate(q, userID, ddays); err != nil {
+		return nil, err
+	}
+	return &ddays[0], nil
 }
 
-func (m *DdayManager) Create(dday *DDay) error {
-	query := `INSERT INTO ddays_tb (d_id, d_title, d_target_date, d_category, d_memo, d_is_important, d_created_at) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+// authorize returns the live D-Day id if userID's role on it allows need,
+// ErrForbidden if userID has a lesser role, and sql.ErrNoRows if none.
+func (m *DdayManager) authorize(q querier, userID, id, need string) (*DDay, error) {
+	dday, err := m.getByID(q, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !RoleAllows(dday.Role, need) {
+		return nil, forbidden(need)
+	}
+	return dday, nil
+}
 
-	_, err := m.Conn.Exec(query, dday.ID, dday.Title, dday.TargetDate,
-		dday.Category, dday.Memo, dday.IsImportant, dday.CreatedAt)
-	return err
+// getOwn returns the live D-Day id only if userID created it.
+func (m *DdayManager) getOwn(q querier, userID, id string) (*DDay, error) {
+	dday, err := m.getByID(q, userID, id)
+	if err == nil && dday.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return dday, err
 }
 
-func (m *DdayManager) Update(id string, dday *DDay) error {
-	query := `UPDATE ddays_tb SET d_title = ?, d_target_date = ?, d_category = ?, d_memo = ?, d_is_important = ? 
+// roleOn is userID's role on D-Day id, live or trashed, or sql.ErrNoRows.
+func (m *DdayManager) roleOn(q querier, userID, id string) (string, error) {
+	query := `SELECT d_user_id, COALESCE(dm_role, '') FROM ddays_tb
+			  LEFT JOIN dday_members_tb ON dm_dday_id = d_id AND dm_user_id = ?
 			  WHERE d_id = ?`
+	var owner sql.NullString
+	var role string
+	if err := q.QueryRow(query, userID, id).Scan(&owner, &role); err != nil {
+		return "", err
+	}
+	if owner.Valid && owner.String == userID {
+		return RoleOwner, nil
+	}
+	if role == "" {
+		return "", sql.ErrNoRows
+	}
+	return role, nil
+}
+
+// decorate fills the tags of ddays and userID's role on them.
+func (m *DdayManager) decorate(q querier, userID string, ddays []DDay) error {
+	if err := m.loadTags(q, ddays); err != nil {
+		return err
+	}
+	return m.loadRoles(q, userID, ddays)
+}
 
-	_, err := m.Conn.Exec(query, dday.Title, dday.TargetDate, dday.Category,
-		dday.Memo, dday.IsImportant, id)
-	return err
+func (m *DdayManager) Create(userID string, dday *DDay) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		return m.create(tx, userID, dday)
+	})
 }
 
-func (m *DdayManager) Delete(id string) error {
-	query := "DELETE FROM ddays_tb WHERE d_id = ?"
-	_, err := m.Conn.Exec(query, id)
-	return err
+func (m *DdayManager) Update(userID, id string, dday *DDay) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		return m.update(tx, userID, id, dday, RevisionUpdate)
+	})
 }
 
-func (m *DdayManager) Count(args ...interface{}) (int, error) {
-	query := "SELECT COUNT(*) FROM ddays_tb"
-	whereClause, _, _, queryArgs := m.buildQuery(args...)
+func (m *DdayManager) Patch(userID, id string, patch *DdayPatch) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		return m.patch(tx, userID, id, patch)
+	})
+}
 
-	if whereClause != "" {
-		query += " WHERE " + whereClause
+func (m *DdayManager) Delete(userID, id string, version int) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		return m.delete(tx, userID, id, version)
+	})
+}
+
+func (m *DdayManager) Restore(userID, id string) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		role, err := m.roleOn(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if !RoleAllows(role, RoleOwner) {
+			return forbidden(RoleOwner)
+		}
+
+		query := "UPDATE ddays_tb SET d_deleted_at = NULL, d_version = d_version + 1 WHERE d_id = ? AND d_deleted_at IS NOT NULL"
+		result, err := tx.Exec(query, id)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		restored, err := m.getByID(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		return m.recordRevision(tx, id, RevisionRestore, userID, SnapshotOf(restored))
+	})
+}
+
+func (m *DdayManager) Purge(cutoff time.Time) (int64, error) {
+	query := "DELETE FROM ddays_tb WHERE d_deleted_at IS NOT NULL AND d_deleted_at < ?"
+	result, err := m.Conn.Exec(query, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (m *DdayManager) Count(userID string, q *Query) (int, error) {
+	if q.inGo() {
+		unpaged := *q
+		unpaged.cursor = nil
+		ddays, err := m.matchInGo(userID, &unpaged)
+		return len(ddays), err
 	}
 
+	whereClause, queryArgs := m.buildWhere(userID, q, false)
+	query := "SELECT COUNT(*) FROM ddays_tb WHERE " + whereClause
+
 	var count int
 	err := m.Conn.QueryRow(query, queryArgs...).Scan(&count)
 	return count, err
 }
 
-func (m *DdayManager) CreateWithTx(tx *sql.Tx, dday *DDay) error {
-	query := `INSERT INTO ddays_tb (d_id, d_title, d_target_date, d_category, d_memo, d_is_important, d_created_at) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+func (m *DdayManager) GetByIDWithTx(tx Tx, userID, id string) (*DDay, error) {
+	sqlTx, err := sqlTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	return m.getByID(sqlTx, userID, id)
+}
+
+func (m *DdayManager) CreateWithTx(tx Tx, userID string, dday *DDay) error {
+	sqlTx, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	return m.create(sqlTx, userID, dday)
+}
+
+func (m *DdayManager) UpdateWithTx(tx Tx, userID, id string, dday *DDay) error {
+	sqlTx, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	return m.update(sqlTx, userID, id, dday, RevisionUpdate)
+}
 
-	_, err := tx.Exec(query, dday.ID, dday.Title, dday.TargetDate,
-		dday.Category, dday.Memo, dday.IsImportant, dday.CreatedAt)
-	return err
+func (m *DdayManager) DeleteWithTx(tx Tx, userID, id string, version int) error {
+	sqlTx, err := sqlTx(tx)
+	if err != nil {
+		return err
+	}
+	return m.delete(sqlTx, userID, id, version)
 }
 
-func (m *DdayManager) UpdateWithTx(tx *sql.Tx, id string, dday *DDay) error {
-	query := `UPDATE ddays_tb SET d_title = ?, d_target_date = ?, d_category = ?, d_memo = ?, d_is_important = ? 
-			  WHERE d_id = ?`
+func (m *DdayManager) create(q querier, userID string, dday *DDay) error {
+	dday.UserID = userID
+	dday.Role = RoleOwner
+	dday.Version = 1
+	query := `INSERT INTO ddays_tb (d_id, d_user_id, d_title, d_title_chosung, d_title_jamo, d_target_date, d_category, d_memo, d_is_important, d_recurrence, d_recurrence_interval,
+			  d_calendar_type, d_lunar_date, d_is_leap_month, d_created_at, d_version)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := tx.Exec(query, dday.Title, dday.TargetDate, dday.Category,
-		dday.Memo, dday.IsImportant, id)
-	return err
-}
-
-func (m *DdayManager) DeleteWithTx(tx *sql.Tx, id string) error {
-	query := "DELETE FROM ddays_tb WHERE d_id = ?"
-	_, err := tx.Exec(query, id)
-	return err
-}
-
-func (m *DdayManager) buildQuery(args ...interface{}) (string, string, string, []interface{}) {
-	var whereConditions []string
-	var queryArgs []interface{}
-	var orderClause string
-	var limitClause string
-
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Where:
-			whereConditions = append(whereConditions, fmt.Sprintf("%s %s ?", v.Column, v.Compare))
-			queryArgs = append(queryArgs, v.Value)
-		case Custom:
-			whereConditions = append(whereConditions, v.Query)
-			queryArgs = append(queryArgs, v.Args...)
-		case Ordering:
-			orderClause = v.OrderBy
-		case Paging:
-			if v.Page > 0 && v.PageSize > 0 {
-				offset := (v.Page - 1) * v.PageSize
-				limitClause = fmt.Sprintf("LIMIT %d, %d", offset, v.PageSize)
-			}
+	_, err := q.Exec(query, dday.ID, dday.UserID, dday.Title, hangul.Choseong(dday.Title), hangul.Decompose(dday.Title), dday.TargetDate,
+		dday.Category, dday.Memo, dday.IsImportant, dday.Recurrence, dday.RecurrenceInterval,
+		dday.CalendarType, nullString(dday.LunarDate), dday.IsLeapMonth, dday.CreatedAt, dday.Version)
+	if err != nil {
+		return err
+	}
+
+	if dday.Tags == nil {
+		dday.Tags = []string{}
+	}
+	if err := m.setTags(q, userID, dday.ID, dday.Tags); err != nil {
+		return err
+	}
+
+	return m.recordRevision(q, dday.ID, RevisionCreate, userID, SnapshotOf(dday))
+}
+
+func (m *DdayManager) update(q querier, userID, id string, dday *DDay, action string) error {
+	existing, err := m.authorize(q, userID, id, RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE ddays_tb SET d_title = ?, d_title_chosung = ?, d_title_jamo = ?, d_target_date = ?, d_category = ?, d_memo = ?, d_is_important = ?,
+			  d_recurrence = ?, d_recurrence_interval = ?, d_calendar_type = ?, d_lunar_date = ?, d_is_leap_month = ?,
+			  d_version = d_version + 1
+			  WHERE d_id = ? AND d_deleted_at IS NULL`
+	args := []interface{}{dday.Title, hangul.Choseong(dday.Title), hangul.Decompose(dday.Title),
+		dday.TargetDate, dday.Category, dday.Memo, dday.IsImportant, dday.Recurrence, dday.RecurrenceInterval,
+		dday.CalendarType, nullString(dday.LunarDate), dday.IsLeapMonth, id}
+	if dday.Version > 0 {
+		query += " AND d_version = ?"
+		args = append(args, dday.Version)
+	}
+
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return m.missOrConflict(q, userID, id, dday.Version)
+	}
+
+	if dday.Tags != nil {
+		if err := m.setTags(q, existing.UserID, id, dday.Tags); err != nil {
+			return err
+		}
+	}
+
+	saved, err := m.getByID(q, userID, id)
+	if err != nil {
+		return err
+	}
+	return m.recordRevision(q, id, action, userID, SnapshotOf(saved))
+}
+
+func (m *DdayManager) patch(q querier, userID, id string, patch *DdayPatch) error {
+	existing, err := m.authorize(q, userID, id, RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	assignments, args := patchAssignments(patch)
+	query := "UPDATE ddays_tb SET " + strings.Join(append(assignments, "d_version = d_version + 1"), ", ") +
+		" WHERE d_id = ? AND d_deleted_at IS NULL"
+	args = append(args, id)
+	if patch.Version > 0 {
+		query += " AND d_version = ?"
+		args = append(args, patch.Version)
+	}
+
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return m.missOrConflict(q, userID, id, patch.Version)
+	}
+
+	if patch.Tags != nil {
+		if err := m.setTags(q, existing.UserID, id, *patch.Tags); err != nil {
+			return err
+		}
+	}
+
+	patched, err := m.getByID(q, userID, id)
+	if err != nil {
+		return err
+	}
+	return m.recordRevision(q, id, RevisionUpdate, userID, SnapshotOf(patched))
+}
+
+// patchAssignments lists the SET clauses for the columns patch writes, with
+// their arguments. A new title also rewrites its search columns.
+func patchAssignments(patch *DdayPatch) ([]string, []interface{}) {
+	var assignments []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		assignments = append(assignments, column+" = ?")
+		args = append(args, value)
+	}
+
+	if patch.Title != nil {
+		set("d_title", *patch.Title)
+		set("d_title_chosung", hangul.Choseong(*patch.Title))
+		set("d_title_jamo", hangul.Decompose(*patch.Title))
+	}
+	if patch.TargetDate != nil {
+		set("d_target_date", *patch.TargetDate)
+	}
+	if patch.Category != nil {
+		set("d_category", *patch.Category)
+	}
+	if patch.Memo != nil {
+		set("d_memo", *patch.Memo)
+	}
+	if patch.IsImportant != nil {
+		set("d_is_important", *patch.IsImportant)
+	}
+	if patch.Recurrence != nil {
+		set("d_recurrence", *patch.Recurrence)
+	}
+	if patch.RecurrenceInterval != nil {
+		set("d_recurrence_interval", *patch.RecurrenceInterval)
+	}
+	if patch.CalendarType != nil {
+		set("d_calendar_type", *patch.CalendarType)
+	}
+	if patch.LunarDate != nil {
+		set("d_lunar_date", nullString(*patch.LunarDate))
+	}
+	if patch.IsLeapMonth != nil {
+		set("d_is_leap_month", *patch.IsLeapMonth)
+	}
+	return assignments, args
+}
+
+func (m *DdayManager) delete(q querier, userID, id string, version int) error {
+	existing, err := m.authorize(q, userID, id, RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE ddays_tb SET d_deleted_at = ?, d_version = d_version + 1 WHERE d_id = ? AND d_deleted_at IS NULL"
+	args := []interface{}{utcNow(), id}
+	if version > 0 {
+		query += " AND d_version = ?"
+		args = append(args, version)
+	}
+
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return m.missOrConflict(q, userID, id, version)
+	}
+
+	return m.recordRevision(q, id, RevisionDelete, userID, SnapshotOf(existing))
+}
+
+// missOrConflict explains a write that touched no rows: a live row means
+// its version moved on, anything else sql.ErrNoRows.
+func (m *DdayManager) missOrConflict(q querier, userID, id string, version int) error {
+	if version == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := m.getByID(q, userID, id); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// buildWhere compiles the query's filters, scoped to the D-Days userID
+// created or is a member of; withCursor adds the keyset condition, which
+// Count must ignore.
+func (m *DdayManager) buildWhere(userID string, q *Query, withCursor bool) (string, []interface{}) {
+	whereConditions := []string{accessCondition}
+	queryArgs := []interface{}{userID, userID}
+
+	for _, f := range q.filters {
+		column := ddayFields[f.Field].column
+		switch f.Op {
+		case OpIn:
+			whereConditions = append(whereConditions, fmt.Sprintf("%s IN (%s)", column, placeholders(len(f.Values))))
+			queryArgs = append(queryArgs, f.Values...)
+		case OpBetween:
+			whereConditions = append(whereConditions, column+" BETWEEN ? AND ?")
+			queryArgs = append(queryArgs, f.Values...)
+		case OpLike:
+			whereConditions = append(whereConditions, column+" LIKE ? ESCAPE '!'")
+			queryArgs = append(queryArgs, containsPattern(f.Values[0].(string)))
+		case OpWordPrefix:
+			prefix := escapeLike(f.Values[0].(string))
+			whereConditions = append(whereConditions, fmt.Sprintf("(%s LIKE ? ESCAPE '!' OR %s LIKE ? ESCAPE '!')", column, column))
+			queryArgs = append(queryArgs, prefix+"%", "% "+prefix+"%")
+		default:
+			whereConditions = append(whereConditions, fmt.Sprintf("%s %s ?", column, sqlOperators[f.Op]))
+			queryArgs = append(queryArgs, f.Values[0])
 		}
 	}
 
-	whereClause := strings.Join(whereConditions, " AND ")
-	return whereClause, orderClause, limitClause, queryArgs
-}
\ No newline at end of file
+	// One-off D-Days are matched exactly here. Recurring ones are never past,
+	// and matchInGo decides whether those already started are due today.
+	if q.status != "" {
+		today := q.today.Format(dateLayout)
+		switch q.status {
+		case StatusPast:
+			whereConditions = append(whereConditions, "d_recurrence = ? AND d_target_date < ?")
+			queryArgs = append(queryArgs, RecurrenceNone, today)
+		case StatusToday:
+			whereConditions = append(whereConditions, "(d_target_date = ? OR (d_recurrence <> ? AND d_target_date < ?))")
+			queryArgs = append(queryArgs, today, RecurrenceNone, today)
+		case StatusUpcoming:
+			whereConditions = append(whereConditions, "(d_target_date > ? OR (d_recurrence <> ? AND d_target_date < ?))")
+			queryArgs = append(queryArgs, today, RecurrenceNone, today)
+		}
+	}
+
+	// A subquery rather than a join keeps one row per D-Day.
+	if len(q.tags) > 0 {
+		condition := "d_id IN (SELECT dt_dday_id FROM dday_tags_tb JOIN tags_tb ON t_id = dt_tag_id WHERE t_name IN (" + placeholders(len(q.tags)) + ")"
+		for _, tag := range q.tags {
+			queryArgs = append(queryArgs, tag)
+		}
+		if q.tagMode == TagModeAll {
+			condition += " GROUP BY dt_dday_id HAVING COUNT(*) = ?"
+			queryArgs = append(queryArgs, len(q.tags))
+		}
+		whereConditions = append(whereConditions, condition+")")
+	}
+
+	if len(q.search) > 0 {
+		conditions, args := m.searchCondition(q.search)
+		whereConditions = append(whereConditions, conditions...)
+		queryArgs = append(queryArgs, args...)
+	}
+
+	if c := q.cursor; withCursor && c != nil && c.After != nil {
+		column := ddayFields[q.sort.Field].column
+		compare := ">"
+		if q.sort.Desc {
+			compare = "<"
+		}
+		whereConditions = append(whereConditions,
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND d_id %s ?))", column, compare, column, compare))
+		queryArgs = append(queryArgs, c.After.Value, c.After.Value, c.After.ID)
+	}
+
+	switch q.trash {
+	case ExcludeTrashed:
+		whereConditions = append(whereConditions, "d_deleted_at IS NULL")
+	case OnlyTrashed:
+		whereConditions = append(whereConditions, "d_deleted_at IS NOT NULL")
+	}
+
+	return strings.Join(whereConditions, " AND "), queryArgs
+}
+
+// buildOrder breaks ties on d_id so offset and keyset pages agree with the
+// memory store and never repeat or skip rows.
+func (m *DdayManager) buildOrder(q *Query) string {
+	direction := "ASC"
+	if q.sort.Desc {
+		direction = "DESC"
+	}
+	column := ddayFields[q.sort.Field].column
+	return column + " " + direction + ", d_id " + direction
+}
+
+func (m *DdayManager) buildLimit(q *Query) string {
+	if q.cursor != nil {
+		return fmt.Sprintf(" LIMIT %d", q.cursor.Limit)
+	}
+	return pagingLimit(q.paging)
+}
+
+func pagingLimit(p Paging) string {
+	if p.Page > 0 && p.PageSize > 0 {
+		return fmt.Sprintf(" LIMIT %d, %d", (p.Page-1)*p.PageSize, p.PageSize)
+	}
+	return ""
+}
+
+var sqlOperators = map[Operator]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// containsPattern turns s into a LIKE pattern matching it as a substring,
+// escaping wildcards with '!' (backslash escapes differ between MySQL and
+// SQLite).
+func containsPattern(s string) string {
+	return "%" + escapeLike(s) + "%"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
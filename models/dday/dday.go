@@ -1,28 +1,10 @@
 package dday
 
+// Categories seeded by migration 0001. The live list is managed through
+// models.CategoryStore and may no longer contain them.
 const (
 	CategoryPersonal = "개인"
 	CategoryStudy    = "학업"
 	CategoryWork     = "업무"
 	CategoryOther    = "기타"
 )
-
-var Categories = []string{
-	CategoryPersonal,
-	CategoryStudy,
-	CategoryWork,
-	CategoryOther,
-}
-
-func IsValidCategory(category string) bool {
-	for _, c := range Categories {
-		if c == category {
-			return true
-		}
-	}
-	return false
-}
-
-func GetDefaultCategory() string {
-	return CategoryPersonal
-}
\ No newline at end of file
@@ -0,0 +1,669 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryDB holds every table of the in-memory backend behind one lock so a
+// transaction can roll back across them as a unit. Tables are only written
+// through setRow, deleteRow, nextID and setCategories, which log how to undo
+// the write in the open transaction.
+type memoryDB struct {
+	mu             sync.Mutex
+	tx             *memoryTx
+	ddays          map[string]DDay
+	revisions      map[string][]Revision
+	categories     []Category
+	nextCategoryID int
+	users          map[string]User
+	refreshTokens  map[string]refreshToken
+	reminders      map[int]Reminder
+	nextReminderID int
+	shares         map[int]Share
+	nextShareID    int
+	members        map[memberKey]Member
+	invitations    map[int]Invitation
+	nextInviteID   int
+}
+
+// memberKey identifies a membership like the dday_members_tb primary key.
+type memberKey struct {
+	ddayID, userID string
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		ddays:         make(map[string]DDay),
+		revisions:     make(map[string][]Revision),
+		users:         make(map[string]User),
+		refreshTokens: make(map[string]refreshToken),
+		reminders:     make(map[int]Reminder),
+		shares:        make(map[int]Share),
+		members:       make(map[memberKey]Member),
+		invitations:   make(map[int]Invitation),
+	}
+}
+
+// onRollback logs undo in the open transaction, if any. Writes made outside
+// a transaction are final.
+func (db *memoryDB) onRollback(undo func()) {
+	if db.tx != nil {
+		db.tx.undo = append(db.tx.undo, undo)
+	}
+}
+
+// setRow writes row under key, logging the row it replaces.
+func setRow[K comparable, V any](db *memoryDB, table map[K]V, key K, row V) {
+	logRow(db, table, key)
+	table[key] = row
+}
+
+// deleteRow removes the row under key, logging it.
+func deleteRow[K comparable, V any](db *memoryDB, table map[K]V, key K) {
+	logRow(db, table, key)
+	delete(table, key)
+}
+
+// logRow logs how to put table[key] back the way it is now.
+func logRow[K comparable, V any](db *memoryDB, table map[K]V, key K) {
+	row, existed := table[key]
+	db.onRollback(func() {
+		if existed {
+			table[key] = row
+		} else {
+			delete(table, key)
+		}
+	})
+}
+
+// nextID advances an id counter and returns the new id.
+func (db *memoryDB) nextID(counter *int) int {
+	*counter++
+	db.onRollback(func() { *counter-- })
+	return *counter
+}
+
+// setCategories replaces the category list. Callers build a new list rather
+// than overwrite the current one's entries, which rollback would not see.
+func (db *memoryDB) setCategories(categories []Category) {
+	previous := db.categories
+	db.onRollback(func() { db.categories = previous })
+	db.categories = categories
+}
+
+// roleOf is userID's role on dday, or "" when it is not shared with them.
+func (db *memoryDB) roleOf(dday DDay, userID string) string {
+	if dday.UserID == userID {
+		return RoleOwner
+	}
+	return db.members[memberKey{dday.ID, userID}].Role
+}
+
+// memoryTx holds the memoryDB lock from Begin until Commit or Rollback, and
+// logs how to undo each write made meanwhile.
+type memoryTx struct {
+	db   *memoryDB
+	undo []func()
+	done bool
+}
+
+func (tx *memoryTx) Commit() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	tx.db.tx = nil
+	tx.db.mu.Unlock()
+	return nil
+}
+
+func (tx *memoryTx) Rollback() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.db.tx = nil
+	tx.db.mu.Unlock()
+	return nil
+}
+
+func (db *memoryDB) begin() *memoryTx {
+	db.mu.Lock()
+	db.tx = &memoryTx{db: db}
+	return db.tx
+}
+
+func (db *memoryDB) checkTx(tx Tx) error {
+	mtx, ok := tx.(*memoryTx)
+	if !ok || mtx.db != db {
+		return fmt.Errorf("unexpected transaction type %T", tx)
+	}
+	if mtx.done {
+		return sql.ErrTxDone
+	}
+	return nil
+}
+
+// inTx runs fn under a private transaction, rolling back if it fails.
+func (db *memoryDB) inTx(fn func() error) error {
+	tx := db.begin()
+	if err := fn(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// MemoryDdayStore keeps D-Days in process memory. Nothing survives a restart;
+// it exists for tests and for running the service without a database.
+type MemoryDdayStore struct {
+	db *memoryDB
+}
+
+func (m *MemoryDdayStore) Begin() (Tx, error) {
+	return m.db.begin(), nil
+}
+
+func (m *MemoryDdayStore) GetAll(userID string, q *Query) ([]DDay, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	ddays := m.filter(userID, q)
+	sortDdays(ddays, q.sort)
+	if q.fuzzy != "" {
+		ddays = rankFuzzy(ddays, q.fuzzy)
+	}
+
+	if q.cursor != nil {
+		return afterCursor(ddays, q.cursor, q.sort), nil
+	}
+
+	start, end := pageBounds(len(ddays), q.paging)
+	if start == end {
+		return nil, nil
+	}
+	return ddays[start:end], nil
+}
+
+func (m *MemoryDdayStore) GetByID(userID, id string) (*DDay, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	return m.getByID(userID, id)
+}
+
+func (m *MemoryDdayStore) getByID(userID, id string) (*DDay, error) {
+	dday, role, ok := m.visible(userID, id)
+	if !ok || dday.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	dday.Role = role
+	return &dday, nil
+}
+
+// authorize mirrors DdayManager.authorize.
+func (m *MemoryDdayStore) authorize(userID, id, need string) (*DDay, error) {
+	dday, err := m.getByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !RoleAllows(dday.Role, need) {
+		return nil, forbidden(need)
+	}
+	return dday, nil
+}
+
+// getOwn mirrors DdayManager.getOwn.
+func (m *MemoryDdayStore) getOwn(userID, id string) (*DDay, error) {
+	dday, err := m.getByID(userID, id)
+	if err == nil && dday.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return dday, err
+}
+
+// visible looks up id among the D-Days userID created or is a member of,
+// trashed ones included, with userID's role on it.
+func (m *MemoryDdayStore) visible(userID, id string) (DDay, string, bool) {
+	dday, ok := m.db.ddays[id]
+	if !ok {
+		return DDay{}, "", false
+	}
+	role := m.db.roleOf(dday, userID)
+	return dday, role, role != ""
+}
+
+// writable is visible for writes needing need, which fail with ErrForbidden
+// when userID's role falls short. Like the SQL store, trashed D-Days are
+// left to the caller.
+func (m *MemoryDdayStore) writable(userID, id, need string) (DDay, bool, error) {
+	dday, role, ok := m.visible(userID, id)
+	if !ok {
+		return DDay{}, false, nil
+	}
+	if dday.DeletedAt == nil && !RoleAllows(role, need) {
+		return DDay{}, false, forbidden(need)
+	}
+	return dday, true, nil
+}
+
+func (m *MemoryDdayStore) Create(userID string, dday *DDay) error {
+	return m.db.inTx(func() error {
+		return m.create(userID, dday)
+	})
+}
+
+func (m *MemoryDdayStore) Update(userID, id string, dday *DDay) error {
+	return m.db.inTx(func() error {
+		return m.update(userID, id, dday, RevisionUpdate)
+	})
+}
+
+func (m *MemoryDdayStore) Delete(userID, id string, version int) error {
+	return m.db.inTx(func() error {
+		return m.trash(userID, id, version)
+	})
+}
+
+func (m *MemoryDdayStore) Restore(userID, id string) error {
+	return m.db.inTx(func() error {
+		dday, role, ok := m.visible(userID, id)
+		if !ok || dday.DeletedAt == nil {
+			return sql.ErrNoRows
+		}
+		if !RoleAllows(role, RoleOwner) {
+			return forbidden(RoleOwner)
+		}
+		dday.DeletedAt = nil
+		dday.Version++
+		dday.UpdatedAt = utcNow()
+		setRow(m.db, m.db.ddays, dday.ID, dday)
+		m.recordRevision(dday.ID, RevisionRestore, userID, SnapshotOf(&dday))
+		return nil
+	})
+}
+
+func (m *MemoryDdayStore) Purge(cutoff time.Time) (int64, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	var purged int64
+	for id, dday := range m.db.ddays {
+		if dday.DeletedAt != nil && dday.DeletedAt.Before(cutoff) {
+			deleteRow(m.db, m.db.ddays, id)
+			deleteRow(m.db, m.db.revisions, id)
+			for reminderID, reminder := range m.db.reminders {
+				if reminder.DdayID == id {
+					deleteRow(m.db, m.db.reminders, reminderID)
+				}
+			}
+			for shareID, share := range m.db.shares {
+				if share.DdayID == id {
+					deleteRow(m.db, m.db.shares, shareID)
+				}
+			}
+			for key := range m.db.members {
+				if key.ddayID == id {
+					deleteRow(m.db, m.db.members, key)
+				}
+			}
+			for invitationID, invitation := range m.db.invitations {
+				if invitation.DdayID == id {
+					deleteRow(m.db, m.db.invitations, invitationID)
+				}
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MemoryDdayStore) Count(userID string, q *Query) (int, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	ddays := m.filter(userID, q)
+	if q.fuzzy != "" {
+		ddays = rankFuzzy(ddays, q.fuzzy)
+	}
+	return len(ddays), nil
+}
+
+func (m *MemoryDdayStore) GetByIDWithTx(tx Tx, userID, id string) (*DDay, error) {
+	if err := m.db.checkTx(tx); err != nil {
+		return nil, err
+	}
+	return m.getByID(userID, id)
+}
+
+func (m *MemoryDdayStore) Patch(userID, id string, patch *DdayPatch) error {
+	return m.db.inTx(func() error {
+		existing, ok, err := m.writable(userID, id, RoleEditor)
+		if err != nil {
+			return err
+		}
+		if !ok || existing.DeletedAt != nil {
+			return sql.ErrNoRows
+		}
+		if patch.Version > 0 && patch.Version != existing.Version {
+			return ErrVersionConflict
+		}
+
+		patch.Apply(&existing)
+		existing.Version++
+		existing.UpdatedAt = utcNow()
+		setRow(m.db, m.db.ddays, existing.ID, existing)
+		m.recordRevision(existing.ID, RevisionUpdate, userID, SnapshotOf(&existing))
+		return nil
+	})
+}
+
+func (m *MemoryDdayStore) CreateWithTx(tx Tx, userID string, dday *DDay) error {
+	if err := m.db.checkTx(tx); err != nil {
+		return err
+	}
+	return m.create(userID, dday)
+}
+
+func (m *MemoryDdayStore) UpdateWithTx(tx Tx, userID, id string, dday *DDay) error {
+	if err := m.db.checkTx(tx); err != nil {
+		return err
+	}
+	return m.update(userID, id, dday, RevisionUpdate)
+}
+
+func (m *MemoryDdayStore) DeleteWithTx(tx Tx, userID, id string, version int) error {
+	if err := m.db.checkTx(tx); err != nil {
+		return err
+	}
+	return m.trash(userID, id, version)
+}
+
+func (m *MemoryDdayStore) GetRevisions(userID, id string) ([]Revision, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, _, ok := m.visible(userID, id); !ok {
+		return nil, nil
+	}
+	revisions := append([]Revision(nil), m.db.revisions[id]...)
+	return newestFirst(withChanges(revisions)), nil
+}
+
+func (m *MemoryDdayStore) Revert(userID, id string, revision int) (*DDay, error) {
+	var reverted *DDay
+	err := m.db.inTx(func() error {
+		current, err := m.authorize(userID, id, RoleEditor)
+		if err != nil {
+			return err
+		}
+
+		for _, rev := range m.db.revisions[id] {
+			if rev.Revision == revision {
+				rev.Snapshot.Apply(current)
+				current.Category = m.liveCategory(current.Category)
+				if err := m.update(userID, id, current, RevisionRevert); err != nil {
+					return err
+				}
+				reverted, err = m.getByID(userID, id)
+				return err
+			}
+		}
+		return sql.ErrNoRows
+	})
+	return reverted, err
+}
+
+func (m *MemoryDdayStore) create(userID string, dday *DDay) error {
+	if _, exists := m.db.ddays[dday.ID]; exists {
+		return fmt.Errorf("duplicate d_id %s", dday.ID)
+	}
+	dday.UserID = userID
+	dday.Version = 1
+	if dday.Tags == nil {
+		dday.Tags = []string{}
+	}
+	stored := *dday
+	dday.Role = RoleOwner
+	stored.ID = strings.Clone(dday.ID)
+	stored.UserID = strings.Clone(userID)
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = utcNow()
+	}
+	stored.UpdatedAt = utcNow()
+	setRow(m.db, m.db.ddays, stored.ID, stored)
+	m.recordRevision(stored.ID, RevisionCreate, userID, SnapshotOf(&stored))
+	return nil
+}
+
+// update mirrors the SQL UPDATE: d_created_at is never rewritten. The stored
+// id is reused as the key because route params handed in by fiber point into
+// a reused request buffer.
+func (m *MemoryDdayStore) update(userID, id string, dday *DDay, action string) error {
+	existing, ok, err := m.writable(userID, id, RoleEditor)
+	if err != nil {
+		return err
+	}
+	if !ok || existing.DeletedAt != nil {
+		return sql.ErrNoRows
+	}
+	if dday.Version > 0 && dday.Version != existing.Version {
+		return ErrVersionConflict
+	}
+	SnapshotOf(dday).Apply(&existing)
+	existing.Version++
+	existing.UpdatedAt = utcNow()
+	setRow(m.db, m.db.ddays, existing.ID, existing)
+	m.recordRevision(existing.ID, action, userID, SnapshotOf(&existing))
+	return nil
+}
+
+func (m *MemoryDdayStore) trash(userID, id string, version int) error {
+	dday, ok, err := m.writable(userID, id, RoleOwner)
+	if err != nil {
+		return err
+	}
+	if !ok || dday.DeletedAt != nil {
+		return sql.ErrNoRows
+	}
+	if version > 0 && version != dday.Version {
+		return ErrVersionConflict
+	}
+	now := utcNow()
+	dday.DeletedAt = &now
+	dday.Version++
+	dday.UpdatedAt = now
+	setRow(m.db, m.db.ddays, dday.ID, dday)
+	m.recordRevision(dday.ID, RevisionDelete, userID, SnapshotOf(&dday))
+	return nil
+}
+
+func (m *MemoryDdayStore) recordRevision(id, action, actor string, snapshot DdaySnapshot) {
+	revisions := m.db.revisions[id]
+	setRow(m.db, m.db.revisions, id, append(revisions, Revision{
+		DdayID:    id,
+		Revision:  len(revisions) + 1,
+		Action:    action,
+		Actor:     strings.Clone(actor),
+		Snapshot:  snapshot,
+		CreatedAt: utcNow(),
+	}))
+}
+
+func (m *MemoryDdayStore) filter(userID string, q *Query) []DDay {
+	var ddays []DDay
+	for _, dday := range m.db.ddays {
+		if dday.Role = m.db.roleOf(dday, userID); dday.Role != "" && q.matches(dday) {
+			ddays = append(ddays, dday)
+		}
+	}
+	return ddays
+}
+
+// matches evaluates the query's filters, search and trash selection against
+// one D-Day the way the SQL store's WHERE clause would.
+func (q *Query) matches(d DDay) bool {
+	switch q.trash {
+	case ExcludeTrashed:
+		if d.DeletedAt != nil {
+			return false
+		}
+	case OnlyTrashed:
+		if d.DeletedAt == nil {
+			return false
+		}
+	}
+
+	for _, term := range q.search {
+		if !containsFold(d.Title, term) && !containsFold(d.Memo, term) {
+			return false
+		}
+	}
+
+	for _, f := range q.filters {
+		if !f.matches(fieldValue(d, f.Field)) {
+			return false
+		}
+	}
+
+	if len(q.tags) > 0 {
+		carried := 0
+		for _, tag := range q.tags {
+			for _, has := range d.Tags {
+				if has == tag {
+					carried++
+					break
+				}
+			}
+		}
+		if carried == 0 || q.tagMode == TagModeAll && carried < len(q.tags) {
+			return false
+		}
+	}
+
+	return q.status == "" || d.statusOn(q.today) == q.status
+}
+
+func (f Filter) matches(value interface{}) bool {
+	compare := func(operand interface{}) int {
+		c, _ := compareValues(value, operand)
+		return c
+	}
+
+	switch f.Op {
+	case OpEq:
+		return compare(f.Values[0]) == 0
+	case OpNe:
+		return compare(f.Values[0]) != 0
+	case OpLt:
+		return compare(f.Values[0]) < 0
+	case OpLte:
+		return compare(f.Values[0]) <= 0
+	case OpGt:
+		return compare(f.Values[0]) > 0
+	case OpGte:
+		return compare(f.Values[0]) >= 0
+	case OpBetween:
+		return compare(f.Values[0]) >= 0 && compare(f.Values[1]) <= 0
+	case OpIn:
+		for _, operand := range f.Values {
+			if compare(operand) == 0 {
+				return true
+			}
+		}
+		return false
+	case OpLike:
+		s, _ := value.(string)
+		return containsFold(s, f.Values[0].(string))
+	case OpWordPrefix:
+		s, _ := value.(string)
+		prefix := strings.ToLower(f.Values[0].(string))
+		s = strings.ToLower(s)
+		return strings.HasPrefix(s, prefix) || strings.Contains(s, " "+prefix)
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// compareValues orders two field values, returning -1, 0 or 1.
+func compareValues(a, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare string with %T", b)
+		}
+		return strings.Compare(x, y), nil
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare bool with %T", b)
+		}
+		if x == y {
+			return 0, nil
+		}
+		if !x {
+			return -1, nil
+		}
+		return 1, nil
+	case time.Time:
+		y, ok := b.(time.Time)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare time with %T", b)
+		}
+		return x.Compare(y), nil
+	}
+	return 0, fmt.Errorf("unsupported value %T", a)
+}
+
+// afterCursor takes the page following cursor from rows already sorted by
+// sortBy.
+func afterCursor(ddays []DDay, cursor *Cursor, sortBy Sort) []DDay {
+	start := 0
+	if after := cursor.After; after != nil {
+		start = sort.Search(len(ddays), func(i int) bool {
+			c, _ := compareValues(fieldValue(ddays[i], sortBy.Field), after.Value)
+			if c == 0 {
+				c = strings.Compare(ddays[i].ID, after.ID)
+			}
+			if sortBy.Desc {
+				return c < 0
+			}
+			return c > 0
+		})
+	}
+
+	ddays = ddays[start:]
+	if cursor.Limit > 0 && len(ddays) > cursor.Limit {
+		ddays = ddays[:cursor.Limit]
+	}
+	if len(ddays) == 0 {
+		return nil
+	}
+	return ddays
+}
+
+// sortDdays orders by sortBy, breaking ties on d_id in the same direction so
+// results are stable across calls and match the SQL keyset ordering.
+func sortDdays(ddays []DDay, sortBy Sort) {
+	sort.SliceStable(ddays, func(i, j int) bool {
+		c, _ := compareValues(fieldValue(ddays[i], sortBy.Field), fieldValue(ddays[j], sortBy.Field))
+		if c == 0 {
+			c = strings.Compare(ddays[i].ID, ddays[j].ID)
+		}
+		if sortBy.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
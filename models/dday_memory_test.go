@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemoryRollback(t *testing.T) {
+	db := newMemoryDB()
+	store := &MemoryDdayStore{db: db}
+	categories := newMemoryCategoryStore(db)
+	if err := store.Create("u1", &DDay{ID: "d1", Title: "생일", Category: "개인"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ddays := map[string]DDay{"d1": db.ddays["d1"]}
+	revisions := append([]Revision(nil), db.revisions["d1"]...)
+	categoryList := append([]Category(nil), db.categories...)
+	nextCategoryID := db.nextCategoryID
+
+	tx := db.begin()
+	if err := store.update("u1", "d1", &DDay{Title: "엄마 생일", Category: "개인"}, RevisionUpdate); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.create("u1", &DDay{ID: "d2", Title: "여행"}); err != nil {
+		t.Fatal(err)
+	}
+	store.recategorize("u1", "개인", "업무")
+	db.setCategories(append(db.categories, Category{ID: db.nextID(&db.nextCategoryID), Name: "여행"}))
+	db.setCategories(db.categories[1:])
+	deleteRow(db, db.ddays, "d1")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(db.ddays, ddays) {
+		t.Errorf("ddays = %v, want %v", db.ddays, ddays)
+	}
+	if got := db.revisions["d1"]; !reflect.DeepEqual(got, revisions) || len(db.revisions) != 1 {
+		t.Errorf("revisions = %v, want %v", db.revisions, revisions)
+	}
+	if !reflect.DeepEqual(db.categories, categoryList) || db.nextCategoryID != nextCategoryID {
+		t.Errorf("categories = %v (next %d), want %v (next %d)", db.categories, db.nextCategoryID, categoryList, nextCategoryID)
+	}
+	if all, _ := categories.GetAll(); len(all) != len(defaultCategories) {
+		t.Errorf("%d categories after rollback, want %d", len(all), len(defaultCategories))
+	}
+
+	// Writes after the transaction ended are not logged into it.
+	if err := tx.Rollback(); err == nil {
+		t.Error("second Rollback succeeded")
+	}
+	if err := store.Create("u1", &DDay{ID: "d3", Title: "시험"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.ddays["d3"]; !ok || db.tx != nil {
+		t.Errorf("d3 stored = %v, open tx = %v; want true, nil", ok, db.tx)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"dday-backend/models/hangul"
+	"sort"
+	"unicode/utf8"
+)
+
+// fuzzyMaxDistance allows one jamo edit for every four typed, so queries
+// shorter than a couple of syllables must match exactly.
+func fuzzyMaxDistance(pattern string) int {
+	return utf8.RuneCountInString(pattern) / 4
+}
+
+// rankFuzzy keeps the D-Days whose decomposed title is within
+// fuzzyMaxDistance of pattern, closest first. Equally close D-Days keep
+// their order, so callers sort by the query's ordering beforehand.
+func rankFuzzy(ddays []DDay, pattern string) []DDay {
+	maxDistance := fuzzyMaxDistance(pattern)
+
+	var matched []DDay
+	distances := map[string]int{}
+	for _, dday := range ddays {
+		distance := hangul.SubstringDistance(pattern, hangul.Decompose(dday.Title))
+		if distance <= maxDistance {
+			matched = append(matched, dday)
+			distances[dday.ID] = distance
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return distances[matched[i].ID] < distances[matched[j].ID]
+	})
+	return matched
+}
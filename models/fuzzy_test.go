@@ -0,0 +1,47 @@
+package models
+
+import (
+	"dday-backend/models/hangul"
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMaxDistance(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    int
+	}{
+		{"생", 0},   // ㅅㅐㅇ
+		{"생일", 1},  // ㅅㅐㅇㅇㅣㄹ
+		{"케이크", 1}, // ㅋㅔㅇㅣㅋㅡ
+		{"생일케이크", 3},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMaxDistance(hangul.Decompose(tt.keyword)); got != tt.want {
+			t.Errorf("fuzzyMaxDistance(%q) = %d, want %d", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestRankFuzzy(t *testing.T) {
+	ddays := []DDay{
+		{ID: "far", Title: "결혼기념일"},
+		{ID: "typo", Title: "생일케익"},
+		{ID: "spaced", Title: "생일 케이크"},
+		{ID: "exact", Title: "생일케이크 주문"},
+		{ID: "also-exact", Title: "엄마 생일케이크"},
+	}
+
+	var ids []string
+	for _, dday := range rankFuzzy(ddays, hangul.Decompose("생일케이크")) {
+		ids = append(ids, dday.ID)
+	}
+	// Equally close matches keep their order.
+	if want := []string{"exact", "also-exact", "spaced", "typo"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("rankFuzzy = %v, want %v", ids, want)
+	}
+
+	if matched := rankFuzzy(ddays, hangul.Decompose("샹")); len(matched) != 0 {
+		t.Errorf("rankFuzzy(샹) matched %d D-Days, want none for a one-syllable typo", len(matched))
+	}
+}
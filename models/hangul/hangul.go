@@ -0,0 +1,126 @@
+// Package hangul decomposes Hangul text into jamo so titles can be matched by
+// initial consonants (초성) or by edit distance while the user is typing.
+package hangul
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	syllableBase  = 0xAC00
+	syllableLast  = 0xD7A3
+	medialCount   = 21
+	finalCount    = 28
+	syllableBlock = medialCount * finalCount
+)
+
+// Compatibility jamo (U+3131..) indexed the way syllables are composed.
+var (
+	initials = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+	medials  = []rune("ㅏㅐㅑㅒㅓㅔㅕㅖㅗㅘㅙㅚㅛㅜㅝㅞㅟㅠㅡㅢㅣ")
+	finals   = append([]rune{0}, []rune("ㄱㄲㄳㄴㄵㄶㄷㄹㄺㄻㄼㄽㄾㄿㅀㅁㅂㅄㅅㅆㅇㅈㅊㅋㅌㅍㅎ")...)
+)
+
+// compounds splits compound vowels and final consonants into the keys typed
+// to produce them, so "달" is a prefix of "닭" once decomposed.
+var compounds = map[rune]string{
+	'ㄳ': "ㄱㅅ", 'ㄵ': "ㄴㅈ", 'ㄶ': "ㄴㅎ", 'ㄺ': "ㄹㄱ", 'ㄻ': "ㄹㅁ",
+	'ㄼ': "ㄹㅂ", 'ㄽ': "ㄹㅅ", 'ㄾ': "ㄹㅌ", 'ㄿ': "ㄹㅍ", 'ㅀ': "ㄹㅎ", 'ㅄ': "ㅂㅅ",
+	'ㅘ': "ㅗㅏ", 'ㅙ': "ㅗㅐ", 'ㅚ': "ㅗㅣ", 'ㅝ': "ㅜㅓ", 'ㅞ': "ㅜㅔ", 'ㅟ': "ㅜㅣ", 'ㅢ': "ㅡㅣ",
+}
+
+func isSyllable(r rune) bool {
+	return r >= syllableBase && r <= syllableLast
+}
+
+// IsConsonant reports whether r is a compatibility consonant such as 'ㅈ'.
+func IsConsonant(r rune) bool {
+	return r >= 'ㄱ' && r <= 'ㅎ'
+}
+
+// Decompose spells s out as the jamo sequence typed on a keyboard, with
+// compound jamo split and other letters lower-cased.
+func Decompose(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !isSyllable(r) {
+			writeJamo(&b, unicode.ToLower(r))
+			continue
+		}
+		offset := r - syllableBase
+		writeJamo(&b, initials[offset/syllableBlock])
+		writeJamo(&b, medials[offset%syllableBlock/finalCount])
+		if final := finals[offset%finalCount]; final != 0 {
+			writeJamo(&b, final)
+		}
+	}
+	return b.String()
+}
+
+func writeJamo(b *strings.Builder, r rune) {
+	if split, ok := compounds[r]; ok {
+		b.WriteString(split)
+		return
+	}
+	b.WriteRune(r)
+}
+
+// Choseong replaces every Hangul syllable in s with its initial consonant
+// and lower-cases everything else, so "전역일 D-Day" becomes "ㅈㅇㅇ d-day".
+func Choseong(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isSyllable(r) {
+			b.WriteRune(initials[(r-syllableBase)/syllableBlock])
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// IsChoseong reports whether s is made only of consonants and spaces, like a
+// query typed as "ㅈㅇㅇ".
+func IsChoseong(s string) bool {
+	found := false
+	for _, r := range s {
+		switch {
+		case IsConsonant(r):
+			found = true
+		case !unicode.IsSpace(r):
+			return false
+		}
+	}
+	return found
+}
+
+// SubstringDistance is the smallest Levenshtein distance between pattern and
+// any substring of text.
+func SubstringDistance(pattern, text string) int {
+	p, t := []rune(pattern), []rune(text)
+
+	// prev[j] is the best distance of the pattern prefix ending at text[j];
+	// a match may start anywhere, so the first row is all zero.
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for i := 1; i <= len(p); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if p[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j-1]+cost, prev[j]+1, curr[j-1]+1)
+		}
+		prev, curr = curr, prev
+	}
+
+	best := len(p)
+	for _, d := range prev {
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
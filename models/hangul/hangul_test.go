@@ -0,0 +1,82 @@
+package hangul
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChoseong(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"전역일 D-Day", "ㅈㅇㅇ d-day"},
+		{"까치 설날", "ㄲㅊ ㅅㄴ"},
+		{"ㅎㅇ 2024", "ㅎㅇ 2024"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Choseong(tt.in); got != tt.want {
+			t.Errorf("Choseong(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"한글", "ㅎㅏㄴㄱㅡㄹ"},
+		{"닭", "ㄷㅏㄹㄱ"},
+		{"괜찮아", "ㄱㅗㅐㄴㅊㅏㄴㅎㅇㅏ"},
+		{"의사 OK", "ㅇㅡㅣㅅㅏ ok"},
+	}
+	for _, tt := range tests {
+		if got := Decompose(tt.in); got != tt.want {
+			t.Errorf("Decompose(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// Typing a word spells out a prefix of it at every keystroke.
+	if !strings.HasPrefix(Decompose("닭"), Decompose("달")) {
+		t.Errorf("Decompose(%q) is not a prefix of Decompose(%q)", "달", "닭")
+	}
+}
+
+func TestIsChoseong(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"ㅈㅇㅇ", true},
+		{"ㅅㅇ ㅋㅇㅋ", true},
+		{"ㅈㅇ일", false},
+		{"ㅏ", false},
+		{"abc", false},
+		{" ", false},
+	}
+	for _, tt := range tests {
+		if got := IsChoseong(tt.in); got != tt.want {
+			t.Errorf("IsChoseong(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSubstringDistance(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		want          int
+	}{
+		{"", "anything", 0},
+		{"cake", "birthday cake", 0},
+		{"cak", "birthday cake", 0},
+		{"ckae", "birthday cake", 2},
+		{"cakes", "cake", 1},
+		{"xyz", "", 3},
+		{Decompose("생일"), Decompose("엄마 생신"), 2},
+	}
+	for _, tt := range tests {
+		if got := SubstringDistance(tt.pattern, tt.text); got != tt.want {
+			t.Errorf("SubstringDistance(%q, %q) = %d, want %d", tt.pattern, tt.text, got, tt.want)
+		}
+	}
+}
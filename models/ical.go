@@ -0,0 +1,193 @@
+package models
+
+import (
+	"dday-backend/ical"
+	"dday-backend/models/lunar"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICalProdID identifies this server in exported calendars.
+const ICalProdID = "-//ddayback//D-Day//KO"
+
+// MaxTitleLength is the longest title d_title holds, in characters.
+const MaxTitleLength = 255
+
+var (
+	ErrICalNoSummary    = errors.New("event has no summary")
+	ErrICalNoDate       = errors.New("event has no start date")
+	ErrICalTitleTooLong = fmt.Errorf("summary is longer than %d characters", MaxTitleLength)
+)
+
+// maxLunarRDates caps the dates listed for a yearly lunar D-Day, which
+// RRULE cannot express.
+const maxLunarRDates = 200
+
+// ICalEvent maps the D-Day to an all-day event, with a display alarm for
+// each of its active reminders.
+func (d *DDay) ICalEvent(reminders []Reminder) (ical.Event, error) {
+	start, err := time.Parse(dateLayout, d.TargetDate)
+	if err != nil {
+		return ical.Event{}, err
+	}
+
+	event := ical.Event{
+		UID:          d.ID + "@ddayback",
+		Summary:      d.Title,
+		Description:  d.Memo,
+		Date:         start,
+		Created:      d.CreatedAt,
+		LastModified: d.UpdatedAt,
+	}
+	if d.Category != "" {
+		event.Categories = []string{d.Category}
+	}
+
+	if d.CalendarType == CalendarLunar && d.IsRecurring() {
+		lastDay := time.Date(lunar.MaxYear+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		occurrences, _ := d.Occurrences(start.AddDate(0, 0, 1), lastDay, maxLunarRDates)
+		event.RDates = occurrences
+	} else {
+		event.RRule = d.rrule(start)
+	}
+
+	for _, reminder := range reminders {
+		if !reminder.IsActive {
+			continue
+		}
+		event.Alarms = append(event.Alarms, ical.Alarm{
+			DaysBefore:  reminder.DaysBefore,
+			Description: d.Title,
+		})
+	}
+	return event, nil
+}
+
+// rrule is the RRULE of a solar D-Day starting on start, or "" for a one-off.
+// Monthly and yearly D-Days fall on the last day of months too short for
+// their date, which BYSETPOS=-1 over the candidate days expresses.
+func (d *DDay) rrule(start time.Time) string {
+	if !d.IsRecurring() {
+		return ""
+	}
+
+	rule := fmt.Sprintf("FREQ=%s;INTERVAL=%d", strings.ToUpper(d.Recurrence), max(d.RecurrenceInterval, 1))
+	switch {
+	case d.Recurrence == RecurrenceMonthly && start.Day() > 28:
+		rule += ";BYMONTHDAY=" + monthDays(start.Day()) + ";BYSETPOS=-1"
+	case d.Recurrence == RecurrenceYearly && start.Month() == time.February && start.Day() == 29:
+		rule += ";BYMONTH=2;BYMONTHDAY=28,29;BYSETPOS=-1"
+	}
+	return rule
+}
+
+// monthDays lists the days from the 28th through day.
+func monthDays(day int) string {
+	days := make([]string, 0, day-27)
+	for d := 28; d <= day; d++ {
+		days = append(days, fmt.Sprint(d))
+	}
+	return strings.Join(days, ",")
+}
+
+// DDayFromICal maps an imported event to a D-Day on the event's date, which
+// for a timed event is its date in loc. The category is left to the caller.
+// Warnings describe what the D-Day cannot carry over, such as parts of a
+// recurrence rule.
+func DDayFromICal(ev ical.Event, loc *time.Location) (DDay, []string, error) {
+	title := strings.TrimSpace(ev.Summary)
+	if title == "" {
+		return DDay{}, nil, ErrICalNoSummary
+	}
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return DDay{}, nil, ErrICalTitleTooLong
+	}
+	if ev.Date.IsZero() {
+		return DDay{}, nil, ErrICalNoDate
+	}
+
+	date := ev.Date
+	if !ev.AllDay {
+		local := date.In(loc)
+		date = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	d := DDay{
+		Title:        title,
+		TargetDate:   date.Format(dateLayout),
+		Memo:         strings.TrimSpace(ev.Description),
+		CalendarType: CalendarSolar,
+	}
+
+	var warnings []string
+	d.Recurrence, d.RecurrenceInterval, warnings = recurrenceFromRRule(ev.RRule, date)
+	if len(ev.RDates) > 0 {
+		warnings = append(warnings, "Extra dates (RDATE) were not imported")
+	}
+	return d, warnings, nil
+}
+
+// recurrenceFromRRule maps an RRULE onto a recurrence and interval. Rules
+// exported by ICalEvent come back unchanged; of other rules only FREQ and
+// INTERVAL are kept.
+func recurrenceFromRRule(rule string, start time.Time) (string, int, []string) {
+	if rule == "" {
+		return RecurrenceNone, 1, nil
+	}
+
+	parts := make(map[string]string)
+	for _, part := range strings.Split(rule, ";") {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			parts[strings.ToUpper(key)] = strings.ToUpper(value)
+		}
+	}
+
+	var warnings []string
+	recurrence := strings.ToLower(parts["FREQ"])
+	switch recurrence {
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+	default:
+		return RecurrenceNone, 1, []string{fmt.Sprintf("Repeats %s, which D-Days cannot; imported as a one-off", strings.ToLower(parts["FREQ"]))}
+	}
+
+	interval := 1
+	if value, ok := parts["INTERVAL"]; ok {
+		n, err := strconv.Atoi(value)
+		switch {
+		case err != nil || n < 1:
+			warnings = append(warnings, "Invalid INTERVAL was read as 1")
+		case n > MaxRecurrenceInterval:
+			interval = MaxRecurrenceInterval
+			warnings = append(warnings, fmt.Sprintf("INTERVAL was capped at %d", MaxRecurrenceInterval))
+		default:
+			interval = n
+		}
+	}
+
+	d := DDay{Recurrence: recurrence, RecurrenceInterval: interval}
+	if strings.EqualFold(d.rrule(start), rule) {
+		return recurrence, interval, warnings
+	}
+
+	var dropped []string
+	for key := range parts {
+		switch key {
+		case "FREQ", "INTERVAL", "WKST":
+		case "COUNT", "UNTIL":
+			warnings = append(warnings, "The event stops repeating ("+key+"), but the D-Day repeats indefinitely")
+		default:
+			dropped = append(dropped, key)
+		}
+	}
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		warnings = append(warnings, "Only FREQ and INTERVAL of the RRULE were kept; ignored "+strings.Join(dropped, ", "))
+	}
+	sort.Strings(warnings)
+	return recurrence, interval, warnings
+}
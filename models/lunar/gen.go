@@ -0,0 +1,355 @@
+//go:build ignore
+
+// gen computes the Korean lunisolar calendar from astronomical new moons
+// and solar terms and writes table.go. Run it with go generate.
+//
+// The rules are those of the calendar published by KASI (한국천문연구원):
+//   - a month begins on the day, in Korean standard time, of a new moon;
+//   - the month containing the winter solstice is the 11th;
+//   - when 13 months fall between two 11th months, the first of them
+//     without a principal solar term (중기) is a leap month and repeats the
+//     number of the month before it.
+//
+// Korean standard time was UTC+8:30 from 1908-04-01 to 1911-12-31 and from
+// 1954-03-21 to 1961-08-09, UTC+9 otherwise; before 1908 the calendar
+// follows Seoul's local mean time.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	firstYear = 1900
+	lastYear  = 2100
+)
+
+func main() {
+	months := lunarMonths(firstYear-2, lastYear+2)
+	if err := writeTable(months); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type month struct {
+	start  int // JDN of the first day
+	number int
+	leap   bool
+}
+
+// lunarMonths lists the months from the 11th month of year from to the 11th
+// month of year to, which closes the list with only its start filled in.
+func lunarMonths(from, to int) []month {
+	var moons []int
+	first := math.Floor((float64(from) - 0.2 - 2000) * 12.3685)
+	last := math.Ceil((float64(to) + 1.2 - 2000) * 12.3685)
+	for k := first; k <= last; k++ {
+		moons = append(moons, localDay(newMoon(k)))
+	}
+
+	// Principal terms lie at multiples of 30 degrees; 270 is the winter
+	// solstice.
+	var terms, solstices []int
+	for y := from; y <= to; y++ {
+		for i := 0; i < 12; i++ {
+			spring := float64(dateToJDN(time.Date(y, 3, 20, 0, 0, 0, 0, time.UTC)))
+			day := localDay(solarTerm(float64(i*30), spring+float64(i)*30.44))
+			terms = append(terms, day)
+			if i == 9 {
+				solstices = append(solstices, day)
+			}
+		}
+	}
+	sort.Ints(terms)
+
+	containing := func(day int) int {
+		return sort.Search(len(moons), func(i int) bool { return moons[i] > day }) - 1
+	}
+	hasTerm := func(i int) bool {
+		j := sort.SearchInts(terms, moons[i])
+		return j < len(terms) && terms[j] < moons[i+1]
+	}
+
+	var months []month
+	for s := 0; s+1 < len(solstices); s++ {
+		a, b := containing(solstices[s]), containing(solstices[s+1])
+		leapAt := -1
+		switch b - a {
+		case 12:
+		case 13:
+			for i := a + 1; i < b; i++ {
+				if !hasTerm(i) {
+					leapAt = i
+					break
+				}
+			}
+		default:
+			log.Fatalf("%d months between the solstices of %d and %d", b-a, from+s, from+s+1)
+		}
+
+		number := 11
+		for i := a; i < b; i++ {
+			if i == leapAt {
+				months = append(months, month{moons[i], number, true})
+				continue
+			}
+			if i != a {
+				number = number%12 + 1
+			}
+			months = append(months, month{moons[i], number, false})
+		}
+	}
+	return append(months, month{start: moons[containing(solstices[len(solstices)-1])]})
+}
+
+// writeTable encodes lunar years firstYear to lastYear into table.go.
+func writeTable(months []month) error {
+	var firstNewYear int
+	var codes []uint32
+	for i := 0; i+1 < len(months) && len(codes) <= lastYear-firstYear; i++ {
+		m := months[i]
+		if m.number != 1 || m.leap || jdnToDate(m.start).Year() < firstYear {
+			continue
+		}
+		if firstNewYear == 0 {
+			firstNewYear = m.start
+		}
+
+		var code uint32
+		for j := 0; ; j++ {
+			if months[i+j+1].start-months[i+j].start == 30 {
+				code |= 1 << j
+			}
+			if months[i+j].leap {
+				code |= uint32(months[i+j].number) << 13
+			}
+			if next := months[i+j+1]; next.number == 1 && !next.leap {
+				break
+			}
+		}
+		codes = append(codes, code)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen.go; DO NOT EDIT.\n\npackage lunar\n\n")
+	fmt.Fprintf(&buf, "const (\n\tMinYear = %d\n\tMaxYear = %d\n)\n\n", firstYear, lastYear)
+	newYear := jdnToDate(firstNewYear)
+	fmt.Fprintf(&buf, "// firstNewYear is the solar date of 1/1 of MinYear as days since 1970-01-01.\n")
+	fmt.Fprintf(&buf, "const firstNewYear = %d // %s\n\n", firstNewYear-2440588, newYear.Format("2006-01-02"))
+	fmt.Fprintf(&buf, "// years[i] describes lunar year MinYear+i. Bit j is set when the year's jth\n")
+	fmt.Fprintf(&buf, "// month, counting a leap month in its place, has 30 days rather than 29;\n")
+	fmt.Fprintf(&buf, "// bits 13-16 hold the number of the leap month, or 0.\n")
+	fmt.Fprintf(&buf, "var years = [...]uint32{\n")
+	for i, code := range codes {
+		if i%8 == 0 {
+			buf.WriteString("\t")
+		}
+		fmt.Fprintf(&buf, "0x%05x,", code)
+		if i%8 == 7 || i == len(codes)-1 {
+			fmt.Fprintf(&buf, " // %d\n", firstYear+i-i%8)
+		} else {
+			buf.WriteString(" ")
+		}
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile("table.go", src, 0o644)
+}
+
+func jdnToDate(jdn int) time.Time {
+	return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, jdn-2451545)
+}
+
+func dateToJDN(t time.Time) int {
+	return 2451545 + int(t.Sub(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).Hours()/24)
+}
+
+// standardOffset is Korean standard time, in hours east of UTC, at jd (UT).
+func standardOffset(jd float64) float64 {
+	day := jdnToDate(int(math.Floor(jd + 0.5)))
+	switch {
+	case day.Before(time.Date(1908, 4, 1, 0, 0, 0, 0, time.UTC)):
+		return 8 + 27.0/60 + 52.0/3600
+	case day.Before(time.Date(1912, 1, 1, 0, 0, 0, 0, time.UTC)):
+		return 8.5
+	case day.Before(time.Date(1954, 3, 21, 0, 0, 0, 0, time.UTC)):
+		return 9
+	case day.Before(time.Date(1961, 8, 10, 0, 0, 0, 0, time.UTC)):
+		return 8.5
+	}
+	return 9
+}
+
+// localDay is the JDN of the day in Korea on which the instant jde falls.
+func localDay(jde float64) int {
+	year := 2000 + (jde-2451545)/365.25
+	jd := jde - deltaT(year)/86400
+	return int(math.Floor(jd + standardOffset(jd)/24 + 0.5))
+}
+
+const rad = math.Pi / 180
+
+// deltaT approximates TT - UT in seconds in year y (Espenak & Meeus).
+func deltaT(y float64) float64 {
+	switch {
+	case y < 1920:
+		t := y - 1900
+		return -2.79 + 1.494119*t - 0.0598939*t*t + 0.0061966*t*t*t - 0.000197*t*t*t*t
+	case y < 1941:
+		t := y - 1920
+		return 21.20 + 0.84493*t - 0.076100*t*t + 0.0020936*t*t*t
+	case y < 1961:
+		t := y - 1950
+		return 29.07 + 0.407*t - t*t/233 + t*t*t/2547
+	case y < 1986:
+		t := y - 1975
+		return 45.45 + 1.067*t - t*t/260 - t*t*t/718
+	case y < 2005:
+		t := y - 2000
+		return 63.86 + 0.3345*t - 0.060374*t*t + 0.0017275*t*t*t + 0.000651814*t*t*t*t + 0.00002373599*t*t*t*t*t
+	case y < 2050:
+		t := y - 2000
+		return 62.92 + 0.32217*t + 0.005589*t*t
+	default:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u - 0.5628*(2150-y)
+	}
+}
+
+// newMoon returns the JDE of the kth new moon after 2000-01-06
+// (Meeus, Astronomical Algorithms, ch. 49).
+func newMoon(k float64) float64 {
+	T := k / 1236.85
+	jde := 2451550.09766 + 29.530588861*k + 0.00015437*T*T - 0.000000150*T*T*T + 0.00000000073*T*T*T*T
+	E := 1 - 0.002516*T - 0.0000074*T*T
+	M := (2.5534 + 29.10535670*k - 0.0000014*T*T - 0.00000011*T*T*T) * rad
+	Mp := (201.5643 + 385.81693528*k + 0.0107582*T*T + 0.00001238*T*T*T - 0.000000058*T*T*T*T) * rad
+	F := (160.7108 + 390.67050284*k - 0.0016118*T*T - 0.00000227*T*T*T + 0.000000011*T*T*T*T) * rad
+	O := (124.7746 - 1.56375588*k + 0.0020672*T*T + 0.00000215*T*T*T) * rad
+	s := math.Sin
+	c := -0.40720*s(Mp) + 0.17241*E*s(M) + 0.01608*s(2*Mp) + 0.01039*s(2*F) +
+		0.00739*E*s(Mp-M) - 0.00514*E*s(Mp+M) + 0.00208*E*E*s(2*M) - 0.00111*s(Mp-2*F) -
+		0.00057*s(Mp+2*F) + 0.00056*E*s(2*Mp+M) - 0.00042*s(3*Mp) + 0.00042*E*s(M+2*F) +
+		0.00038*E*s(M-2*F) - 0.00024*E*s(2*Mp-M) - 0.00017*s(O) - 0.00007*s(Mp+2*M) +
+		0.00004*s(2*Mp-2*F) + 0.00004*s(3*M) + 0.00003*s(Mp+M-2*F) + 0.00003*s(2*Mp+2*F) -
+		0.00003*s(Mp+M+2*F) + 0.00003*s(Mp-M+2*F) - 0.00002*s(Mp-M-2*F) - 0.00002*s(3*Mp+M) +
+		0.00002*s(4*Mp)
+	A := []struct{ a, b, amp float64 }{
+		{299.77, 0.107408, 0.000325}, {251.88, 0.016321, 0.000165}, {251.83, 26.651886, 0.000164},
+		{349.42, 36.412478, 0.000126}, {84.66, 18.206239, 0.000110}, {141.74, 53.303771, 0.000062},
+		{207.14, 2.453732, 0.000060}, {154.84, 7.306860, 0.000056}, {34.52, 27.261239, 0.000047},
+		{207.19, 0.121824, 0.000042}, {291.34, 1.844379, 0.000040}, {161.72, 24.198154, 0.000037},
+		{239.56, 25.513099, 0.000035}, {331.55, 3.592518, 0.000023},
+	}
+	for i, a := range A {
+		arg := a.a + a.b*k
+		if i == 0 {
+			arg -= 0.009173 * T * T
+		}
+		c += a.amp * s(arg*rad)
+	}
+	return jde + c
+}
+
+// earthL and earthR are the VSOP87 series for the Earth's heliocentric
+// longitude and radius vector, truncated as in Meeus, appendix III.
+type term struct{ a, b, c float64 }
+
+var earthL = [][]term{
+	{{175347046, 0, 0}, {3341656, 4.6692568, 6283.0758500}, {34894, 4.6261, 12566.1517}, {3497, 2.7441, 5753.3849},
+		{3418, 2.8289, 3.5231}, {3136, 3.6277, 77713.7715}, {2676, 4.4181, 7860.4194}, {2343, 6.1352, 3930.2097},
+		{1324, 0.7425, 11506.7698}, {1273, 2.0371, 529.6910}, {1199, 1.1096, 1577.3435}, {990, 5.233, 5884.927},
+		{902, 2.045, 26.298}, {857, 3.508, 398.149}, {780, 1.179, 5223.694}, {753, 2.533, 5507.553},
+		{505, 4.583, 18849.228}, {492, 4.205, 775.523}, {357, 2.920, 0.067}, {317, 5.849, 11790.629},
+		{284, 1.899, 796.298}, {271, 0.315, 10977.079}, {243, 0.345, 5486.778}, {206, 4.806, 2544.314},
+		{205, 1.869, 5573.143}, {202, 2.458, 6069.777}, {156, 0.833, 213.299}, {132, 3.411, 2942.463},
+		{126, 1.083, 20.775}, {115, 0.645, 0.980}, {103, 0.636, 4694.003}, {102, 0.976, 15720.839},
+		{102, 4.267, 7.114}, {99, 6.21, 2146.17}, {98, 0.68, 155.42}, {86, 5.98, 161000.69},
+		{85, 1.30, 6275.96}, {85, 3.67, 71430.70}, {80, 1.81, 17260.15}, {79, 3.04, 12036.46},
+		{75, 1.76, 5088.63}, {74, 3.50, 3154.69}, {74, 4.68, 801.82}, {70, 0.83, 9437.76},
+		{62, 3.98, 8827.39}, {61, 1.82, 7084.90}, {57, 2.78, 6286.60}, {56, 4.39, 14143.50},
+		{56, 3.47, 6279.55}, {52, 0.19, 12139.55}, {52, 1.33, 1748.02}, {51, 0.28, 5856.48},
+		{49, 0.49, 1194.45}, {41, 5.37, 8429.24}, {41, 2.40, 19651.05}, {39, 6.17, 10447.39},
+		{37, 6.04, 10213.29}, {37, 2.57, 1059.38}, {36, 1.71, 2352.87}, {36, 1.78, 6812.77},
+		{33, 0.59, 17789.85}, {30, 0.44, 83996.85}, {30, 2.74, 1349.87}, {25, 3.16, 4690.48}},
+	{{628331966747, 0, 0}, {206059, 2.678235, 6283.07585}, {4303, 2.6351, 12566.1517}, {425, 1.590, 3.523},
+		{119, 5.796, 26.298}, {109, 2.966, 1577.344}, {93, 2.59, 18849.23}, {72, 1.14, 529.69},
+		{68, 1.87, 398.15}, {67, 4.41, 5507.55}, {59, 2.89, 5223.69}, {56, 2.17, 155.42},
+		{45, 0.40, 796.30}, {36, 0.47, 775.52}, {29, 2.65, 7.11}, {21, 5.34, 0.98},
+		{19, 1.85, 5486.78}, {19, 4.97, 213.30}, {17, 2.99, 6275.96}, {16, 0.03, 2544.31},
+		{16, 1.43, 2146.17}, {15, 1.21, 10977.08}, {12, 2.83, 1748.02}, {12, 3.26, 5088.63},
+		{12, 5.27, 1194.45}, {12, 2.08, 4694.00}, {11, 0.77, 553.57}, {10, 1.30, 6286.60},
+		{10, 4.24, 1349.87}, {9, 2.70, 242.73}, {9, 5.64, 951.72}, {8, 5.30, 2352.87},
+		{6, 2.65, 9437.76}, {6, 4.67, 4690.48}},
+	{{52919, 0, 0}, {8720, 1.0721, 6283.0758}, {309, 0.867, 12566.152}, {27, 0.05, 3.52},
+		{16, 5.19, 26.30}, {16, 3.68, 155.42}, {10, 0.76, 18849.23}, {9, 2.06, 77713.77},
+		{7, 0.83, 775.52}, {5, 4.66, 1577.34}, {4, 1.03, 7.11}, {4, 3.44, 5573.14},
+		{3, 5.14, 796.30}, {3, 6.05, 5507.55}, {3, 1.19, 242.73}, {3, 6.12, 529.69},
+		{3, 0.31, 398.15}, {3, 2.28, 553.57}, {2, 4.38, 5223.69}, {2, 3.75, 0.98}},
+	{{289, 5.844, 6283.076}, {35, 0, 0}, {17, 5.49, 12566.15}, {3, 5.20, 155.42},
+		{1, 4.72, 3.52}, {1, 5.30, 18849.23}, {1, 5.97, 242.73}},
+	{{114, 3.142, 0}, {8, 4.13, 6283.08}, {1, 3.84, 12566.15}},
+	{{1, 3.14, 0}},
+}
+
+var earthR0 = []term{{100013989, 0, 0}, {1670700, 3.0984635, 6283.0758500}, {13956, 3.05525, 12566.15170}, {3084, 5.1985, 77713.7715}}
+var earthR1 = []term{{103019, 1.107490, 6283.075850}, {1721, 1.0644, 12566.1517}}
+
+func series(ts []term, tau float64) float64 {
+	sum := 0.0
+	for _, t := range ts {
+		sum += t.a * math.Cos(t.b+t.c*tau)
+	}
+	return sum
+}
+
+// sunLongitude is the apparent geocentric longitude of the sun at jde, in
+// degrees (Meeus, ch. 25).
+func sunLongitude(jde float64) float64 {
+	tau := (jde - 2451545) / 365250
+	L := 0.0
+	for i := len(earthL) - 1; i >= 0; i-- {
+		L = L*tau + series(earthL[i], tau)
+	}
+	L = L / 1e8
+	R := (series(earthR0, tau) + series(earthR1, tau)*tau) / 1e8
+	theta := math.Mod(L/rad+180, 360)
+	T := tau * 10
+	// FK5 correction, nutation in longitude and aberration.
+	theta -= 0.09033 / 3600
+	omega := (125.04452 - 1934.136261*T) * rad
+	Ls := (280.4665 + 36000.7698*T) * rad
+	Lm := (218.3165 + 481267.8813*T) * rad
+	dpsi := -17.20*math.Sin(omega) - 1.32*math.Sin(2*Ls) - 0.23*math.Sin(2*Lm) + 0.21*math.Sin(2*omega)
+	theta += dpsi/3600 - 20.4898/3600/R
+	theta = math.Mod(theta, 360)
+	if theta < 0 {
+		theta += 360
+	}
+	return theta
+}
+
+// solarTerm finds the JDE near guess at which the sun reaches longitude lon.
+func solarTerm(lon, guess float64) float64 {
+	jde := guess
+	for i := 0; i < 50; i++ {
+		d := lon - sunLongitude(jde)
+		d = math.Mod(d+540, 360) - 180
+		jde += d * 365.25 / 360
+		if math.Abs(d) < 1e-7 {
+			break
+		}
+	}
+	return jde
+}
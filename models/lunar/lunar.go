@@ -0,0 +1,177 @@
+// Package lunar converts between solar (Gregorian) dates and the Korean
+// lunisolar calendar (음력) for lunar years MinYear to MaxYear. The table
+// behind it is computed by gen.go and agrees with KASI where the Korean and
+// Chinese calendars differ, as for the leap months of 2012 and 2017.
+package lunar
+
+//go:generate go run gen.go
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrOutOfRange  = errors.New("date outside the supported lunar calendar")
+	ErrInvalidDate = errors.New("no such lunar date")
+)
+
+// Date is a day of the lunar calendar. Leap marks the leap month (윤달)
+// that follows the regular month of the same number.
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+	Leap  bool
+}
+
+// String formats the date as YYYY-MM-DD, without the leap flag.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Parse reads a lunar YYYY-MM-DD, which unlike a solar date may name the
+// 30th of any month, and checks that it exists.
+func Parse(value string, leap bool) (Date, error) {
+	var d Date
+	if len(value) != 10 {
+		return d, ErrInvalidDate
+	}
+	if _, err := fmt.Sscanf(value, "%4d-%2d-%2d", &d.Year, &d.Month, &d.Day); err != nil {
+		return d, ErrInvalidDate
+	}
+	d.Leap = leap
+	if _, err := d.offset(); err != nil {
+		return Date{}, err
+	}
+	return d, nil
+}
+
+// yearStarts[i] is the first day of lunar year MinYear+i as days since
+// 1970-01-01, with one extra entry for the day after MaxYear ends.
+var yearStarts = func() []int {
+	starts := make([]int, len(years)+1)
+	starts[0] = firstNewYear
+	for i := range years {
+		starts[i+1] = starts[i] + yearDays(i)
+	}
+	return starts
+}()
+
+func monthCount(i int) int {
+	if years[i]>>13 != 0 {
+		return 13
+	}
+	return 12
+}
+
+// monthLength is the length of the jth month of year index i, counting a
+// leap month in its place.
+func monthLength(i, j int) int {
+	if years[i]&(1<<j) != 0 {
+		return 30
+	}
+	return 29
+}
+
+func yearDays(i int) int {
+	days := 0
+	for j := 0; j < monthCount(i); j++ {
+		days += monthLength(i, j)
+	}
+	return days
+}
+
+// LeapMonth returns the number of year's leap month, or 0 when it has none
+// or is out of range.
+func LeapMonth(year int) int {
+	if year < MinYear || year > MaxYear {
+		return 0
+	}
+	return int(years[year-MinYear] >> 13)
+}
+
+// MonthDays returns the length, 29 or 30, of a month of year.
+func MonthDays(year, month int, leap bool) (int, error) {
+	i, j, err := monthIndex(year, month, leap)
+	if err != nil {
+		return 0, err
+	}
+	return monthLength(i, j), nil
+}
+
+// monthIndex locates a month as a year index and its position in the year.
+func monthIndex(year, month int, leap bool) (int, int, error) {
+	if year < MinYear || year > MaxYear {
+		return 0, 0, ErrOutOfRange
+	}
+	if month < 1 || month > 12 {
+		return 0, 0, ErrInvalidDate
+	}
+
+	i := year - MinYear
+	leapMonth := int(years[i] >> 13)
+	if leap && leapMonth != month {
+		return 0, 0, ErrInvalidDate
+	}
+	j := month - 1
+	if leapMonth != 0 && (month > leapMonth || leap) {
+		j++
+	}
+	return i, j, nil
+}
+
+// offset counts the days from 1970-01-01 to d.
+func (d Date) offset() (int, error) {
+	i, j, err := monthIndex(d.Year, d.Month, d.Leap)
+	if err != nil {
+		return 0, err
+	}
+	if d.Day < 1 || d.Day > monthLength(i, j) {
+		return 0, ErrInvalidDate
+	}
+
+	days := yearStarts[i] + d.Day - 1
+	for k := 0; k < j; k++ {
+		days += monthLength(i, k)
+	}
+	return days, nil
+}
+
+// ToSolar converts d to its solar date, at midnight UTC.
+func ToSolar(d Date) (time.Time, error) {
+	days, err := d.offset()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(days)*86400, 0).UTC(), nil
+}
+
+// FromSolar converts the calendar date of t to the lunar calendar.
+func FromSolar(t time.Time) (Date, error) {
+	days := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	if days < yearStarts[0] || days >= yearStarts[len(years)] {
+		return Date{}, ErrOutOfRange
+	}
+
+	i := sort.Search(len(years), func(i int) bool { return yearStarts[i+1] > days })
+	d := Date{Year: MinYear + i}
+	leapMonth := int(years[i] >> 13)
+	rest := days - yearStarts[i]
+	for j := 0; j < monthCount(i); j++ {
+		length := monthLength(i, j)
+		if rest < length {
+			d.Month = j + 1
+			if leapMonth != 0 && j >= leapMonth {
+				d.Month = j
+				d.Leap = j == leapMonth
+			}
+			d.Day = rest + 1
+			break
+		}
+		rest -= length
+	}
+	return d, nil
+}
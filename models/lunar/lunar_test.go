@@ -0,0 +1,88 @@
+package lunar
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Dates published by KASI, including years where the Korean leap month
+// differs from the Chinese one.
+var conversions = []struct {
+	lunar Date
+	solar string
+}{
+	{Date{2023, 1, 1, false}, "2023-01-22"},
+	{Date{2024, 1, 1, false}, "2024-02-10"},
+	{Date{2023, 8, 15, false}, "2023-09-29"},
+	{Date{2024, 8, 15, false}, "2024-09-17"},
+	{Date{2023, 2, 1, false}, "2023-02-20"},
+	{Date{2023, 2, 1, true}, "2023-03-22"},
+	{Date{2023, 2, 29, true}, "2023-04-19"},
+	{Date{2023, 3, 1, false}, "2023-04-20"},
+	{Date{2020, 4, 1, true}, "2020-05-23"},
+	{Date{2012, 3, 1, true}, "2012-04-21"},
+	{Date{2017, 5, 1, true}, "2017-06-24"},
+	{Date{2025, 6, 1, true}, "2025-07-25"},
+}
+
+func TestToSolar(t *testing.T) {
+	for _, tt := range conversions {
+		got, err := ToSolar(tt.lunar)
+		if err != nil {
+			t.Errorf("ToSolar(%+v): %v", tt.lunar, err)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.solar {
+			t.Errorf("ToSolar(%+v) = %s, want %s", tt.lunar, got.Format("2006-01-02"), tt.solar)
+		}
+	}
+}
+
+func TestFromSolar(t *testing.T) {
+	for _, tt := range conversions {
+		solar, _ := time.Parse("2006-01-02", tt.solar)
+		got, err := FromSolar(solar)
+		if err != nil {
+			t.Errorf("FromSolar(%s): %v", tt.solar, err)
+			continue
+		}
+		if got != tt.lunar {
+			t.Errorf("FromSolar(%s) = %+v, want %+v", tt.solar, got, tt.lunar)
+		}
+	}
+}
+
+func TestLeapMonth(t *testing.T) {
+	tests := []struct {
+		year, want int
+	}{
+		{2012, 3}, {2017, 5}, {2020, 4}, {2023, 2}, {2024, 0}, {MaxYear + 1, 0},
+	}
+	for _, tt := range tests {
+		if got := LeapMonth(tt.year); got != tt.want {
+			t.Errorf("LeapMonth(%d) = %d, want %d", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		leap  bool
+		err   error
+	}{
+		{"2023-02-29", true, nil},
+		{"2023-02-30", true, ErrInvalidDate},
+		{"2024-02-01", true, ErrInvalidDate},
+		{"2023-13-01", false, ErrInvalidDate},
+		{"2023-2-1", false, ErrInvalidDate},
+		{"1899-01-01", false, ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.value, tt.leap)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q, %v) error = %v, want %v", tt.value, tt.leap, err, tt.err)
+		}
+	}
+}
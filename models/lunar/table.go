@@ -0,0 +1,43 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package lunar
+
+const (
+	MinYear = 1900
+	MaxYear = 2100
+)
+
+// firstNewYear is the solar date of 1/1 of MinYear as days since 1970-01-01.
+const firstNewYear = -25537 // 1900-01-31
+
+// years[i] describes lunar year MinYear+i. Bit j is set when the year's jth
+// month, counting a leap month in its place, has 30 days rather than 29;
+// bits 13-16 hold the number of the leap month, or 0.
+var years = [...]uint32{
+	0x116d2, 0x00752, 0x00ea5, 0x0ae4a, 0x0054b, 0x00a97, 0x09556, 0x0056a, // 1900
+	0x00b55, 0x05752, 0x00752, 0x0d725, 0x00b25, 0x00a4b, 0x0b29b, 0x00aad, // 1908
+	0x0056a, 0x04b69, 0x00ba9, 0x0fb52, 0x00d92, 0x00d25, 0x0ba4d, 0x00956, // 1916
+	0x002b5, 0x095ad, 0x006d4, 0x00da9, 0x05d92, 0x00e92, 0x0cd26, 0x00527, // 1924
+	0x00a57, 0x0b2b6, 0x00ada, 0x006d4, 0x06ea9, 0x00749, 0x0f693, 0x00a93, // 1932
+	0x0052b, 0x0ca5b, 0x0096d, 0x00b6a, 0x09b54, 0x00ba4, 0x00b49, 0x05a93, // 1940
+	0x00a95, 0x0f52b, 0x0052d, 0x00aad, 0x0b56a, 0x00db2, 0x00da4, 0x07d49, // 1948
+	0x00d4a, 0x11a95, 0x00a96, 0x00556, 0x0cab5, 0x00ad5, 0x006d2, 0x08ea5, // 1956
+	0x00ea5, 0x00e4a, 0x06c96, 0x00a9b, 0x0f556, 0x0056a, 0x00b59, 0x0b752, // 1964
+	0x00752, 0x00725, 0x0964b, 0x00a4b, 0x112ab, 0x002ad, 0x0056b, 0x0cb69, // 1972
+	0x00da9, 0x00d92, 0x09b25, 0x00d25, 0x15a4d, 0x00a56, 0x002b6, 0x0d5ad, // 1980
+	0x006d4, 0x00da9, 0x0bd92, 0x00e92, 0x00d26, 0x06a56, 0x00a57, 0x112b6, // 1988
+	0x00b5a, 0x006d4, 0x0aec9, 0x00749, 0x00693, 0x09527, 0x0052b, 0x00a5b, // 1996
+	0x0555a, 0x0036a, 0x0fb55, 0x00ba4, 0x00b49, 0x0ba93, 0x00a95, 0x0052d, // 2004
+	0x06a5d, 0x00aad, 0x135aa, 0x005d2, 0x00da5, 0x0bd4a, 0x00d4a, 0x00a95, // 2012
+	0x0952d, 0x00556, 0x00ab5, 0x055aa, 0x006d2, 0x0cea5, 0x00ea5, 0x00e4a, // 2020
+	0x0ac96, 0x00c9b, 0x0055a, 0x06ad5, 0x00b69, 0x17752, 0x00752, 0x00b25, // 2028
+	0x0d64b, 0x00a4b, 0x004ab, 0x0a55b, 0x0056d, 0x00b69, 0x05b52, 0x00d92, // 2036
+	0x0fd25, 0x00d25, 0x00a4d, 0x0b4ad, 0x002b6, 0x005b5, 0x06da9, 0x00ea9, // 2044
+	0x11d92, 0x00e92, 0x00d26, 0x0ca56, 0x00a57, 0x004d6, 0x086b5, 0x006d5, // 2052
+	0x00ec9, 0x06e92, 0x00693, 0x0f52b, 0x0052b, 0x00a5b, 0x0b55a, 0x0056a, // 2060
+	0x00b55, 0x09749, 0x00b49, 0x11a93, 0x00a95, 0x0052d, 0x0caad, 0x00ab5, // 2068
+	0x005aa, 0x08ba5, 0x00da5, 0x00d4a, 0x07a95, 0x00c95, 0x0f52e, 0x00556, // 2076
+	0x00ab5, 0x0b5b2, 0x006d2, 0x00ea5, 0x09e4a, 0x0064a, 0x10c97, 0x00cab, // 2084
+	0x0055a, 0x0cad5, 0x00b69, 0x00752, 0x096a5, 0x00b25, 0x0064b, 0x07497, // 2092
+	0x004ab, // 2100
+}
@@ -0,0 +1,392 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Roles a user can have on a D-Day, each allowing what the ones before it
+// do: viewers see it, editors also change it, and owners also delete it and
+// manage its members, invitations and share links. Its creator is always an
+// owner.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+// InvitationTTL is how long an invitation can be accepted.
+const InvitationTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrForbidden is returned, wrapped with the role needed, when userID can
+	// see a D-Day but their role does not allow the call.
+	ErrForbidden   = errors.New("insufficient role")
+	ErrCreatorRole = errors.New("the creator of a d-day always stays an owner")
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAllows reports whether role grants what need does.
+func RoleAllows(role, need string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[need]
+}
+
+func forbidden(need string) error {
+	return fmt.Errorf("%w: %s role required", ErrForbidden, need)
+}
+
+// Member is a user who can see a D-Day. The creator is listed as an owner
+// who joined when the D-Day was created.
+type Member struct {
+	UserID   string    `json:"user_id" db:"dm_user_id"`
+	Email    string    `json:"email" db:"u_email"`
+	Name     string    `json:"name" db:"u_name"`
+	Role     string    `json:"role" db:"dm_role"`
+	JoinedAt time.Time `json:"joined_at" db:"dm_created_at"`
+}
+
+// Invitation offers a role on a D-Day to whoever accepts it, by ID if it is
+// addressed to their email or by its code. Only the hash of the code is
+// kept, and accepting or declining deletes the invitation.
+type Invitation struct {
+	ID     int    `json:"id" db:"di_id"`
+	DdayID string `json:"dday_id" db:"di_dday_id"`
+	// DdayTitle is listed for the invitee, who cannot see the D-Day yet.
+	DdayTitle string    `json:"dday_title,omitempty" db:"-"`
+	Email     string    `json:"email,omitempty" db:"di_email"`
+	Role      string    `json:"role" db:"di_role"`
+	CodeHash  string    `json:"-" db:"di_code_hash"`
+	InvitedBy string    `json:"invited_by" db:"di_invited_by"`
+	ExpiresAt time.Time `json:"expires_at" db:"di_expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"di_created_at"`
+}
+
+// MemberStore keeps who besides its creator can see each D-Day, and the
+// invitations to join it.
+//
+// The methods taking a ddayID act on behalf of userID and return
+// sql.ErrNoRows when userID cannot see ddayID or it is in the trash, and
+// ErrForbidden when userID's role does not allow the call.
+type MemberStore interface {
+	// Members lists the D-Day's creator, then its members in the order they
+	// joined. Any member may list them.
+	Members(userID, ddayID string) ([]Member, error)
+	// SetRole changes memberID's role and needs the owner role. It returns
+	// sql.ErrNoRows when memberID is not a member.
+	SetRole(userID, ddayID, memberID, role string) error
+	// RemoveMember takes memberID off the D-Day. Owners may remove anyone,
+	// and any member may remove themselves to leave it. Both return
+	// ErrCreatorRole for the creator.
+	RemoveMember(userID, ddayID, memberID string) error
+
+	// Invite, Invitations and CancelInvitation need the owner role. Invite
+	// expires the invitation after InvitationTTL unless ExpiresAt is set.
+	Invite(userID, ddayID string, invitation *Invitation) error
+	// Invitations lists the D-Day's unexpired invitations, newest first.
+	Invitations(userID, ddayID string) ([]Invitation, error)
+	CancelInvitation(userID, ddayID string, id int) error
+
+	// Pending lists the unexpired invitations addressed to email, newest
+	// first.
+	Pending(email string) ([]Invitation, error)
+	// AcceptByID accepts invitation id addressed to user's email, and
+	// AcceptByCode the one whose code hashes to codeHash, which must be
+	// addressed to user's email if to anyone. Both make user a member with
+	// the invitation's role, never lowering the role they have, and return
+	// the invitation. Unknown, expired and misaddressed invitations, and
+	// those of trashed D-Days, give sql.ErrNoRows.
+	AcceptByID(user *User, id int) (*Invitation, error)
+	AcceptByCode(user *User, codeHash string) (*Invitation, error)
+	// Decline deletes invitation id addressed to email.
+	Decline(email string, id int) error
+}
+
+// Members is the MemberStore selected by InitDatabase.
+var Members MemberStore
+
+func NewMemberStore() MemberStore {
+	return Members
+}
+
+// MemberManager is the SQL MemberStore.
+type MemberManager struct {
+	Conn  *Connection
+	ddays *DdayManager
+}
+
+func NewMemberManager() *MemberManager {
+	return &MemberManager{Conn: DB, ddays: NewDdayManager()}
+}
+
+const invitationColumns = "di_id, di_dday_id, di_email, di_role, di_code_hash, di_invited_by, di_expires_at, di_created_at"
+
+// scanInvitation reads the invitationColumns of a row, followed by any
+// extra columns the query selected into extra.
+func scanInvitation(row rowScanner, extra ...interface{}) (Invitation, error) {
+	var invitation Invitation
+	var email sql.NullString
+	dest := []interface{}{&invitation.ID, &invitation.DdayID, &email, &invitation.Role, &invitation.CodeHash,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.CreatedAt}
+	err := row.Scan(append(dest, extra...)...)
+	invitation.Email = email.String
+	return invitation, err
+}
+
+func (m *MemberManager) Members(userID, ddayID string) ([]Member, error) {
+	dday, err := m.ddays.getByID(m.Conn, userID, ddayID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []Member{}
+	creator := Member{UserID: dday.UserID, Role: RoleOwner, JoinedAt: dday.CreatedAt}
+	err = m.Conn.QueryRow("SELECT u_email, u_name FROM users_tb WHERE u_id = ?", dday.UserID).Scan(&creator.Email, &creator.Name)
+	if err != nil {
+		return nil, err
+	}
+	members = append(members, creator)
+
+	query := `SELECT dm_user_id, u_email, u_name, dm_role, dm_created_at
+			  FROM dday_members_tb
+			  JOIN users_tb ON u_id = dm_user_id
+			  WHERE dm_dday_id = ?
+			  ORDER BY dm_created_at, dm_user_id`
+	rows, err := m.Conn.Query(query, ddayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member Member
+		if err := rows.Scan(&member.UserID, &member.Email, &member.Name, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (m *MemberManager) SetRole(userID, ddayID, memberID, role string) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		dday, err := m.ddays.authorize(tx, userID, ddayID, RoleOwner)
+		if err != nil {
+			return err
+		}
+		if memberID == dday.UserID {
+			return ErrCreatorRole
+		}
+
+		// MySQL counts changed rows, not matched ones, so check first.
+		var exists int
+		err = tx.QueryRow("SELECT 1 FROM dday_members_tb WHERE dm_dday_id = ? AND dm_user_id = ?", ddayID, memberID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE dday_members_tb SET dm_role = ? WHERE dm_dday_id = ? AND dm_user_id = ?", role, ddayID, memberID)
+		return err
+	})
+}
+
+func (m *MemberManager) RemoveMember(userID, ddayID, memberID string) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		dday, err := m.ddays.getByID(tx, userID, ddayID)
+		if err != nil {
+			return err
+		}
+		if memberID == dday.UserID {
+			return ErrCreatorRole
+		}
+		if memberID != userID && !RoleAllows(dday.Role, RoleOwner) {
+			return forbidden(RoleOwner)
+		}
+
+		return deleteOne(tx, "DELETE FROM dday_members_tb WHERE dm_dday_id = ? AND dm_user_id = ?", ddayID, memberID)
+	})
+}
+
+func (m *MemberManager) Invite(userID, ddayID string, invitation *Invitation) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if _, err := m.ddays.authorize(tx, userID, ddayID, RoleOwner); err != nil {
+			return err
+		}
+
+		invitation.DdayID = ddayID
+		invitation.InvitedBy = userID
+		invitation.CreatedAt = utcNow()
+		if invitation.ExpiresAt.IsZero() {
+			invitation.ExpiresAt = invitation.CreatedAt.Add(InvitationTTL)
+		}
+		query := `INSERT INTO dday_invitations_tb (di_dday_id, di_email, di_role, di_code_hash, di_invited_by, di_expires_at, di_created_at)
+				  VALUES (?, ?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, invitation.DdayID, nullString(invitation.Email), invitation.Role, invitation.CodeHash,
+			invitation.InvitedBy, invitation.ExpiresAt.UTC(), invitation.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		invitation.ID = int(id)
+		return err
+	})
+}
+
+func (m *MemberManager) Invitations(userID, ddayID string) ([]Invitation, error) {
+	if _, err := m.ddays.authorize(m.Conn, userID, ddayID, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + invitationColumns + `, d_title
+			  FROM dday_invitations_tb
+			  JOIN ddays_tb ON d_id = di_dday_id
+			  WHERE di_dday_id = ? AND di_expires_at > ?
+			  ORDER BY di_id DESC`
+	return m.selectInvitations(query, ddayID, utcNow())
+}
+
+func (m *MemberManager) CancelInvitation(userID, ddayID string, id int) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if _, err := m.ddays.authorize(tx, userID, ddayID, RoleOwner); err != nil {
+			return err
+		}
+		return deleteOne(tx, "DELETE FROM dday_invitations_tb WHERE di_id = ? AND di_dday_id = ?", id, ddayID)
+	})
+}
+
+func (m *MemberManager) Pending(email string) ([]Invitation, error) {
+	query := `SELECT ` + invitationColumns + `, d_title
+			  FROM dday_invitations_tb
+			  JOIN ddays_tb ON d_id = di_dday_id
+			  WHERE di_email = ? AND di_expires_at > ? AND d_deleted_at IS NULL
+			  ORDER BY di_id DESC`
+	return m.selectInvitations(query, email, utcNow())
+}
+
+// selectInvitations runs query, which selects the invitationColumns and
+// d_title.
+func (m *MemberManager) selectInvitations(query string, args ...interface{}) ([]Invitation, error) {
+	rows, err := m.Conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		var title string
+		invitation, err := scanInvitation(rows, &title)
+		if err != nil {
+			return nil, err
+		}
+		invitation.DdayTitle = title
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+func (m *MemberManager) AcceptByID(user *User, id int) (*Invitation, error) {
+	return m.accept(user, "di_id = ? AND di_email = ?", id, user.Email)
+}
+
+func (m *MemberManager) AcceptByCode(user *User, codeHash string) (*Invitation, error) {
+	return m.accept(user, "di_code_hash = ?", codeHash)
+}
+
+// accept takes up the invitation matching condition for user.
+func (m *MemberManager) accept(user *User, condition string, args ...interface{}) (*Invitation, error) {
+	var invitation Invitation
+	err := m.ddays.inTx(func(tx *sql.Tx) error {
+		query := `SELECT ` + invitationColumns + `, d_title, d_user_id
+				  FROM dday_invitations_tb
+				  JOIN ddays_tb ON d_id = di_dday_id
+				  WHERE ` + condition + ` AND di_expires_at > ? AND d_deleted_at IS NULL`
+		var title string
+		var creator sql.NullString
+		var err error
+		invitation, err = scanInvitation(tx.QueryRow(query, append(args, utcNow())...), &title, &creator)
+		if err != nil {
+			return err
+		}
+		invitation.DdayTitle = title
+		if invitation.Email != "" && invitation.Email != user.Email {
+			return sql.ErrNoRows
+		}
+
+		if _, err := tx.Exec("DELETE FROM dday_invitations_tb WHERE di_id = ?", invitation.ID); err != nil {
+			return err
+		}
+		if creator.String == user.ID {
+			return nil
+		}
+
+		var role string
+		err = tx.QueryRow("SELECT dm_role FROM dday_members_tb WHERE dm_dday_id = ? AND dm_user_id = ?", invitation.DdayID, user.ID).Scan(&role)
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec("INSERT INTO dday_members_tb (dm_dday_id, dm_user_id, dm_role, dm_created_at) VALUES (?, ?, ?, ?)",
+				invitation.DdayID, user.ID, invitation.Role, utcNow())
+		case err == nil && !RoleAllows(role, invitation.Role):
+			_, err = tx.Exec("UPDATE dday_members_tb SET dm_role = ? WHERE dm_dday_id = ? AND dm_user_id = ?",
+				invitation.Role, invitation.DdayID, user.ID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (m *MemberManager) Decline(email string, id int) error {
+	return deleteOne(m.Conn, "DELETE FROM dday_invitations_tb WHERE di_id = ? AND di_email = ?", id, email)
+}
+
+// loadRoles sets userID's role on ddays: owner of those they created, and
+// their membership role, looked up per lookupBatchSize D-Days, on the rest.
+func (m *DdayManager) loadRoles(q querier, userID string, ddays []DDay) error {
+	index := make(map[string]int)
+	var shared []interface{}
+	for i := range ddays {
+		if ddays[i].UserID == userID {
+			ddays[i].Role = RoleOwner
+			continue
+		}
+		index[ddays[i].ID] = i
+		shared = append(shared, ddays[i].ID)
+	}
+
+	for start := 0; start < len(shared); start += lookupBatchSize {
+		batch := shared[start:min(start+lookupBatchSize, len(shared))]
+		query := "SELECT dm_dday_id, dm_role FROM dday_members_tb WHERE dm_user_id = ? AND dm_dday_id IN (" + placeholders(len(batch)) + ")"
+		if err := scanPairs(q, query, append([]interface{}{userID}, batch...), func(id, role string) {
+			if i, ok := index[id]; ok {
+				ddays[i].Role = role
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOne runs a DELETE, returning sql.ErrNoRows when it removed nothing.
+func deleteOne(q querier, query string, args ...interface{}) error {
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// MemoryMemberStore is the in-memory MemberStore. It shares its memoryDB with
+// the MemoryDdayStore that owns the D-Days.
+type MemoryMemberStore struct {
+	db    *memoryDB
+	ddays *MemoryDdayStore
+}
+
+func newMemoryMemberStore(db *memoryDB) *MemoryMemberStore {
+	return &MemoryMemberStore{db: db, ddays: &MemoryDdayStore{db: db}}
+}
+
+func (m *MemoryMemberStore) Members(userID, ddayID string) ([]Member, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	dday, err := m.ddays.getByID(userID, ddayID)
+	if err != nil {
+		return nil, err
+	}
+
+	creator, ok := m.db.users[dday.UserID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	members := []Member{{UserID: creator.ID, Email: creator.Email, Name: creator.Name, Role: RoleOwner, JoinedAt: dday.CreatedAt}}
+
+	var joined []Member
+	for key, member := range m.db.members {
+		if key.ddayID == dday.ID {
+			user := m.db.users[key.userID]
+			member.Email, member.Name = user.Email, user.Name
+			joined = append(joined, member)
+		}
+	}
+	sort.Slice(joined, func(i, j int) bool {
+		if !joined[i].JoinedAt.Equal(joined[j].JoinedAt) {
+			return joined[i].JoinedAt.Before(joined[j].JoinedAt)
+		}
+		return joined[i].UserID < joined[j].UserID
+	})
+	return append(members, joined...), nil
+}
+
+func (m *MemoryMemberStore) SetRole(userID, ddayID, memberID, role string) error {
+	return m.db.inTx(func() error {
+		dday, err := m.ddays.authorize(userID, ddayID, RoleOwner)
+		if err != nil {
+			return err
+		}
+		if memberID == dday.UserID {
+			return ErrCreatorRole
+		}
+
+		key := memberKey{dday.ID, memberID}
+		member, ok := m.db.members[key]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		// Reassigning rewrites the key, so use the stored, cloned user ID
+		// rather than the route param.
+		member.Role = role
+		setRow(m.db, m.db.members, memberKey{dday.ID, member.UserID}, member)
+		return nil
+	})
+}
+
+func (m *MemoryMemberStore) RemoveMember(userID, ddayID, memberID string) error {
+	return m.db.inTx(func() error {
+		dday, err := m.ddays.getByID(userID, ddayID)
+		if err != nil {
+			return err
+		}
+		if memberID == dday.UserID {
+			return ErrCreatorRole
+		}
+		if memberID != userID && !RoleAllows(dday.Role, RoleOwner) {
+			return forbidden(RoleOwner)
+		}
+
+		key := memberKey{dday.ID, memberID}
+		if _, ok := m.db.members[key]; !ok {
+			return sql.ErrNoRows
+		}
+		deleteRow(m.db, m.db.members, key)
+		return nil
+	})
+}
+
+func (m *MemoryMemberStore) Invite(userID, ddayID string, invitation *Invitation) error {
+	return m.db.inTx(func() error {
+		dday, err := m.ddays.authorize(userID, ddayID, RoleOwner)
+		if err != nil {
+			return err
+		}
+
+		invitation.ID = m.db.nextID(&m.db.nextInviteID)
+		invitation.DdayID = ddayID
+		invitation.InvitedBy = userID
+		invitation.CreatedAt = utcNow()
+		if invitation.ExpiresAt.IsZero() {
+			invitation.ExpiresAt = invitation.CreatedAt.Add(InvitationTTL)
+		}
+		stored := *invitation
+		stored.DdayID = dday.ID
+		stored.DdayTitle = ""
+		stored.Email = strings.Clone(invitation.Email)
+		stored.InvitedBy = strings.Clone(userID)
+		setRow(m.db, m.db.invitations, stored.ID, stored)
+		return nil
+	})
+}
+
+func (m *MemoryMemberStore) Invitations(userID, ddayID string) ([]Invitation, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, err := m.ddays.authorize(userID, ddayID, RoleOwner); err != nil {
+		return nil, err
+	}
+	return m.live(func(invitation Invitation) bool {
+		return invitation.DdayID == ddayID
+	}), nil
+}
+
+func (m *MemoryMemberStore) CancelInvitation(userID, ddayID string, id int) error {
+	return m.db.inTx(func() error {
+		if _, err := m.ddays.authorize(userID, ddayID, RoleOwner); err != nil {
+			return err
+		}
+		invitation, ok := m.db.invitations[id]
+		if !ok || invitation.DdayID != ddayID {
+			return sql.ErrNoRows
+		}
+		deleteRow(m.db, m.db.invitations, id)
+		return nil
+	})
+}
+
+func (m *MemoryMemberStore) Pending(email string) ([]Invitation, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	return m.live(func(invitation Invitation) bool {
+		return invitation.Email != "" && invitation.Email == email
+	}), nil
+}
+
+// live lists the unexpired invitations of live D-Days that keep selects,
+// newest first, with their D-Day's title.
+func (m *MemoryMemberStore) live(keep func(Invitation) bool) []Invitation {
+	now := utcNow()
+	invitations := []Invitation{}
+	for _, invitation := range m.db.invitations {
+		dday, ok := m.db.ddays[invitation.DdayID]
+		if !ok || dday.DeletedAt != nil || !invitation.ExpiresAt.After(now) || !keep(invitation) {
+			continue
+		}
+		invitation.DdayTitle = dday.Title
+		invitations = append(invitations, invitation)
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].ID > invitations[j].ID
+	})
+	return invitations
+}
+
+func (m *MemoryMemberStore) AcceptByID(user *User, id int) (*Invitation, error) {
+	return m.accept(user, func(invitation Invitation) bool {
+		return invitation.ID == id && invitation.Email != ""
+	})
+}
+
+func (m *MemoryMemberStore) AcceptByCode(user *User, codeHash string) (*Invitation, error) {
+	return m.accept(user, func(invitation Invitation) bool {
+		return codeHash != "" && invitation.CodeHash == codeHash
+	})
+}
+
+// accept mirrors MemberManager.accept for the invitation match selects.
+func (m *MemoryMemberStore) accept(user *User, match func(Invitation) bool) (*Invitation, error) {
+	var accepted *Invitation
+	err := m.db.inTx(func() error {
+		found := m.live(match)
+		if len(found) == 0 {
+			return sql.ErrNoRows
+		}
+		invitation := found[0]
+		if invitation.Email != "" && invitation.Email != user.Email {
+			return sql.ErrNoRows
+		}
+
+		deleteRow(m.db, m.db.invitations, invitation.ID)
+		accepted = &invitation
+		dday := m.db.ddays[invitation.DdayID]
+		if dday.UserID == user.ID {
+			return nil
+		}
+
+		key := memberKey{dday.ID, strings.Clone(user.ID)}
+		member, ok := m.db.members[key]
+		if !ok {
+			member = Member{UserID: key.userID, JoinedAt: utcNow()}
+		}
+		if !RoleAllows(member.Role, invitation.Role) {
+			member.Role = invitation.Role
+		}
+		setRow(m.db, m.db.members, key, member)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accepted, nil
+}
+
+func (m *MemoryMemberStore) Decline(email string, id int) error {
+	return m.db.inTx(func() error {
+		invitation, ok := m.db.invitations[id]
+		if !ok || invitation.Email == "" || invitation.Email != email {
+			return sql.ErrNoRows
+		}
+		deleteRow(m.db, m.db.invitations, id)
+		return nil
+	})
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notifications_tb;
+DROP TABLE IF EXISTS users_tb;
+DROP TABLE IF EXISTS categories_tb;
+DROP TABLE IF EXISTS ddays_tb;
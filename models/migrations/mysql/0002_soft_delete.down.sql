@@ -0,0 +1,2 @@
+DROP INDEX idx_d_deleted_at ON ddays_tb;
+ALTER TABLE ddays_tb DROP COLUMN d_deleted_at;
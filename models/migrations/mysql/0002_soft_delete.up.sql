@@ -0,0 +1,2 @@
+ALTER TABLE ddays_tb ADD COLUMN d_deleted_at TIMESTAMP NULL DEFAULT NULL;
+CREATE INDEX idx_d_deleted_at ON ddays_tb (d_deleted_at);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS dday_revisions_tb (
+    r_id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    r_dday_id VARCHAR(36) NOT NULL,
+    r_revision INT NOT NULL,
+    r_action VARCHAR(16) NOT NULL,
+    r_actor VARCHAR(100) NOT NULL,
+    r_snapshot MEDIUMTEXT NOT NULL,
+    r_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uq_r_dday_revision (r_dday_id, r_revision),
+    FOREIGN KEY (r_dday_id) REFERENCES ddays_tb(d_id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- 기존 D-Day마다 현재 상태를 1번 리비전으로 남겨 이후 diff와 되돌리기의 기준으로 삼는다.
+INSERT INTO dday_revisions_tb (r_dday_id, r_revision, r_action, r_actor, r_snapshot, r_created_at)
+SELECT d_id, 1, 'create', 'migration',
+    CONCAT('{"title":', JSON_QUOTE(d_title),
+        ',"target_date":"', DATE_FORMAT(d_target_date, '%Y-%m-%d'),
+        '","category":', JSON_QUOTE(d_category),
+        ',"memo":', JSON_QUOTE(COALESCE(d_memo, '')),
+        ',"is_important":', IF(d_is_important, 'true', 'false'), '}'),
+    d_created_at
+FROM ddays_tb;
@@ -0,0 +1 @@
+ALTER TABLE ddays_tb DROP INDEX ft_d_title_memo;
@@ -0,0 +1,2 @@
+-- 제목/메모 전문 검색 인덱스. 한글은 띄어쓰기 단위로 나누면 조사가 붙어 검색되지 않으므로 ngram 파서를 사용한다.
+ALTER TABLE ddays_tb ADD FULLTEXT INDEX ft_d_title_memo (d_title, d_memo) WITH PARSER ngram;
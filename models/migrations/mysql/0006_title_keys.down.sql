@@ -0,0 +1,4 @@
+ALTER TABLE ddays_tb
+    DROP INDEX idx_d_title_chosung,
+    DROP COLUMN d_title_jamo,
+    DROP COLUMN d_title_chosung;
@@ -0,0 +1,6 @@
+-- 초성/자모 검색용 제목 키. 한글 분해는 애플리케이션에서 하므로 기존 행은 마이그레이션 직후 채운다.
+-- 자모끼리 정확히 비교하도록 바이너리 콜레이션을 사용한다.
+ALTER TABLE ddays_tb
+    ADD COLUMN d_title_chosung VARCHAR(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NULL AFTER d_title,
+    ADD COLUMN d_title_jamo TEXT CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NULL AFTER d_title_chosung,
+    ADD INDEX idx_d_title_chosung (d_title_chosung);
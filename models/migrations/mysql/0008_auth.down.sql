@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS refresh_tokens_tb;
+ALTER TABLE users_tb DROP COLUMN u_password_hash;
@@ -0,0 +1,4 @@
+ALTER TABLE ddays_tb
+    DROP FOREIGN KEY fk_d_user_id,
+    DROP INDEX idx_d_user_id,
+    DROP COLUMN d_user_id;
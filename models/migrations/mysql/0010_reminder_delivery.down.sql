@@ -0,0 +1,3 @@
+ALTER TABLE notifications_tb
+    DROP INDEX uq_n_dday_days_before,
+    DROP COLUMN n_sent_on;
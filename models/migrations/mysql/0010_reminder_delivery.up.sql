@@ -0,0 +1,5 @@
+-- 알림을 마지막으로 보낸 날짜. 스케줄러는 이 값을 오늘로 바꾸는 데 성공했을 때만 발송하므로
+-- 서버가 재시작되거나 여러 대가 떠 있어도 같은 날 두 번 보내지 않는다.
+ALTER TABLE notifications_tb
+    ADD COLUMN n_sent_on DATE NULL DEFAULT NULL AFTER n_is_active,
+    ADD UNIQUE KEY uq_n_dday_days_before (n_dday_id, n_days_before);
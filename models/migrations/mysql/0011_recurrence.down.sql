@@ -0,0 +1,3 @@
+ALTER TABLE ddays_tb
+    DROP COLUMN d_recurrence_interval,
+    DROP COLUMN d_recurrence;
@@ -0,0 +1,5 @@
+-- 반복 D-Day. d_target_date가 첫 번째 발생일이고, d_recurrence 단위(daily/weekly/monthly/yearly)로
+-- d_recurrence_interval마다 반복된다. 'none'은 한 번뿐인 D-Day다.
+ALTER TABLE ddays_tb
+    ADD COLUMN d_recurrence VARCHAR(10) NOT NULL DEFAULT 'none' AFTER d_is_important,
+    ADD COLUMN d_recurrence_interval INT NOT NULL DEFAULT 1 AFTER d_recurrence;
@@ -0,0 +1,4 @@
+ALTER TABLE ddays_tb
+    DROP COLUMN d_is_leap_month,
+    DROP COLUMN d_lunar_date,
+    DROP COLUMN d_calendar_type;
@@ -0,0 +1,6 @@
+-- 음력 D-Day. d_lunar_date는 음력 날짜(YYYY-MM-DD, 30일이 있을 수 있어 문자열로 저장)이고
+-- d_is_leap_month는 윤달 여부다. d_target_date에는 환산한 양력 날짜를 함께 저장한다.
+ALTER TABLE ddays_tb
+    ADD COLUMN d_calendar_type VARCHAR(5) NOT NULL DEFAULT 'solar' AFTER d_recurrence_interval,
+    ADD COLUMN d_lunar_date VARCHAR(10) NULL DEFAULT NULL AFTER d_calendar_type,
+    ADD COLUMN d_is_leap_month BOOLEAN NOT NULL DEFAULT FALSE AFTER d_lunar_date;
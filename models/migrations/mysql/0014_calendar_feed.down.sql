@@ -0,0 +1,3 @@
+ALTER TABLE users_tb
+    DROP INDEX uq_u_feed_token_hash,
+    DROP COLUMN u_feed_token_hash;
@@ -0,0 +1,4 @@
+-- 캘린더 앱이 구독하는 .ics 피드 URL의 비밀 토큰. 리프레시 토큰처럼 SHA-256 해시만 저장하며, NULL이면 피드가 없다.
+ALTER TABLE users_tb
+    ADD COLUMN u_feed_token_hash CHAR(64) NULL DEFAULT NULL AFTER u_timezone,
+    ADD UNIQUE KEY uq_u_feed_token_hash (u_feed_token_hash);
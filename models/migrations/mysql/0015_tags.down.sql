@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dday_tags_tb;
+DROP TABLE IF EXISTS tags_tb;
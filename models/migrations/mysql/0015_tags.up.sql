@@ -0,0 +1,21 @@
+-- 태그는 사용자별로 관리하며 이름은 소문자로 저장한다. 이름 비교가 Go와 같도록 바이너리 콜레이션을 쓴다.
+CREATE TABLE IF NOT EXISTS tags_tb (
+    t_id INT AUTO_INCREMENT PRIMARY KEY,
+    t_user_id VARCHAR(36) NOT NULL,
+    t_name VARCHAR(50) COLLATE utf8mb4_bin NOT NULL,
+    t_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uq_t_user_name (t_user_id, t_name),
+    FOREIGN KEY (t_user_id) REFERENCES users_tb(u_id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- D-Day와 태그의 다대다 연결. D-Day나 태그가 지워지면 연결도 함께 지워진다.
+CREATE TABLE IF NOT EXISTS dday_tags_tb (
+    dt_dday_id VARCHAR(36) NOT NULL,
+    dt_tag_id INT NOT NULL,
+
+    PRIMARY KEY (dt_dday_id, dt_tag_id),
+    INDEX idx_dt_tag_id (dt_tag_id),
+    FOREIGN KEY (dt_dday_id) REFERENCES ddays_tb(d_id) ON DELETE CASCADE,
+    FOREIGN KEY (dt_tag_id) REFERENCES tags_tb(t_id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
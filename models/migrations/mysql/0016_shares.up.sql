@@ -0,0 +1,17 @@
+-- 계정 없는 사람에게 D-Day 하나를 보여주는 공유 링크. 토큰은 SHA-256 해시만, 비밀번호는 bcrypt 해시만 저장하며
+-- 비밀번호가 없으면 빈 문자열이다. 폐기하거나 만료된 링크도 조회수와 함께 남겨 둔다.
+CREATE TABLE IF NOT EXISTS shares_tb (
+    sh_id INT AUTO_INCREMENT PRIMARY KEY,
+    sh_dday_id VARCHAR(36) NOT NULL,
+    sh_token_hash CHAR(64) NOT NULL,
+    sh_password_hash VARCHAR(255) NOT NULL DEFAULT '',
+    sh_show_memo BOOLEAN DEFAULT FALSE,
+    sh_view_count INT NOT NULL DEFAULT 0,
+    sh_expires_at TIMESTAMP NULL DEFAULT NULL,
+    sh_revoked_at TIMESTAMP NULL DEFAULT NULL,
+    sh_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uq_sh_token_hash (sh_token_hash),
+    INDEX idx_sh_dday_id (sh_dday_id),
+    FOREIGN KEY (sh_dday_id) REFERENCES ddays_tb(d_id) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dday_invitations_tb;
+DROP TABLE IF EXISTS dday_members_tb;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS notifications_tb;
+DROP TRIGGER IF EXISTS trg_users_tb_updated_at;
+DROP TABLE IF EXISTS users_tb;
+DROP TABLE IF EXISTS categories_tb;
+DROP TRIGGER IF EXISTS trg_ddays_tb_updated_at;
+DROP TABLE IF EXISTS ddays_tb;
@@ -0,0 +1,61 @@
+-- MySQL 0001_init과 같은 스키마. SQLite에는 인라인 INDEX와 ON UPDATE가 없어
+-- 인덱스는 별도로 만들고 *_updated_at은 트리거로 갱신한다.
+
+CREATE TABLE IF NOT EXISTS ddays_tb (
+    d_id VARCHAR(36) PRIMARY KEY,
+    d_title VARCHAR(255) NOT NULL,
+    d_target_date DATE NOT NULL,
+    d_category VARCHAR(50) NOT NULL DEFAULT '개인',
+    d_memo TEXT,
+    d_is_important BOOLEAN DEFAULT FALSE,
+    d_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    d_updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_d_target_date ON ddays_tb (d_target_date);
+CREATE INDEX IF NOT EXISTS idx_d_category ON ddays_tb (d_category);
+CREATE INDEX IF NOT EXISTS idx_d_is_important ON ddays_tb (d_is_important);
+CREATE INDEX IF NOT EXISTS idx_d_created_at ON ddays_tb (d_created_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_ddays_tb_updated_at AFTER UPDATE ON ddays_tb
+FOR EACH ROW WHEN NEW.d_updated_at = OLD.d_updated_at
+BEGIN
+    UPDATE ddays_tb SET d_updated_at = CURRENT_TIMESTAMP WHERE d_id = NEW.d_id;
+END;
+
+CREATE TABLE IF NOT EXISTS categories_tb (
+    cat_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cat_name VARCHAR(50) NOT NULL UNIQUE,
+    cat_color VARCHAR(7),
+    cat_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO categories_tb (cat_name, cat_color) VALUES
+    ('개인', '#007bff'),
+    ('학업', '#28a745'),
+    ('업무', '#ffc107'),
+    ('기타', '#6c757d');
+
+CREATE TABLE IF NOT EXISTS users_tb (
+    u_id VARCHAR(36) PRIMARY KEY,
+    u_email VARCHAR(255) UNIQUE NOT NULL,
+    u_name VARCHAR(100) NOT NULL,
+    u_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    u_updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_users_tb_updated_at AFTER UPDATE ON users_tb
+FOR EACH ROW WHEN NEW.u_updated_at = OLD.u_updated_at
+BEGIN
+    UPDATE users_tb SET u_updated_at = CURRENT_TIMESTAMP WHERE u_id = NEW.u_id;
+END;
+
+CREATE TABLE IF NOT EXISTS notifications_tb (
+    n_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    n_dday_id VARCHAR(36) NOT NULL REFERENCES ddays_tb(d_id) ON DELETE CASCADE,
+    n_days_before INT NOT NULL,
+    n_is_active BOOLEAN DEFAULT TRUE,
+    n_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_n_dday_id ON notifications_tb (n_dday_id);
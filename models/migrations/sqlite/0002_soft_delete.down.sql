@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_d_deleted_at;
+ALTER TABLE ddays_tb DROP COLUMN d_deleted_at;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dday_revisions_tb;
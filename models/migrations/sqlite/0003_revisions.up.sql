@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS dday_revisions_tb (
+    r_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    r_dday_id VARCHAR(36) NOT NULL REFERENCES ddays_tb(d_id) ON DELETE CASCADE,
+    r_revision INT NOT NULL,
+    r_action VARCHAR(16) NOT NULL,
+    r_actor VARCHAR(100) NOT NULL,
+    r_snapshot TEXT NOT NULL,
+    r_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (r_dday_id, r_revision)
+);
+
+-- 기존 D-Day마다 현재 상태를 1번 리비전으로 남겨 이후 diff와 되돌리기의 기준으로 삼는다.
+INSERT INTO dday_revisions_tb (r_dday_id, r_revision, r_action, r_actor, r_snapshot, r_created_at)
+SELECT d_id, 1, 'create', 'migration',
+    json_object('title', d_title,
+        'target_date', substr(d_target_date, 1, 10),
+        'category', d_category,
+        'memo', COALESCE(d_memo, ''),
+        'is_important', json(CASE WHEN d_is_important THEN 'true' ELSE 'false' END)),
+    d_created_at
+FROM ddays_tb;
@@ -0,0 +1 @@
+ALTER TABLE ddays_tb DROP COLUMN d_version;
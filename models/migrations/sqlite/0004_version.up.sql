@@ -0,0 +1 @@
+ALTER TABLE ddays_tb ADD COLUMN d_version INT NOT NULL DEFAULT 1;
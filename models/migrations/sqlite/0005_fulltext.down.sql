@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_ddays_fts_delete;
+DROP TRIGGER IF EXISTS trg_ddays_fts_update;
+DROP TRIGGER IF EXISTS trg_ddays_fts_insert;
+DROP TABLE IF EXISTS ddays_fts;
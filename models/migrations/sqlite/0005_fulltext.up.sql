@@ -0,0 +1,23 @@
+-- 제목/메모 전문 검색 인덱스. MySQL의 ngram 파서 대신 FTS5 trigram 토크나이저로 한글 부분 일치를 지원한다.
+CREATE VIRTUAL TABLE IF NOT EXISTS ddays_fts USING fts5(d_id UNINDEXED, d_title, d_memo, tokenize='trigram');
+
+INSERT INTO ddays_fts (d_id, d_title, d_memo)
+SELECT d_id, d_title, COALESCE(d_memo, '') FROM ddays_tb;
+
+CREATE TRIGGER IF NOT EXISTS trg_ddays_fts_insert
+AFTER INSERT ON ddays_tb
+BEGIN
+    INSERT INTO ddays_fts (d_id, d_title, d_memo) VALUES (NEW.d_id, NEW.d_title, COALESCE(NEW.d_memo, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_ddays_fts_update
+AFTER UPDATE OF d_title, d_memo ON ddays_tb
+BEGIN
+    UPDATE ddays_fts SET d_title = NEW.d_title, d_memo = COALESCE(NEW.d_memo, '') WHERE d_id = OLD.d_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_ddays_fts_delete
+AFTER DELETE ON ddays_tb
+BEGIN
+    DELETE FROM ddays_fts WHERE d_id = OLD.d_id;
+END;
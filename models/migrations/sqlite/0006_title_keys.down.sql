@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_d_title_chosung;
+ALTER TABLE ddays_tb DROP COLUMN d_title_jamo;
+ALTER TABLE ddays_tb DROP COLUMN d_title_chosung;
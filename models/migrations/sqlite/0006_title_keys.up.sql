@@ -0,0 +1,4 @@
+-- 초성/자모 검색용 제목 키. 한글 분해는 애플리케이션에서 하므로 기존 행은 마이그레이션 직후 채운다.
+ALTER TABLE ddays_tb ADD COLUMN d_title_chosung VARCHAR(255);
+ALTER TABLE ddays_tb ADD COLUMN d_title_jamo TEXT;
+CREATE INDEX IF NOT EXISTS idx_d_title_chosung ON ddays_tb (d_title_chosung);
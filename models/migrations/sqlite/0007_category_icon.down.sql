@@ -0,0 +1 @@
+ALTER TABLE categories_tb DROP COLUMN cat_icon;
@@ -0,0 +1 @@
+ALTER TABLE categories_tb ADD COLUMN cat_icon VARCHAR(50);
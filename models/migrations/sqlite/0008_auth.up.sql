@@ -0,0 +1,12 @@
+ALTER TABLE users_tb ADD COLUMN u_password_hash VARCHAR(255) NOT NULL DEFAULT '';
+
+-- 리프레시 토큰은 원문 대신 SHA-256 해시만 저장한다. 갱신할 때마다 새 토큰을 발급하고 이전 토큰은 폐기한다.
+CREATE TABLE IF NOT EXISTS refresh_tokens_tb (
+    rt_token_hash CHAR(64) PRIMARY KEY,
+    rt_user_id VARCHAR(36) NOT NULL REFERENCES users_tb(u_id) ON DELETE CASCADE,
+    rt_expires_at TIMESTAMP NOT NULL,
+    rt_revoked_at TIMESTAMP NULL DEFAULT NULL,
+    rt_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rt_user_id ON refresh_tokens_tb (rt_user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_d_user_id;
+ALTER TABLE ddays_tb DROP COLUMN d_user_id;
@@ -0,0 +1,4 @@
+-- D-Day마다 소유자를 둔다. 계정이 생기기 전에 만든 행은 NULL로 남으며 어느 사용자에게도 보이지 않는다.
+-- 마이그레이션 직후 DB_ASSIGN_OWNERLESS_TO 계정에 배정하거나 `migrate assign-owner <email>`로 배정한다.
+ALTER TABLE ddays_tb ADD COLUMN d_user_id VARCHAR(36) REFERENCES users_tb(u_id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_d_user_id ON ddays_tb (d_user_id);
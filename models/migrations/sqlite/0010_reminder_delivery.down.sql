@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_n_dday_days_before;
+ALTER TABLE notifications_tb DROP COLUMN n_sent_on;
@@ -0,0 +1,2 @@
+ALTER TABLE ddays_tb DROP COLUMN d_recurrence_interval;
+ALTER TABLE ddays_tb DROP COLUMN d_recurrence;
@@ -0,0 +1,3 @@
+ALTER TABLE ddays_tb DROP COLUMN d_is_leap_month;
+ALTER TABLE ddays_tb DROP COLUMN d_lunar_date;
+ALTER TABLE ddays_tb DROP COLUMN d_calendar_type;
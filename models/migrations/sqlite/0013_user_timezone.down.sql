@@ -0,0 +1 @@
+ALTER TABLE users_tb DROP COLUMN u_timezone;
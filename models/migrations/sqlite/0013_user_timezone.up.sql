@@ -0,0 +1,2 @@
+-- 사용자가 날짜를 세는 IANA 시간대(예: Asia/Seoul). 빈 값이면 서버의 TIMEZONE을 따른다.
+ALTER TABLE users_tb ADD COLUMN u_timezone VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_u_feed_token_hash;
+ALTER TABLE users_tb DROP COLUMN u_feed_token_hash;
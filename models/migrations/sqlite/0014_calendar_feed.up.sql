@@ -0,0 +1,3 @@
+-- 캘린더 앱이 구독하는 .ics 피드 URL의 비밀 토큰. 리프레시 토큰처럼 SHA-256 해시만 저장하며, NULL이면 피드가 없다.
+ALTER TABLE users_tb ADD COLUMN u_feed_token_hash CHAR(64) NULL DEFAULT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_u_feed_token_hash ON users_tb (u_feed_token_hash);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_dt_tag_id;
+DROP TABLE IF EXISTS dday_tags_tb;
+DROP TABLE IF EXISTS tags_tb;
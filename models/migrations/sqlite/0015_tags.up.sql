@@ -0,0 +1,19 @@
+-- 태그는 사용자별로 관리하며 이름은 소문자로 저장한다.
+CREATE TABLE IF NOT EXISTS tags_tb (
+    t_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    t_user_id VARCHAR(36) NOT NULL REFERENCES users_tb(u_id) ON DELETE CASCADE,
+    t_name VARCHAR(50) NOT NULL,
+    t_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (t_user_id, t_name)
+);
+
+-- D-Day와 태그의 다대다 연결. D-Day나 태그가 지워지면 연결도 함께 지워진다.
+CREATE TABLE IF NOT EXISTS dday_tags_tb (
+    dt_dday_id VARCHAR(36) NOT NULL REFERENCES ddays_tb(d_id) ON DELETE CASCADE,
+    dt_tag_id INTEGER NOT NULL REFERENCES tags_tb(t_id) ON DELETE CASCADE,
+
+    PRIMARY KEY (dt_dday_id, dt_tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dt_tag_id ON dday_tags_tb (dt_tag_id);
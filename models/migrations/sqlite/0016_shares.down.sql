@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sh_dday_id;
+DROP TABLE IF EXISTS shares_tb;
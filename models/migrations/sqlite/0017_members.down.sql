@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_di_email;
+DROP INDEX IF EXISTS idx_di_dday_id;
+DROP TABLE IF EXISTS dday_invitations_tb;
+DROP INDEX IF EXISTS idx_dm_user_id;
+DROP TABLE IF EXISTS dday_members_tb;
@@ -0,0 +1,28 @@
+-- 여러 사용자가 함께 보는 D-Day의 구성원. D-Day를 만든 사용자(d_user_id)는 행 없이 항상 owner이며,
+-- 역할은 viewer(조회), editor(수정), owner(삭제·구성원 관리) 순으로 권한이 넓어진다.
+CREATE TABLE IF NOT EXISTS dday_members_tb (
+    dm_dday_id VARCHAR(36) NOT NULL REFERENCES ddays_tb(d_id) ON DELETE CASCADE,
+    dm_user_id VARCHAR(36) NOT NULL REFERENCES users_tb(u_id) ON DELETE CASCADE,
+    dm_role VARCHAR(10) NOT NULL,
+    dm_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (dm_dday_id, dm_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dm_user_id ON dday_members_tb (dm_user_id);
+
+-- 구성원 초대. 초대 코드는 SHA-256 해시만 저장하며 수락하거나 거절하면 삭제한다.
+-- di_email이 있으면 그 이메일의 사용자만 수락할 수 있고, NULL이면 코드를 아는 누구나 수락할 수 있다.
+CREATE TABLE IF NOT EXISTS dday_invitations_tb (
+    di_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    di_dday_id VARCHAR(36) NOT NULL REFERENCES ddays_tb(d_id) ON DELETE CASCADE,
+    di_email VARCHAR(255) NULL DEFAULT NULL,
+    di_role VARCHAR(10) NOT NULL,
+    di_code_hash CHAR(64) NOT NULL UNIQUE,
+    di_invited_by VARCHAR(36) NOT NULL REFERENCES users_tb(u_id) ON DELETE CASCADE,
+    di_expires_at TIMESTAMP NOT NULL,
+    di_created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_di_dday_id ON dday_invitations_tb (di_dday_id);
+CREATE INDEX IF NOT EXISTS idx_di_email ON dday_invitations_tb (di_email);
@@ -0,0 +1,335 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MIMEJSONPatch is the media type of RFC 6902 JSON Patch documents. Other
+// bodies of a PATCH are read as RFC 7396 merge patches.
+const MIMEJSONPatch = "application/json-patch+json"
+
+var (
+	ErrInvalidPatch       = errors.New("invalid patch")
+	ErrPatchTestFailed    = errors.New("patch test failed")
+	ErrTitleRequired      = errors.New("title is required")
+	ErrTargetDateRequired = errors.New("target date is required")
+	ErrInvalidTargetDate  = errors.New("invalid target date")
+)
+
+// DdayPatch is a partial update of a D-Day: only its non-nil fields are
+// written. A non-zero Version makes it conditional, as for Update.
+type DdayPatch struct {
+	Title              *string
+	TargetDate         *string
+	Category           *string
+	Memo               *string
+	IsImportant        *bool
+	Recurrence         *string
+	RecurrenceInterval *int
+	CalendarType       *string
+	LunarDate          *string
+	IsLeapMonth        *bool
+	Tags               *[]string
+	Version            int
+}
+
+// patchFields are the JSON names of the fields a patch may set.
+var patchFields = map[string]bool{
+	"title": true, "target_date": true, "category": true, "memo": true,
+	"is_important": true, "recurrence": true, "recurrence_interval": true,
+	"calendar_type": true, "lunar_date": true, "is_leap_month": true,
+	"tags": true,
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (p *DdayPatch) IsEmpty() bool {
+	return p.Title == nil && p.TargetDate == nil && p.Category == nil && p.Memo == nil &&
+		p.IsImportant == nil && p.Recurrence == nil && p.RecurrenceInterval == nil &&
+		p.CalendarType == nil && p.LunarDate == nil && p.IsLeapMonth == nil && p.Tags == nil
+}
+
+// Apply copies the patch's fields onto dday.
+func (p *DdayPatch) Apply(dday *DDay) {
+	if p.Title != nil {
+		dday.Title = *p.Title
+	}
+	if p.TargetDate != nil {
+		dday.TargetDate = *p.TargetDate
+	}
+	if p.Category != nil {
+		dday.Category = *p.Category
+	}
+	if p.Memo != nil {
+		dday.Memo = *p.Memo
+	}
+	if p.IsImportant != nil {
+		dday.IsImportant = *p.IsImportant
+	}
+	if p.Recurrence != nil {
+		dday.Recurrence = *p.Recurrence
+	}
+	if p.RecurrenceInterval != nil {
+		dday.RecurrenceInterval = *p.RecurrenceInterval
+	}
+	if p.CalendarType != nil {
+		dday.CalendarType = *p.CalendarType
+	}
+	if p.LunarDate != nil {
+		dday.LunarDate = *p.LunarDate
+	}
+	if p.IsLeapMonth != nil {
+		dday.IsLeapMonth = *p.IsLeapMonth
+	}
+	if p.Tags != nil {
+		dday.Tags = *p.Tags
+	}
+}
+
+// MergePatchFields reads an RFC 7396 merge patch, returning the top-level
+// fields it sets. A null value removes the field, that is, resets it.
+func MergePatchFields(body []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("%w: body must be a JSON object", ErrInvalidPatch)
+	}
+	return fields, nil
+}
+
+// jsonPatchOp is one operation of an RFC 6902 JSON Patch.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatchFields applies an RFC 6902 JSON Patch to the JSON form of current
+// and returns the top-level fields it changed, like MergePatchFields. Paths
+// name top-level fields only; a failed test operation returns
+// ErrPatchTestFailed.
+func JSONPatchFields(current *DDay, body []byte) (map[string]json.RawMessage, error) {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("%w: body must be an array of operations", ErrInvalidPatch)
+	}
+
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	set := func(field string, value json.RawMessage) {
+		doc[field], fields[field] = value, value
+	}
+	null := json.RawMessage("null")
+
+	for i, op := range ops {
+		field, err := patchPath(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+		value, present := doc[field]
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d has no value", ErrInvalidPatch, i)
+			}
+			set(field, op.Value)
+		case "remove":
+			set(field, null)
+		case "copy", "move":
+			from, err := patchPath(op.From)
+			if err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+			}
+			set(field, doc[from])
+			if op.Op == "move" && from != field {
+				set(from, null)
+			}
+		case "test":
+			if !present {
+				value = null
+			}
+			if !sameJSON(value, op.Value) {
+				return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, op.Path)
+			}
+		default:
+			return nil, fmt.Errorf("%w: operation %d has unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+	}
+	return fields, nil
+}
+
+// patchPath reads a JSON Pointer to a top-level field.
+func patchPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") || strings.Count(path, "/") != 1 {
+		return "", fmt.Errorf("path %q must name a top-level field", path)
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(path[1:]), nil
+}
+
+// sameJSON compares two JSON values structurally.
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// NewDdayPatch validates the fields a patch sets on current and builds the
+// patch that writes them. Only the supplied fields are checked, with the
+// rules CreateDday applies, except that a category is not checked against
+// the store. Null resets a field to its default; title and target_date have
+// none and cannot be removed.
+//
+// Recurrence and calendar fields depend on each other, so a patch that sets
+// any of them is checked against the merged D-Day, and also writes the
+// fields ResolveCalendar derives, such as a lunar D-Day's target date.
+func NewDdayPatch(current *DDay, fields map[string]json.RawMessage) (*DdayPatch, error) {
+	patch := &DdayPatch{}
+	for name, value := range fields {
+		if !patchFields[name] {
+			return nil, fmt.Errorf("%w: field %q cannot be patched", ErrInvalidPatch, name)
+		}
+		if err := patch.set(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Title != nil {
+		title := strings.TrimSpace(*patch.Title)
+		if title == "" {
+			return nil, ErrTitleRequired
+		}
+		patch.Title = &title
+	}
+	if patch.Memo != nil {
+		memo := strings.TrimSpace(*patch.Memo)
+		patch.Memo = &memo
+	}
+	if patch.Tags != nil {
+		tags, err := NormalizeTags(*patch.Tags)
+		if err != nil {
+			return nil, err
+		}
+		patch.Tags = &tags
+	}
+
+	if patch.TargetDate == nil && patch.Recurrence == nil && patch.RecurrenceInterval == nil &&
+		patch.CalendarType == nil && patch.LunarDate == nil && patch.IsLeapMonth == nil {
+		return patch, nil
+	}
+
+	merged := *current
+	patch.Apply(&merged)
+
+	// A lunar D-Day's target date is computed from its lunar date.
+	if patch.TargetDate != nil && merged.CalendarType != CalendarLunar {
+		if *patch.TargetDate == "" {
+			return nil, ErrTargetDateRequired
+		}
+		if _, err := time.Parse(dateLayout, *patch.TargetDate); err != nil {
+			return nil, ErrInvalidTargetDate
+		}
+	}
+
+	recurrence, interval, err := ParseRecurrence(merged.Recurrence, merged.RecurrenceInterval)
+	if err != nil {
+		return nil, err
+	}
+	merged.Recurrence, merged.RecurrenceInterval = recurrence, interval
+	if err := merged.ResolveCalendar(); err != nil {
+		return nil, err
+	}
+
+	// Write the derived fields that the patch set or that changed.
+	if patch.Recurrence != nil || merged.Recurrence != current.Recurrence {
+		patch.Recurrence = &merged.Recurrence
+	}
+	if patch.RecurrenceInterval != nil || merged.RecurrenceInterval != current.RecurrenceInterval {
+		patch.RecurrenceInterval = &merged.RecurrenceInterval
+	}
+	if patch.TargetDate != nil || merged.TargetDate != current.TargetDate {
+		patch.TargetDate = &merged.TargetDate
+	}
+	if patch.CalendarType != nil || merged.CalendarType != current.CalendarType {
+		patch.CalendarType = &merged.CalendarType
+	}
+	if patch.LunarDate != nil || merged.LunarDate != current.LunarDate {
+		patch.LunarDate = &merged.LunarDate
+	}
+	if patch.IsLeapMonth != nil || merged.IsLeapMonth != current.IsLeapMonth {
+		patch.IsLeapMonth = &merged.IsLeapMonth
+	}
+	return patch, nil
+}
+
+// set decodes one field of a patch; null stands for the field's default.
+func (p *DdayPatch) set(name string, value json.RawMessage) error {
+	isNull := string(bytes.TrimSpace(value)) == "null"
+
+	var err error
+	decode := func(dest interface{}) {
+		if !isNull {
+			err = json.Unmarshal(value, dest)
+		}
+	}
+	switch name {
+	case "title":
+		if isNull {
+			return ErrTitleRequired
+		}
+		p.Title = new(string)
+		decode(p.Title)
+	case "target_date":
+		if isNull {
+			return ErrTargetDateRequired
+		}
+		p.TargetDate = new(string)
+		decode(p.TargetDate)
+	case "category":
+		p.Category = new(string)
+		decode(p.Category)
+	case "memo":
+		p.Memo = new(string)
+		decode(p.Memo)
+	case "is_important":
+		p.IsImportant = new(bool)
+		decode(p.IsImportant)
+	case "recurrence":
+		p.Recurrence = new(string)
+		decode(p.Recurrence)
+	case "recurrence_interval":
+		p.RecurrenceInterval = new(int)
+		decode(p.RecurrenceInterval)
+	case "calendar_type":
+		p.CalendarType = new(string)
+		decode(p.CalendarType)
+	case "lunar_date":
+		p.LunarDate = new(string)
+		decode(p.LunarDate)
+	case "is_leap_month":
+		p.IsLeapMonth = new(bool)
+		decode(p.IsLeapMonth)
+	case "tags":
+		p.Tags = &[]string{}
+		decode(p.Tags)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s has the wrong type", ErrInvalidPatch, name)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func patchTarget() *DDay {
+	return &DDay{
+		ID: "d1", Title: "생일", TargetDate: "2024-05-01", Category: "개인", Memo: "케이크",
+		Recurrence: RecurrenceYearly, RecurrenceInterval: 1, CalendarType: CalendarSolar,
+		Tags: []string{"family"}, Version: 3,
+	}
+}
+
+// rawFields renders fields the way the patch readers return them, for
+// comparison.
+func rawFields(fields map[string]json.RawMessage) map[string]string {
+	rendered := make(map[string]string, len(fields))
+	for name, value := range fields {
+		rendered[name] = string(value)
+	}
+	return rendered
+}
+
+func TestMergePatchFields(t *testing.T) {
+	fields, err := MergePatchFields([]byte(`{"memo": null, "is_important": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"memo": "null", "is_important": "true"}; !reflect.DeepEqual(rawFields(fields), want) {
+		t.Errorf("fields = %v, want %v", rawFields(fields), want)
+	}
+
+	for _, body := range []string{`[]`, `null`, `"memo"`, `{`} {
+		if _, err := MergePatchFields([]byte(body)); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("MergePatchFields(%s) error = %v, want ErrInvalidPatch", body, err)
+		}
+	}
+}
+
+func TestJSONPatchFields(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]string
+		err  error
+	}{
+		{
+			name: "replace and remove",
+			body: `[{"op": "replace", "path": "/title", "value": "엄마 생일"}, {"op": "remove", "path": "/memo"}]`,
+			want: map[string]string{"title": `"엄마 생일"`, "memo": "null"},
+		},
+		{
+			name: "test passes",
+			body: `[{"op": "test", "path": "/version", "value": 3}, {"op": "add", "path": "/is_important", "value": true}]`,
+			want: map[string]string{"is_important": "true"},
+		},
+		{
+			name: "test compares structurally",
+			body: `[{"op": "test", "path": "/tags", "value": [ "family" ]}]`,
+			want: map[string]string{},
+		},
+		{
+			name: "test of an absent field matches null",
+			body: `[{"op": "test", "path": "/deleted_at", "value": null}]`,
+			want: map[string]string{},
+		},
+		{
+			name: "copy and move",
+			body: `[{"op": "copy", "from": "/title", "path": "/memo"}, {"op": "move", "from": "/category", "path": "/title"}]`,
+			want: map[string]string{"memo": `"생일"`, "title": `"개인"`, "category": "null"},
+		},
+		{
+			name: "failed test",
+			body: `[{"op": "replace", "path": "/memo", "value": "x"}, {"op": "test", "path": "/title", "value": "다른 제목"}]`,
+			err:  ErrPatchTestFailed,
+		},
+		{
+			name: "test sees earlier operations",
+			body: `[{"op": "replace", "path": "/memo", "value": "x"}, {"op": "test", "path": "/memo", "value": "케이크"}]`,
+			err:  ErrPatchTestFailed,
+		},
+		{
+			name: "nested path",
+			body: `[{"op": "replace", "path": "/tags/0", "value": "x"}]`,
+			err:  ErrInvalidPatch,
+		},
+		{
+			name: "missing value",
+			body: `[{"op": "add", "path": "/memo"}]`,
+			err:  ErrInvalidPatch,
+		},
+		{
+			name: "unknown op",
+			body: `[{"op": "increment", "path": "/version"}]`,
+			err:  ErrInvalidPatch,
+		},
+		{
+			name: "not an array",
+			body: `{"op": "remove", "path": "/memo"}`,
+			err:  ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := JSONPatchFields(patchTarget(), []byte(tt.body))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(rawFields(fields), tt.want) {
+				t.Errorf("fields = %v, want %v", rawFields(fields), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDdayPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, patch *DdayPatch)
+		err   error
+	}{
+		{
+			name: "trims and leaves the rest alone",
+			body: `{"title": "  엄마 생일 ", "memo": null}`,
+			check: func(t *testing.T, patch *DdayPatch) {
+				if *patch.Title != "엄마 생일" || *patch.Memo != "" {
+					t.Errorf("title, memo = %q, %q", *patch.Title, *patch.Memo)
+				}
+				if patch.TargetDate != nil || patch.Recurrence != nil || patch.Tags != nil {
+					t.Errorf("patch sets fields it was not given: %+v", patch)
+				}
+			},
+		},
+		{
+			name: "normalizes tags",
+			body: `{"tags": [" Travel", "family", "travel"]}`,
+			check: func(t *testing.T, patch *DdayPatch) {
+				if want := []string{"family", "travel"}; !reflect.DeepEqual(*patch.Tags, want) {
+					t.Errorf("tags = %v, want %v", *patch.Tags, want)
+				}
+			},
+		},
+		{
+			name: "null recurrence makes a one-off",
+			body: `{"recurrence": null}`,
+			check: func(t *testing.T, patch *DdayPatch) {
+				if *patch.Recurrence != RecurrenceNone || patch.RecurrenceInterval != nil {
+					t.Errorf("recurrence, interval = %q, %v", *patch.Recurrence, patch.RecurrenceInterval)
+				}
+			},
+		},
+		{
+			name: "null interval defaults to 1",
+			body: `{"recurrence": "monthly", "recurrence_interval": null}`,
+			check: func(t *testing.T, patch *DdayPatch) {
+				if *patch.Recurrence != RecurrenceMonthly || *patch.RecurrenceInterval != 1 {
+					t.Errorf("recurrence = %q every %d", *patch.Recurrence, *patch.RecurrenceInterval)
+				}
+			},
+		},
+		{
+			name: "switching to lunar derives the target date",
+			body: `{"calendar_type": "lunar", "lunar_date": "2024-01-01"}`,
+			check: func(t *testing.T, patch *DdayPatch) {
+				if patch.TargetDate == nil || *patch.TargetDate != "2024-02-10" {
+					t.Errorf("target date = %v, want 2024-02-10", patch.TargetDate)
+				}
+			},
+		},
+		{name: "null title", body: `{"title": null}`, err: ErrTitleRequired},
+		{name: "blank title", body: `{"title": " "}`, err: ErrTitleRequired},
+		{name: "null target date", body: `{"target_date": null}`, err: ErrTargetDateRequired},
+		{name: "bad target date", body: `{"target_date": "2024-02-30"}`, err: ErrInvalidTargetDate},
+		{name: "wrong type", body: `{"is_important": "yes"}`, err: ErrInvalidPatch},
+		{name: "read-only field", body: `{"version": 9}`, err: ErrInvalidPatch},
+		{name: "bad recurrence", body: `{"recurrence_interval": 0, "recurrence": "hourly"}`, err: ErrInvalidRecurrence},
+		{name: "bad tags", body: `{"tags": ["a,b"]}`, err: ErrInvalidTags},
+		{
+			name: "lunar checked against the merged recurrence",
+			body: `{"calendar_type": "lunar", "lunar_date": "2024-01-01", "recurrence": "monthly"}`,
+			err:  ErrLunarRecurrence,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := MergePatchFields([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch, err := NewDdayPatch(patchTarget(), fields)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.check != nil {
+				tt.check(t, patch)
+			}
+		})
+	}
+}
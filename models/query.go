@@ -0,0 +1,378 @@
+package models
+
+import (
+	"dday-backend/models/hangul"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Field names a D-Day attribute that queries may filter or sort on. Values
+// match the JSON field names, except for the derived title keys.
+type Field string
+
+const (
+	FieldID          Field = "id"
+	FieldTitle       Field = "title"
+	FieldTargetDate  Field = "target_date"
+	FieldCategory    Field = "category"
+	FieldMemo        Field = "memo"
+	FieldIsImportant Field = "is_important"
+	FieldCreatedAt   Field = "created_at"
+	FieldUpdatedAt   Field = "updated_at"
+	FieldDeletedAt   Field = "deleted_at"
+
+	// FieldTitleChosung is the title with each Hangul syllable reduced to its
+	// initial consonant and FieldTitleJamo the title spelled out as jamo; see
+	// package hangul. Operands must be converted the same way.
+	FieldTitleChosung Field = "title_chosung"
+	FieldTitleJamo    Field = "title_jamo"
+)
+
+type Operator string
+
+const (
+	OpEq      Operator = "eq"
+	OpNe      Operator = "ne"
+	OpLt      Operator = "lt"
+	OpLte     Operator = "lte"
+	OpGt      Operator = "gt"
+	OpGte     Operator = "gte"
+	OpIn      Operator = "in"
+	OpBetween Operator = "between"
+	// OpLike matches values containing the operand as a substring; SQL
+	// wildcards in the operand are matched literally.
+	OpLike Operator = "like"
+	// OpWordPrefix matches values with a space-separated word starting with
+	// the operand.
+	OpWordPrefix Operator = "word_prefix"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindDate
+	kindBool
+	kindTime
+)
+
+type fieldSpec struct {
+	column   string
+	kind     fieldKind
+	ops      []Operator
+	sortable bool
+}
+
+var (
+	stringOps  = []Operator{OpEq, OpNe, OpIn, OpLike}
+	orderedOps = []Operator{OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIn, OpBetween}
+	rangeOps   = []Operator{OpLt, OpLte, OpGt, OpGte, OpBetween}
+	keyOps     = []Operator{OpLike, OpWordPrefix}
+)
+
+// ddayFields is the whitelist of queryable D-Day fields.
+var ddayFields = map[Field]fieldSpec{
+	FieldID:          {column: "d_id", kind: kindString, ops: []Operator{OpEq, OpIn}, sortable: true},
+	FieldTitle:       {column: "d_title", kind: kindString, ops: stringOps, sortable: true},
+	FieldTargetDate:  {column: "d_target_date", kind: kindDate, ops: orderedOps, sortable: true},
+	FieldCategory:    {column: "d_category", kind: kindString, ops: stringOps, sortable: true},
+	FieldMemo:        {column: "d_memo", kind: kindString, ops: []Operator{OpLike}},
+	FieldIsImportant: {column: "d_is_important", kind: kindBool, ops: []Operator{OpEq, OpNe}, sortable: true},
+	FieldCreatedAt:   {column: "d_created_at", kind: kindTime, ops: rangeOps, sortable: true},
+	FieldUpdatedAt:   {column: "d_updated_at", kind: kindTime, ops: rangeOps, sortable: true},
+	FieldDeletedAt:   {column: "d_deleted_at", kind: kindTime, ops: rangeOps, sortable: true},
+
+	FieldTitleChosung: {column: "d_title_chosung", kind: kindString, ops: keyOps},
+	FieldTitleJamo:    {column: "d_title_jamo", kind: kindString, ops: keyOps},
+}
+
+// Trash selects how soft-deleted rows are treated; queries default to
+// ExcludeTrashed.
+type Trash int
+
+const (
+	ExcludeTrashed Trash = iota
+	IncludeTrashed
+	OnlyTrashed
+)
+
+type Filter struct {
+	Field  Field
+	Op     Operator
+	Values []interface{}
+}
+
+type Sort struct {
+	Field Field
+	Desc  bool
+}
+
+// DefaultSort is applied when a query names no ordering.
+var DefaultSort = Sort{Field: FieldTargetDate}
+
+type Paging struct {
+	Page     int
+	PageSize int
+}
+
+// pageBounds returns the slice bounds of page p within n sorted rows; an
+// unset paging spans all of them.
+func pageBounds(n int, p Paging) (int, int) {
+	if p.Page <= 0 || p.PageSize <= 0 {
+		return 0, n
+	}
+	start := (p.Page - 1) * p.PageSize
+	if start > n {
+		start = n
+	}
+	end := start + p.PageSize
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// Query is a validated D-Day query. It can only be produced by
+// QueryBuilder.Build, so stores may trust every field and operator in it.
+type Query struct {
+	filters []Filter
+	search  []string
+	fuzzy   string
+	status  string
+	today   time.Time
+	tags    []string
+	tagMode string
+	trash   Trash
+	sort    Sort
+	paging  Paging
+	cursor  *Cursor
+}
+
+func (q *Query) Sort() Sort {
+	return q.sort
+}
+
+// inGo reports whether the SQL store must finish q in Go after narrowing
+// the rows in SQL: fuzzy matches are ranked by edit distance, and whether a
+// recurring D-Day is upcoming or today depends on its next occurrence.
+func (q *Query) inGo() bool {
+	return q.fuzzy != "" || q.status == StatusUpcoming || q.status == StatusToday
+}
+
+type QueryBuilder struct {
+	query Query
+	err   error
+}
+
+func NewQuery() *QueryBuilder {
+	return &QueryBuilder{query: Query{sort: DefaultSort}}
+}
+
+// Where adds a filter. in takes one or more values, between exactly two and
+// every other operator exactly one.
+func (b *QueryBuilder) Where(field Field, op Operator, values ...interface{}) *QueryBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	spec, ok := ddayFields[field]
+	if !ok {
+		b.err = fmt.Errorf("%w: unknown field %q", ErrInvalidQuery, field)
+		return b
+	}
+	if !spec.allows(op) {
+		b.err = fmt.Errorf("%w: operator %q is not supported on %s", ErrInvalidQuery, op, field)
+		return b
+	}
+
+	switch op {
+	case OpIn:
+		if len(values) == 0 {
+			b.err = fmt.Errorf("%w: %s in needs at least one value", ErrInvalidQuery, field)
+			return b
+		}
+	case OpBetween:
+		if len(values) != 2 {
+			b.err = fmt.Errorf("%w: %s between needs two values", ErrInvalidQuery, field)
+			return b
+		}
+	default:
+		if len(values) != 1 {
+			b.err = fmt.Errorf("%w: %s %s needs one value", ErrInvalidQuery, field, op)
+			return b
+		}
+	}
+
+	for _, value := range values {
+		if err := spec.check(value); err != nil {
+			b.err = fmt.Errorf("%w: %s: %v", ErrInvalidQuery, field, err)
+			return b
+		}
+	}
+
+	b.query.filters = append(b.query.filters, Filter{Field: field, Op: op, Values: values})
+	return b
+}
+
+// Search keeps D-Days whose title or memo contains every whitespace-separated
+// term of keyword, ignoring case.
+func (b *QueryBuilder) Search(keyword string) *QueryBuilder {
+	b.query.search = searchTerms(keyword)
+	return b
+}
+
+// Fuzzy keeps D-Days whose title contains keyword within a small number of
+// jamo edits, closest matches first. It cannot be combined with a cursor.
+func (b *QueryBuilder) Fuzzy(keyword string) *QueryBuilder {
+	b.query.fuzzy = hangul.Decompose(strings.TrimSpace(keyword))
+	return b
+}
+
+// Status keeps D-Days with the given Status* on today, a date at midnight
+// UTC.
+func (b *QueryBuilder) Status(status string, today time.Time) *QueryBuilder {
+	if b.err != nil {
+		return b
+	}
+	if !IsStatus(status) {
+		b.err = fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, status)
+		return b
+	}
+	b.query.status = status
+	b.query.today = today
+	return b
+}
+
+// Tags keeps D-Days carrying any or, with TagModeAll, every one of tags.
+func (b *QueryBuilder) Tags(tags []string, mode string) *QueryBuilder {
+	if b.err != nil {
+		return b
+	}
+	if mode != TagModeAny && mode != TagModeAll {
+		b.err = fmt.Errorf("%w: unknown tag mode %q", ErrInvalidQuery, mode)
+		return b
+	}
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		b.err = fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		return b
+	}
+	b.query.tags = normalized
+	b.query.tagMode = mode
+	return b
+}
+
+func (b *QueryBuilder) Trashed(trash Trash) *QueryBuilder {
+	b.query.trash = trash
+	return b
+}
+
+func (b *QueryBuilder) OrderBy(field Field, desc bool) *QueryBuilder {
+	if b.err != nil {
+		return b
+	}
+	if spec, ok := ddayFields[field]; !ok || !spec.sortable {
+		b.err = fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, field)
+		return b
+	}
+	b.query.sort = Sort{Field: field, Desc: desc}
+	return b
+}
+
+// Page selects offset pagination; non-positive values leave results unpaged.
+func (b *QueryBuilder) Page(page, pageSize int) *QueryBuilder {
+	b.query.paging = Paging{Page: page, PageSize: pageSize}
+	return b
+}
+
+// After selects keyset pagination; see Cursor.
+func (b *QueryBuilder) After(after *CursorKey, limit int) *QueryBuilder {
+	b.query.cursor = &Cursor{After: after, Limit: limit}
+	return b
+}
+
+func (b *QueryBuilder) Build() (*Query, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.query.cursor != nil && b.query.fuzzy != "" {
+		return nil, fmt.Errorf("%w: fuzzy matches cannot be paged with a cursor", ErrInvalidQuery)
+	}
+	if c := b.query.cursor; c != nil && c.After != nil && !c.After.Matches(b.query.sort) {
+		return nil, fmt.Errorf("%w: cursor does not match the requested ordering", ErrInvalidCursor)
+	}
+	query := b.query
+	return &query, nil
+}
+
+func (s fieldSpec) allows(op Operator) bool {
+	for _, allowed := range s.ops {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (s fieldSpec) check(value interface{}) error {
+	switch s.kind {
+	case kindString:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected a string, got %T", value)
+		}
+	case kindDate:
+		date, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a YYYY-MM-DD string, got %T", value)
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("expected a YYYY-MM-DD string, got %q", date)
+		}
+	case kindBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected a bool, got %T", value)
+		}
+	case kindTime:
+		if _, ok := value.(time.Time); !ok {
+			return fmt.Errorf("expected a time, got %T", value)
+		}
+	}
+	return nil
+}
+
+// fieldValue reads field from a D-Day, with a zero time for a nil
+// DeletedAt so every value of a field shares one Go type.
+func fieldValue(d DDay, field Field) interface{} {
+	switch field {
+	case FieldID:
+		return d.ID
+	case FieldTitle:
+		return d.Title
+	case FieldTargetDate:
+		return d.TargetDate
+	case FieldCategory:
+		return d.Category
+	case FieldMemo:
+		return d.Memo
+	case FieldIsImportant:
+		return d.IsImportant
+	case FieldCreatedAt:
+		return d.CreatedAt
+	case FieldUpdatedAt:
+		return d.UpdatedAt
+	case FieldDeletedAt:
+		if d.DeletedAt == nil {
+			return time.Time{}
+		}
+		return *d.DeletedAt
+	case FieldTitleChosung:
+		return hangul.Choseong(d.Title)
+	case FieldTitleJamo:
+		return hangul.Decompose(d.Title)
+	}
+	return nil
+}
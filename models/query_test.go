@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryBuilderWhere(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  Field
+		op     Operator
+		values []interface{}
+		err    error
+	}{
+		{name: "title like", field: FieldTitle, op: OpLike, values: []interface{}{"생일"}},
+		{name: "category in", field: FieldCategory, op: OpIn, values: []interface{}{"개인", "업무"}},
+		{name: "target date between", field: FieldTargetDate, op: OpBetween, values: []interface{}{"2024-01-01", "2024-12-31"}},
+		{name: "created after", field: FieldCreatedAt, op: OpGt, values: []interface{}{time.Now()}},
+		{name: "unknown field", field: "d_title", op: OpEq, values: []interface{}{"x"}, err: ErrInvalidQuery},
+		{name: "user scoping is not a field", field: "user_id", op: OpEq, values: []interface{}{"u1"}, err: ErrInvalidQuery},
+		{name: "memo only supports like", field: FieldMemo, op: OpEq, values: []interface{}{"x"}, err: ErrInvalidQuery},
+		{name: "no ordering on bools", field: FieldIsImportant, op: OpLt, values: []interface{}{true}, err: ErrInvalidQuery},
+		{name: "in without values", field: FieldCategory, op: OpIn, err: ErrInvalidQuery},
+		{name: "between one value", field: FieldTargetDate, op: OpBetween, values: []interface{}{"2024-01-01"}, err: ErrInvalidQuery},
+		{name: "eq two values", field: FieldTitle, op: OpEq, values: []interface{}{"a", "b"}, err: ErrInvalidQuery},
+		{name: "bad date", field: FieldTargetDate, op: OpEq, values: []interface{}{"2024-13-01"}, err: ErrInvalidQuery},
+		{name: "wrong type", field: FieldIsImportant, op: OpEq, values: []interface{}{"true"}, err: ErrInvalidQuery},
+		{name: "time as string", field: FieldUpdatedAt, op: OpLt, values: []interface{}{"2024-01-01"}, err: ErrInvalidQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := NewQuery().Where(tt.field, tt.op, tt.values...).Build()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if err == nil && len(query.filters) != 1 {
+				t.Errorf("filters = %v, want one", query.filters)
+			}
+		})
+	}
+}
+
+func TestQueryBuilderKeepsFirstError(t *testing.T) {
+	_, err := NewQuery().
+		Where("nope", OpEq, "x").
+		Where(FieldTitle, OpEq, "생일").
+		OrderBy(FieldTitle, false).
+		Build()
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestQueryBuilderOrderBy(t *testing.T) {
+	query, err := NewQuery().Build()
+	if err != nil || query.Sort() != DefaultSort {
+		t.Fatalf("default sort = %v, %v; want %v", query.Sort(), err, DefaultSort)
+	}
+
+	query, err = NewQuery().OrderBy(FieldUpdatedAt, true).Build()
+	if want := (Sort{Field: FieldUpdatedAt, Desc: true}); err != nil || query.Sort() != want {
+		t.Errorf("sort = %v, %v; want %v", query.Sort(), err, want)
+	}
+
+	for _, field := range []Field{FieldMemo, FieldTitleChosung, FieldTitleJamo, "d_target_date", "target_date; DROP TABLE"} {
+		if _, err := NewQuery().OrderBy(field, false).Build(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("OrderBy(%q) error = %v, want ErrInvalidQuery", field, err)
+		}
+	}
+}
+
+func TestQueryBuilderStatusAndTags(t *testing.T) {
+	today := mustDate("2024-05-01")
+	if _, err := NewQuery().Status(StatusUpcoming, today).Build(); err != nil {
+		t.Errorf("Status(%q) error = %v", StatusUpcoming, err)
+	}
+	if _, err := NewQuery().Status("soon", today).Build(); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Status(soon) error = %v, want ErrInvalidQuery", err)
+	}
+
+	query, err := NewQuery().Tags([]string{"Travel", " family", "travel"}, TagModeAll).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(query.tags) != 2 || query.tagMode != TagModeAll {
+		t.Errorf("tags, mode = %v, %q; want two tags, %q", query.tags, query.tagMode, TagModeAll)
+	}
+	if _, err := NewQuery().Tags([]string{"travel"}, "some").Build(); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Tags mode some error = %v, want ErrInvalidQuery", err)
+	}
+	if _, err := NewQuery().Tags([]string{"a,b"}, TagModeAny).Build(); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Tags(a,b) error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestQueryBuilderCursor(t *testing.T) {
+	key := CursorKeyOf(DDay{ID: "d1", Title: "생일"}, Sort{Field: FieldTitle})
+
+	if _, err := NewQuery().OrderBy(FieldTitle, false).After(&key, 10).Build(); err != nil {
+		t.Errorf("matching cursor error = %v", err)
+	}
+	if _, err := NewQuery().After(nil, 10).Build(); err != nil {
+		t.Errorf("first page error = %v", err)
+	}
+	if _, err := NewQuery().OrderBy(FieldTitle, true).After(&key, 10).Build(); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("reversed cursor error = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := NewQuery().After(&key, 10).Build(); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor under the default sort error = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := NewQuery().Fuzzy("생일").After(nil, 10).Build(); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("fuzzy cursor error = %v, want ErrInvalidQuery", err)
+	}
+}
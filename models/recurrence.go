@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Recurrence frequencies. A recurring D-Day's TargetDate is its first
+// occurrence; it then repeats every RecurrenceInterval days, weeks, months or
+// years.
+//
+// Monthly and yearly rules stay anchored to the first occurrence's day of the
+// month and fall back to the last day of shorter months: a D-Day on Jan 31
+// recurs on Feb 28 (29 in leap years), Mar 31, Apr 30, and one on Feb 29
+// recurs on Feb 28 in common years. Lunar D-Days recur yearly on their lunar
+// date instead (see lunarAnniversary).
+const (
+	RecurrenceNone    = "none"
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+	RecurrenceYearly  = "yearly"
+)
+
+const (
+	MaxRecurrenceInterval = 999
+	dateLayout            = "2006-01-02"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+// ParseRecurrence validates a recurrence rule from a client, defaulting an
+// empty frequency to RecurrenceNone and a zero interval to 1.
+func ParseRecurrence(frequency string, interval int) (string, int, error) {
+	if frequency == "" {
+		frequency = RecurrenceNone
+	}
+	if interval == 0 {
+		interval = 1
+	}
+
+	switch frequency {
+	case RecurrenceNone:
+		return frequency, 1, nil
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+	default:
+		return "", 0, ErrInvalidRecurrence
+	}
+	if interval < 1 || interval > MaxRecurrenceInterval {
+		return "", 0, ErrInvalidRecurrence
+	}
+	return frequency, interval, nil
+}
+
+// IsRecurring reports whether the D-Day repeats.
+func (d *DDay) IsRecurring() bool {
+	return d.Recurrence != "" && d.Recurrence != RecurrenceNone
+}
+
+// OccursOn reports whether day is one of the D-Day's occurrences.
+func (d *DDay) OccursOn(day time.Time) bool {
+	start, err := time.Parse(dateLayout, d.TargetDate)
+	if err != nil {
+		return false
+	}
+	next, ok := d.nextOccurrence(start, day)
+	return ok && next.Equal(day)
+}
+
+// Occurrences lists up to limit occurrences between from and to, inclusive.
+// The second result reports whether more occurrences fall in the range.
+func (d *DDay) Occurrences(from, to time.Time, limit int) ([]time.Time, bool) {
+	start, err := time.Parse(dateLayout, d.TargetDate)
+	if err != nil {
+		return nil, false
+	}
+
+	var occurrences []time.Time
+	next, ok := d.nextOccurrence(start, from)
+	for ok && !next.After(to) {
+		if len(occurrences) == limit {
+			return occurrences, true
+		}
+		occurrences = append(occurrences, next)
+		next, ok = d.nextOccurrence(start, next.AddDate(0, 0, 1))
+	}
+	return occurrences, false
+}
+
+// nextOccurrence finds the first occurrence on or after from. A one-off
+// D-Day has none once its date has passed.
+func (d *DDay) nextOccurrence(start, from time.Time) (time.Time, bool) {
+	if !start.Before(from) {
+		return start, true
+	}
+	if !d.IsRecurring() {
+		return time.Time{}, false
+	}
+	if d.CalendarType == CalendarLunar {
+		return d.nextLunarOccurrence(from)
+	}
+
+	interval := d.RecurrenceInterval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch d.Recurrence {
+	case RecurrenceDaily, RecurrenceWeekly:
+		step := interval
+		if d.Recurrence == RecurrenceWeekly {
+			step *= 7
+		}
+		n := (daysBetween(start, from) + step - 1) / step
+		return start.AddDate(0, 0, n*step), true
+	case RecurrenceMonthly, RecurrenceYearly:
+		step := interval
+		if d.Recurrence == RecurrenceYearly {
+			step *= 12
+		}
+		months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+		for n := months / step; ; n++ {
+			if next := addMonthsClamped(start, n*step); !next.Before(from) {
+				return next, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// addMonthsClamped moves date by months, keeping its day of the month or
+// the last day of shorter months.
+func addMonthsClamped(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(date.Day(), lastDay)-1)
+}
+
+// daysBetween counts the days from a to b, both midnight UTC.
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func mustDate(value string) time.Time {
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func formatDates(dates []time.Time) []string {
+	formatted := make([]string, len(dates))
+	for i, d := range dates {
+		formatted[i] = d.Format(dateLayout)
+	}
+	return formatted
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		frequency    string
+		interval     int
+		want         string
+		wantInterval int
+		wantErr      bool
+	}{
+		{"", 0, RecurrenceNone, 1, false},
+		{RecurrenceNone, 5, RecurrenceNone, 1, false},
+		{RecurrenceMonthly, 0, RecurrenceMonthly, 1, false},
+		{RecurrenceWeekly, 2, RecurrenceWeekly, 2, false},
+		{RecurrenceYearly, MaxRecurrenceInterval, RecurrenceYearly, MaxRecurrenceInterval, false},
+		{RecurrenceDaily, -1, "", 0, true},
+		{RecurrenceDaily, MaxRecurrenceInterval + 1, "", 0, true},
+		{"hourly", 1, "", 0, true},
+	}
+	for _, tt := range tests {
+		got, interval, err := ParseRecurrence(tt.frequency, tt.interval)
+		if (err != nil) != tt.wantErr || got != tt.want || interval != tt.wantInterval {
+			t.Errorf("ParseRecurrence(%q, %d) = %q, %d, %v; want %q, %d, error %v",
+				tt.frequency, tt.interval, got, interval, err, tt.want, tt.wantInterval, tt.wantErr)
+		}
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		recurrence string
+		interval   int
+		from, to   string
+		want       []string
+	}{
+		{
+			name:   "one-off in range",
+			target: "2024-03-01", recurrence: RecurrenceNone, interval: 1,
+			from: "2024-01-01", to: "2024-12-31",
+			want: []string{"2024-03-01"},
+		},
+		{
+			name:   "one-off passed",
+			target: "2024-03-01", recurrence: RecurrenceNone, interval: 1,
+			from: "2024-03-02", to: "2024-12-31",
+			want: []string{},
+		},
+		{
+			name:   "every third day",
+			target: "2024-01-01", recurrence: RecurrenceDaily, interval: 3,
+			from: "2024-01-02", to: "2024-01-10",
+			want: []string{"2024-01-04", "2024-01-07", "2024-01-10"},
+		},
+		{
+			name:   "every other week",
+			target: "2024-01-01", recurrence: RecurrenceWeekly, interval: 2,
+			from: "2024-01-10", to: "2024-02-01",
+			want: []string{"2024-01-15", "2024-01-29"},
+		},
+		{
+			name:   "month end in a common year",
+			target: "2023-01-31", recurrence: RecurrenceMonthly, interval: 1,
+			from: "2023-01-01", to: "2023-06-30",
+			want: []string{"2023-01-31", "2023-02-28", "2023-03-31", "2023-04-30", "2023-05-31", "2023-06-30"},
+		},
+		{
+			name:   "month end in a leap year",
+			target: "2024-01-31", recurrence: RecurrenceMonthly, interval: 1,
+			from: "2024-02-01", to: "2024-03-31",
+			want: []string{"2024-02-29", "2024-03-31"},
+		},
+		{
+			name:   "month end every other month",
+			target: "2023-12-31", recurrence: RecurrenceMonthly, interval: 2,
+			from: "2024-01-01", to: "2024-06-30",
+			want: []string{"2024-02-29", "2024-04-30", "2024-06-30"},
+		},
+		{
+			name:   "Feb 29 yearly",
+			target: "2024-02-29", recurrence: RecurrenceYearly, interval: 1,
+			from: "2024-01-01", to: "2028-12-31",
+			want: []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			name:   "Feb 29 every fourth year",
+			target: "2024-02-29", recurrence: RecurrenceYearly, interval: 4,
+			from: "2024-03-01", to: "2033-01-01",
+			want: []string{"2028-02-29", "2032-02-29"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dday := DDay{TargetDate: tt.target, Recurrence: tt.recurrence, RecurrenceInterval: tt.interval}
+			got, more := dday.Occurrences(mustDate(tt.from), mustDate(tt.to), 100)
+			if more {
+				t.Errorf("more = true, want false")
+			}
+			if formatted := formatDates(got); !reflect.DeepEqual(formatted, tt.want) {
+				t.Errorf("Occurrences = %v, want %v", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccurrencesLimit(t *testing.T) {
+	dday := DDay{TargetDate: "2024-01-01", Recurrence: RecurrenceDaily, RecurrenceInterval: 1}
+	got, more := dday.Occurrences(mustDate("2024-01-01"), mustDate("2024-01-31"), 3)
+	if want := []string{"2024-01-01", "2024-01-02", "2024-01-03"}; !reflect.DeepEqual(formatDates(got), want) || !more {
+		t.Errorf("Occurrences = %v, %v; want %v, true", formatDates(got), more, want)
+	}
+}
+
+func TestOccursOn(t *testing.T) {
+	dday := DDay{TargetDate: "2024-02-29", Recurrence: RecurrenceYearly, RecurrenceInterval: 1}
+	for day, want := range map[string]bool{
+		"2024-02-29": true,
+		"2025-02-28": true,
+		"2025-03-01": false,
+		"2028-02-28": false,
+		"2028-02-29": true,
+		"2023-02-28": false,
+	} {
+		if got := dday.OccursOn(mustDate(day)); got != want {
+			t.Errorf("OccursOn(%s) = %v, want %v", day, got, want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrReminderExists = errors.New("reminder already exists")
+
+// MaxReminderDaysBefore is how far ahead of a D-Day a reminder may go off.
+const MaxReminderDaysBefore = 365
+
+// Reminder asks for a notification DaysBefore days ahead of a D-Day. SentOn
+// is the last day it went out.
+type Reminder struct {
+	ID         int       `json:"id" db:"n_id"`
+	DdayID     string    `json:"dday_id" db:"n_dday_id"`
+	DaysBefore int       `json:"days_before" db:"n_days_before"`
+	IsActive   bool      `json:"is_active" db:"n_is_active"`
+	SentOn     string    `json:"sent_on,omitempty" db:"n_sent_on"`
+	CreatedAt  time.Time `json:"created_at" db:"n_created_at"`
+}
+
+// ScheduledReminder is an active reminder together with the D-Day and the
+// owner it notifies.
+type ScheduledReminder struct {
+	Reminder
+	DDay  DDay
+	Owner User
+}
+
+// DueOccurrence reports which occurrence of the D-Day, as YYYY-MM-DD, the
+// reminder goes off for on today, a date at midnight UTC. A reminder is due
+// DaysBefore days ahead of every occurrence of a recurring D-Day.
+func (r *ScheduledReminder) DueOccurrence(today time.Time) (string, bool) {
+	occurrence := today.AddDate(0, 0, r.DaysBefore)
+	if !r.DDay.OccursOn(occurrence) {
+		return "", false
+	}
+	return occurrence.Format("2006-01-02"), true
+}
+
+// ReminderStore keeps the reminders of D-Days.
+//
+// The methods taking a ddayID act on behalf of userID and return
+// sql.ErrNoRows when ddayID is not one of userID's live D-Days, exactly like
+// a missing reminder. Reminders go to the D-Day's creator, so the members of
+// a shared D-Day cannot see or set them.
+type ReminderStore interface {
+	List(userID, ddayID string) ([]Reminder, error)
+	// ListByUser lists the reminders of all of userID's live D-Days.
+	ListByUser(userID string) ([]Reminder, error)
+	Get(userID, ddayID string, id int) (*Reminder, error)
+	// Create and Update return ErrReminderExists when the D-Day already has
+	// a reminder DaysBefore days ahead.
+	Create(userID, ddayID string, reminder *Reminder) error
+	Update(userID, ddayID string, id int, reminder *Reminder) error
+	Delete(userID, ddayID string, id int) error
+
+	// Scheduled lists, for every user, the active reminders of live D-Days
+	// whose target date is between from and to (YYYY-MM-DD, inclusive), and
+	// of recurring ones that started by to.
+	Scheduled(from, to string) ([]ScheduledReminder, error)
+	// MarkSent records that reminder id goes out on date. It returns false
+	// when it was already marked for that date, in which case it must not
+	// be sent again.
+	MarkSent(id int, date string) (bool, error)
+}
+
+// Reminders is the ReminderStore selected by InitDatabase.
+var Reminders ReminderStore
+
+func NewReminderStore() ReminderStore {
+	return Reminders
+}
+
+// ReminderManager is the SQL ReminderStore.
+type ReminderManager struct {
+	Conn  *Connection
+	ddays *DdayManager
+}
+
+func NewReminderManager() *ReminderManager {
+	return &ReminderManager{Conn: DB, ddays: NewDdayManager()}
+}
+
+const reminderColumns = "n_id, n_dday_id, n_days_before, n_is_active, n_sent_on, n_created_at"
+
+func scanReminder(row rowScanner) (Reminder, error) {
+	var reminder Reminder
+	err := row.Scan(reminderDest(&reminder)...)
+	return reminder, err
+}
+
+// reminderDest lists where the reminderColumns scan into.
+func reminderDest(reminder *Reminder) []interface{} {
+	return []interface{}{&reminder.ID, &reminder.DdayID, &reminder.DaysBefore, &reminder.IsActive,
+		dateColumn{&reminder.SentOn}, &reminder.CreatedAt}
+}
+
+func (m *ReminderManager) List(userID, ddayID string) ([]Reminder, error) {
+	if _, err := m.ddays.getOwn(m.Conn, userID, ddayID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + reminderColumns + " FROM notifications_tb WHERE n_dday_id = ? ORDER BY n_days_before DESC, n_id"
+	rows, err := m.Conn.Query(query, ddayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []Reminder{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (m *ReminderManager) ListByUser(userID string) ([]Reminder, error) {
+	query := `SELECT ` + reminderColumns + `
+			  FROM notifications_tb
+			  JOIN ddays_tb ON d_id = n_dday_id
+			  WHERE d_user_id = ? AND d_deleted_at IS NULL
+			  ORDER BY n_dday_id, n_days_before DESC, n_id`
+	rows, err := m.Conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []Reminder{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+func (m *ReminderManager) Get(userID, ddayID string, id int) (*Reminder, error) {
+	return m.get(m.Conn, userID, ddayID, id)
+}
+
+func (m *ReminderManager) get(q querier, userID, ddayID string, id int) (*Reminder, error) {
+	if _, err := m.ddays.getOwn(q, userID, ddayID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + reminderColumns + " FROM notifications_tb WHERE n_id = ? AND n_dday_id = ?"
+	reminder, err := scanReminder(q.QueryRow(query, id, ddayID))
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// daysTaken reports whether another reminder than id already fires
+// daysBefore days ahead of the D-Day.
+func (m *ReminderManager) daysTaken(q querier, ddayID string, daysBefore, id int) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM notifications_tb WHERE n_dday_id = ? AND n_days_before = ? AND n_id <> ?"
+	err := q.QueryRow(query, ddayID, daysBefore, id).Scan(&count)
+	return count > 0, err
+}
+
+func (m *ReminderManager) Create(userID, ddayID string, reminder *Reminder) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if _, err := m.ddays.getOwn(tx, userID, ddayID); err != nil {
+			return err
+		}
+		if taken, err := m.daysTaken(tx, ddayID, reminder.DaysBefore, 0); err != nil {
+			return err
+		} else if taken {
+			return ErrReminderExists
+		}
+
+		reminder.DdayID = ddayID
+		reminder.CreatedAt = utcNow()
+		query := "INSERT INTO notifications_tb (n_dday_id, n_days_before, n_is_active, n_created_at) VALUES (?, ?, ?, ?)"
+		result, err := tx.Exec(query, reminder.DdayID, reminder.DaysBefore, reminder.IsActive, reminder.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		reminder.ID = int(id)
+		return err
+	})
+}
+
+// Update changes when and whether a reminder fires. Moving it to another day
+// clears SentOn so it can go out again.
+func (m *ReminderManager) Update(userID, ddayID string, id int, reminder *Reminder) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		existing, err := m.get(tx, userID, ddayID, id)
+		if err != nil {
+			return err
+		}
+		if taken, err := m.daysTaken(tx, ddayID, reminder.DaysBefore, id); err != nil {
+			return err
+		} else if taken {
+			return ErrReminderExists
+		}
+
+		query := "UPDATE notifications_tb SET n_days_before = ?, n_is_active = ? WHERE n_id = ?"
+		if existing.DaysBefore != reminder.DaysBefore {
+			query = "UPDATE notifications_tb SET n_days_before = ?, n_is_active = ?, n_sent_on = NULL WHERE n_id = ?"
+		}
+		_, err = tx.Exec(query, reminder.DaysBefore, reminder.IsActive, id)
+		return err
+	})
+}
+
+func (m *ReminderManager) Delete(userID, ddayID string, id int) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if _, err := m.get(tx, userID, ddayID, id); err != nil {
+			return err
+		}
+		_, err := tx.Exec("DELETE FROM notifications_tb WHERE n_id = ?", id)
+		return err
+	})
+}
+
+func (m *ReminderManager) Scheduled(from, to string) ([]ScheduledReminder, error) {
+	query := `SELECT ` + ddayColumns + `, ` + reminderColumns + `, u_email, u_name, u_timezone
+			  FROM notifications_tb
+			  JOIN ddays_tb ON d_id = n_dday_id
+			  JOIN users_tb ON u_id = d_user_id
+			  WHERE n_is_active = ? AND d_deleted_at IS NULL
+			  AND (d_target_date BETWEEN ? AND ? OR (d_recurrence <> ? AND d_target_date <= ?))
+			  ORDER BY n_id`
+
+	rows, err := m.Conn.Query(query, true, from, to, RecurrenceNone, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scheduled []ScheduledReminder
+	for rows.Next() {
+		var s ScheduledReminder
+		extra := append(reminderDest(&s.Reminder), &s.Owner.Email, &s.Owner.Name, &s.Owner.Timezone)
+		if s.DDay, err = scanDday(rows, extra...); err != nil {
+			return nil, err
+		}
+		s.Owner.ID = s.DDay.UserID
+		scheduled = append(scheduled, s)
+	}
+
+	return scheduled, rows.Err()
+}
+
+func (m *ReminderManager) MarkSent(id int, date string) (bool, error) {
+	query := "UPDATE notifications_tb SET n_sent_on = ? WHERE n_id = ? AND (n_sent_on IS NULL OR n_sent_on <> ?)"
+	result, err := m.Conn.Exec(query, date, id, date)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
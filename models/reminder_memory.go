@@ -0,0 +1,181 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// MemoryReminderStore is the in-memory ReminderStore. It shares its memoryDB
+// with the MemoryDdayStore that owns the D-Days.
+type MemoryReminderStore struct {
+	db    *memoryDB
+	ddays *MemoryDdayStore
+}
+
+func newMemoryReminderStore(db *memoryDB) *MemoryReminderStore {
+	return &MemoryReminderStore{db: db, ddays: &MemoryDdayStore{db: db}}
+}
+
+func (m *MemoryReminderStore) List(userID, ddayID string) ([]Reminder, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, err := m.ddays.getOwn(userID, ddayID); err != nil {
+		return nil, err
+	}
+
+	reminders := []Reminder{}
+	for _, reminder := range m.db.reminders {
+		if reminder.DdayID == ddayID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		if reminders[i].DaysBefore != reminders[j].DaysBefore {
+			return reminders[i].DaysBefore > reminders[j].DaysBefore
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+	return reminders, nil
+}
+
+func (m *MemoryReminderStore) ListByUser(userID string) ([]Reminder, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	reminders := []Reminder{}
+	for _, reminder := range m.db.reminders {
+		if _, err := m.ddays.getOwn(userID, reminder.DdayID); err == nil {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		a, b := reminders[i], reminders[j]
+		if a.DdayID != b.DdayID {
+			return a.DdayID < b.DdayID
+		}
+		if a.DaysBefore != b.DaysBefore {
+			return a.DaysBefore > b.DaysBefore
+		}
+		return a.ID < b.ID
+	})
+	return reminders, nil
+}
+
+func (m *MemoryReminderStore) Get(userID, ddayID string, id int) (*Reminder, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	return m.get(userID, ddayID, id)
+}
+
+func (m *MemoryReminderStore) get(userID, ddayID string, id int) (*Reminder, error) {
+	if _, err := m.ddays.getOwn(userID, ddayID); err != nil {
+		return nil, err
+	}
+	reminder, ok := m.db.reminders[id]
+	if !ok || reminder.DdayID != ddayID {
+		return nil, sql.ErrNoRows
+	}
+	return &reminder, nil
+}
+
+func (m *MemoryReminderStore) daysTaken(ddayID string, daysBefore, id int) bool {
+	for _, reminder := range m.db.reminders {
+		if reminder.DdayID == ddayID && reminder.DaysBefore == daysBefore && reminder.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryReminderStore) Create(userID, ddayID string, reminder *Reminder) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	dday, err := m.ddays.getOwn(userID, ddayID)
+	if err != nil {
+		return err
+	}
+	if m.daysTaken(ddayID, reminder.DaysBefore, 0) {
+		return ErrReminderExists
+	}
+
+	reminder.ID = m.db.nextID(&m.db.nextReminderID)
+	reminder.DdayID = ddayID
+	reminder.CreatedAt = utcNow()
+	stored := *reminder
+	stored.DdayID = dday.ID
+	setRow(m.db, m.db.reminders, stored.ID, stored)
+	return nil
+}
+
+func (m *MemoryReminderStore) Update(userID, ddayID string, id int, reminder *Reminder) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	existing, err := m.get(userID, ddayID, id)
+	if err != nil {
+		return err
+	}
+	if m.daysTaken(ddayID, reminder.DaysBefore, id) {
+		return ErrReminderExists
+	}
+
+	if existing.DaysBefore != reminder.DaysBefore {
+		existing.SentOn = ""
+	}
+	existing.DaysBefore = reminder.DaysBefore
+	existing.IsActive = reminder.IsActive
+	setRow(m.db, m.db.reminders, id, *existing)
+	return nil
+}
+
+func (m *MemoryReminderStore) Delete(userID, ddayID string, id int) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, err := m.get(userID, ddayID, id); err != nil {
+		return err
+	}
+	deleteRow(m.db, m.db.reminders, id)
+	return nil
+}
+
+func (m *MemoryReminderStore) Scheduled(from, to string) ([]ScheduledReminder, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	var scheduled []ScheduledReminder
+	for _, reminder := range m.db.reminders {
+		if !reminder.IsActive {
+			continue
+		}
+		dday, ok := m.db.ddays[reminder.DdayID]
+		if !ok || dday.DeletedAt != nil || dday.TargetDate > to || (dday.TargetDate < from && !dday.IsRecurring()) {
+			continue
+		}
+		owner, ok := m.db.users[dday.UserID]
+		if !ok {
+			continue
+		}
+		scheduled = append(scheduled, ScheduledReminder{Reminder: reminder, DDay: dday, Owner: owner})
+	}
+	sort.Slice(scheduled, func(i, j int) bool {
+		return scheduled[i].ID < scheduled[j].ID
+	})
+	return scheduled, nil
+}
+
+func (m *MemoryReminderStore) MarkSent(id int, date string) (bool, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	reminder, ok := m.db.reminders[id]
+	if !ok || reminder.SentOn == date {
+		return false, nil
+	}
+	reminder.SentOn = strings.Clone(date)
+	setRow(m.db, m.db.reminders, id, reminder)
+	return true, nil
+}
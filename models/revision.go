@@ -0,0 +1,244 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionRevert  = "revert"
+)
+
+// DdaySnapshot is the user-editable state of a D-Day captured by a revision.
+type DdaySnapshot struct {
+	Title              string `json:"title"`
+	TargetDate         string `json:"target_date"`
+	Category           string `json:"category"`
+	Memo               string `json:"memo"`
+	IsImportant        bool   `json:"is_important"`
+	Recurrence         string `json:"recurrence"`
+	RecurrenceInterval int    `json:"recurrence_interval"`
+	CalendarType       string `json:"calendar_type"`
+	LunarDate          string `json:"lunar_date,omitempty"`
+	IsLeapMonth        bool   `json:"is_leap_month"`
+	// Tags is nil in snapshots taken before D-Days had tags.
+	Tags []string `json:"tags"`
+}
+
+func SnapshotOf(dday *DDay) DdaySnapshot {
+	return DdaySnapshot{
+		Title:              dday.Title,
+		TargetDate:         dday.TargetDate,
+		Category:           dday.Category,
+		Memo:               dday.Memo,
+		IsImportant:        dday.IsImportant,
+		Recurrence:         dday.Recurrence,
+		RecurrenceInterval: dday.RecurrenceInterval,
+		CalendarType:       dday.CalendarType,
+		LunarDate:          dday.LunarDate,
+		IsLeapMonth:        dday.IsLeapMonth,
+		Tags:               dday.Tags,
+	}
+}
+
+// Apply copies the snapshot's fields onto dday. Snapshots taken before
+// recurrence and lunar dates existed apply as one-off solar D-Days, and
+// those taken before tags leave the tags alone.
+func (s DdaySnapshot) Apply(dday *DDay) {
+	dday.Title = s.Title
+	dday.TargetDate = s.TargetDate
+	dday.Category = s.Category
+	dday.Memo = s.Memo
+	dday.IsImportant = s.IsImportant
+	dday.Recurrence, dday.RecurrenceInterval = s.Recurrence, s.RecurrenceInterval
+	if dday.Recurrence == "" {
+		dday.Recurrence, dday.RecurrenceInterval = RecurrenceNone, 1
+	}
+	dday.CalendarType, dday.LunarDate, dday.IsLeapMonth = s.CalendarType, s.LunarDate, s.IsLeapMonth
+	if dday.CalendarType == "" {
+		dday.CalendarType = CalendarSolar
+	}
+	if s.Tags != nil {
+		dday.Tags = s.Tags
+	}
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type Revision struct {
+	DdayID    string        `json:"dday_id"`
+	Revision  int           `json:"revision"`
+	Action    string        `json:"action"`
+	Actor     string        `json:"actor"`
+	Snapshot  DdaySnapshot  `json:"snapshot"`
+	Changes   []FieldChange `json:"changes"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// DiffSnapshots lists the fields that differ between two snapshots. A nil
+// prev reports every field as newly set.
+func DiffSnapshots(prev *DdaySnapshot, next DdaySnapshot) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field string, from, to interface{}, changed bool) {
+		if prev == nil {
+			from = nil
+		} else if !changed {
+			return
+		}
+		changes = append(changes, FieldChange{Field: field, From: from, To: to})
+	}
+
+	var p DdaySnapshot
+	if prev != nil {
+		p = *prev
+	}
+	add("title", p.Title, next.Title, p.Title != next.Title)
+	add("target_date", p.TargetDate, next.TargetDate, p.TargetDate != next.TargetDate)
+	add("category", p.Category, next.Category, p.Category != next.Category)
+	add("memo", p.Memo, next.Memo, p.Memo != next.Memo)
+	add("is_important", p.IsImportant, next.IsImportant, p.IsImportant != next.IsImportant)
+	add("recurrence", p.Recurrence, next.Recurrence, p.Recurrence != next.Recurrence)
+	add("recurrence_interval", p.RecurrenceInterval, next.RecurrenceInterval, p.RecurrenceInterval != next.RecurrenceInterval)
+	add("calendar_type", p.CalendarType, next.CalendarType, p.CalendarType != next.CalendarType)
+	add("lunar_date", p.LunarDate, next.LunarDate, p.LunarDate != next.LunarDate)
+	add("is_leap_month", p.IsLeapMonth, next.IsLeapMonth, p.IsLeapMonth != next.IsLeapMonth)
+	add("tags", p.Tags, next.Tags, strings.Join(p.Tags, ",") != strings.Join(next.Tags, ","))
+
+	return changes
+}
+
+// withChanges fills Changes on revisions ordered oldest first.
+func withChanges(revisions []Revision) []Revision {
+	var prev *DdaySnapshot
+	for i := range revisions {
+		revisions[i].Changes = DiffSnapshots(prev, revisions[i].Snapshot)
+		prev = &revisions[i].Snapshot
+	}
+	return revisions
+}
+
+// querier is the subset of *sql.DB and *sql.Tx the SQL store runs against.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (m *DdayManager) recordRevision(q querier, id, action, actor string, snapshot DdaySnapshot) error {
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	var next int
+	err = q.QueryRow("SELECT COALESCE(MAX(r_revision), 0) + 1 FROM dday_revisions_tb WHERE r_dday_id = ?", id).Scan(&next)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO dday_revisions_tb (r_dday_id, r_revision, r_action, r_actor, r_snapshot, r_created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = q.Exec(query, id, next, action, actor, string(body), utcNow())
+	return err
+}
+
+// GetRevisions returns the history of a D-Day userID can see, newest first,
+// including trashed D-Days.
+func (m *DdayManager) GetRevisions(userID, id string) ([]Revision, error) {
+	query := `SELECT r_dday_id, r_revision, r_action, r_actor, r_snapshot, r_created_at
+			  FROM dday_revisions_tb
+			  WHERE r_dday_id = ? AND EXISTS (SELECT 1 FROM ddays_tb WHERE d_id = r_dday_id AND ` + accessCondition + `)
+			  ORDER BY r_revision ASC`
+
+	rows, err := m.Conn.Query(query, id, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var rev Revision
+		var body string
+		if err := rows.Scan(&rev.DdayID, &rev.Revision, &rev.Action, &rev.Actor, &body, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(body), &rev.Snapshot); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newestFirst(withChanges(revisions)), nil
+}
+
+// Revert rewrites a live D-Day with the snapshot stored in revision and
+// records that as a new revision. A category renamed or deleted since is
+// replaced by the default one. It returns sql.ErrNoRows when either the
+// D-Day or the revision does not exist.
+func (m *DdayManager) Revert(userID, id string, revision int) (*DDay, error) {
+	var reverted *DDay
+	err := m.inTx(func(tx *sql.Tx) error {
+		current, err := m.authorize(tx, userID, id, RoleEditor)
+		if err != nil {
+			return err
+		}
+
+		var body string
+		query := "SELECT r_snapshot FROM dday_revisions_tb WHERE r_dday_id = ? AND r_revision = ?"
+		if err := tx.QueryRow(query, id, revision).Scan(&body); err != nil {
+			return err
+		}
+
+		var snapshot DdaySnapshot
+		if err := json.Unmarshal([]byte(body), &snapshot); err != nil {
+			return err
+		}
+
+		snapshot.Apply(current)
+		if current.Category, err = m.liveCategory(tx, current.Category); err != nil {
+			return err
+		}
+
+		if err := m.update(tx, userID, id, current, RevisionRevert); err != nil {
+			return err
+		}
+
+		reverted, err = m.getByID(tx, userID, id)
+		return err
+	})
+	return reverted, err
+}
+
+func (m *DdayManager) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func newestFirst(revisions []Revision) []Revision {
+	for i, j := 0, len(revisions)-1; i < j; i, j = i+1, j-1 {
+		revisions[i], revisions[j] = revisions[j], revisions[i]
+	}
+	return revisions
+}
@@ -0,0 +1,320 @@
+package models
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchResult is a D-Day matched by a full-text search together with its
+// relevance score and the matched fragments.
+type SearchResult struct {
+	DDay
+	Score      float64    `json:"score"`
+	Highlights Highlights `json:"highlights"`
+}
+
+// Highlights holds HTML-escaped text with each matched term wrapped in
+// <mark>. Memo is a fragment around the first match and is empty when the
+// memo did not match.
+type Highlights struct {
+	Title string `json:"title"`
+	Memo  string `json:"memo,omitempty"`
+}
+
+const (
+	maxSearchTerms = 8
+	// memoFragmentRadius is how many characters of context surround the
+	// first match in a memo highlight.
+	memoFragmentRadius = 40
+	// titleWeight ranks a title match above the same match in a memo.
+	titleWeight = 2
+)
+
+// searchTerms splits a search string into lower-cased, de-duplicated terms.
+// Double quotes are dropped because MySQL boolean mode and FTS5 both use
+// them to delimit phrases.
+func searchTerms(s string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range strings.Fields(strings.ToLower(strings.ReplaceAll(s, `"`, " "))) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+func newSearchResult(d DDay, score float64, terms []string) SearchResult {
+	memo := ""
+	if marks := markTerms([]rune(d.Memo), terms); marks != nil {
+		memo = renderMarks([]rune(d.Memo), marks, memoFragmentRadius)
+	}
+
+	title := []rune(d.Title)
+	return SearchResult{
+		DDay:  d,
+		Score: score,
+		Highlights: Highlights{
+			Title: renderMarks(title, markTerms(title, terms), 0),
+			Memo:  memo,
+		},
+	}
+}
+
+// markTerms flags every rune of text covered by a case-insensitive match of
+// one of the terms, returning nil when nothing matched.
+func markTerms(text []rune, terms []string) []bool {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var marks []bool
+	for _, term := range terms {
+		needle := []rune(term)
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if !hasRunePrefix(lower[i:], needle) {
+				continue
+			}
+			if marks == nil {
+				marks = make([]bool, len(text))
+			}
+			for j := i; j < i+len(needle); j++ {
+				marks[j] = true
+			}
+		}
+	}
+	return marks
+}
+
+func hasRunePrefix(s, prefix []rune) bool {
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// renderMarks escapes text and wraps marked runs in <mark>. A positive
+// radius trims the text to that many runes around the first mark.
+func renderMarks(text []rune, marks []bool, radius int) string {
+	start, end := 0, len(text)
+	if radius > 0 && marks != nil {
+		first := 0
+		for first < len(marks) && !marks[first] {
+			first++
+		}
+		if first-radius > start {
+			start = first - radius
+		}
+		if first+radius < end {
+			end = first + radius
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	marked := false
+	for i := start; i < end; i++ {
+		on := marks != nil && marks[i]
+		if on != marked {
+			if on {
+				b.WriteString("<mark>")
+			} else {
+				b.WriteString("</mark>")
+			}
+			marked = on
+		}
+		b.WriteString(html.EscapeString(string(text[i])))
+	}
+	if marked {
+		b.WriteString("</mark>")
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// Search runs a full-text query and returns matches ordered by relevance.
+// The query's ordering and cursor are ignored; Count gives the total.
+func (m *DdayManager) Search(userID string, q *Query) ([]SearchResult, error) {
+	if len(q.search) == 0 {
+		return nil, ErrInvalidQuery
+	}
+
+	score, scoreArgs := m.relevance(q.search)
+	whereClause, queryArgs := m.buildWhere(userID, q, false)
+	query := "SELECT " + ddayColumns + ", " + score + " AS relevance FROM ddays_tb WHERE " + whereClause
+	query += " ORDER BY relevance DESC, d_id ASC"
+	query += pagingLimit(q.paging)
+
+	rows, err := m.Conn.Query(query, append(scoreArgs, queryArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var relevance float64
+		dday, err := scanDday(rows, &relevance)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, newSearchResult(dday, relevance, q.search))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	ddays := make([]DDay, len(results))
+	for i := range results {
+		ddays[i] = results[i].DDay
+	}
+	if err := m.decorate(m.Conn, userID, ddays); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Tags, results[i].Role = ddays[i].Tags, ddays[i].Role
+	}
+	return results, nil
+}
+
+// fullTextTokenSize is the shortest term the dialect's full-text index can
+// match: MySQL's ngram parser indexes bigrams, FTS5's trigram tokenizer
+// trigrams. Shorter terms fall back to LIKE.
+func (m *DdayManager) fullTextTokenSize() int {
+	if m.Conn.Driver == DriverSQLite {
+		return 3
+	}
+	return 2
+}
+
+// splitTerms separates terms the full-text index can match from those that
+// need a LIKE scan.
+func (m *DdayManager) splitTerms(terms []string) (indexed, scanned []string) {
+	for _, term := range terms {
+		if len([]rune(term)) >= m.fullTextTokenSize() {
+			indexed = append(indexed, term)
+		} else {
+			scanned = append(scanned, term)
+		}
+	}
+	return indexed, scanned
+}
+
+// matchExpression renders indexed terms as a query requiring all of them,
+// each as a phrase.
+func (m *DdayManager) matchExpression(indexed []string) string {
+	phrases := make([]string, len(indexed))
+	for i, term := range indexed {
+		phrases[i] = `"` + term + `"`
+		if m.Conn.Driver != DriverSQLite {
+			phrases[i] = "+" + phrases[i]
+		}
+	}
+	return strings.Join(phrases, " ")
+}
+
+// searchCondition restricts rows to those containing every search term in
+// the title or memo.
+func (m *DdayManager) searchCondition(terms []string) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	indexed, scanned := m.splitTerms(terms)
+	if len(indexed) > 0 {
+		if m.Conn.Driver == DriverSQLite {
+			conditions = append(conditions, "d_id IN (SELECT d_id FROM ddays_fts WHERE ddays_fts MATCH ?)")
+		} else {
+			conditions = append(conditions, "MATCH(d_title, d_memo) AGAINST (? IN BOOLEAN MODE)")
+		}
+		args = append(args, m.matchExpression(indexed))
+	}
+
+	for _, term := range scanned {
+		pattern := containsPattern(term)
+		conditions = append(conditions, "(d_title LIKE ? ESCAPE '!' OR d_memo LIKE ? ESCAPE '!')")
+		args = append(args, pattern, pattern)
+	}
+
+	return conditions, args
+}
+
+// relevance is the SQL expression scoring a row against the search terms.
+func (m *DdayManager) relevance(terms []string) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+
+	indexed, scanned := m.splitTerms(terms)
+	if len(indexed) > 0 {
+		if m.Conn.Driver == DriverSQLite {
+			// bm25 is lower for better matches; weights follow the FTS
+			// columns (d_id, d_title, d_memo).
+			parts = append(parts, "COALESCE((SELECT -bm25(ddays_fts, 0.0, 2.0, 1.0) FROM ddays_fts WHERE ddays_fts MATCH ? AND ddays_fts.d_id = ddays_tb.d_id), 0)")
+		} else {
+			parts = append(parts, "MATCH(d_title, d_memo) AGAINST (? IN BOOLEAN MODE)")
+		}
+		args = append(args, m.matchExpression(indexed))
+	}
+
+	for _, term := range scanned {
+		pattern := containsPattern(term)
+		parts = append(parts, "CASE WHEN d_title LIKE ? ESCAPE '!' THEN 2 ELSE 0 END + CASE WHEN d_memo LIKE ? ESCAPE '!' THEN 1 ELSE 0 END")
+		args = append(args, pattern, pattern)
+	}
+
+	return "(" + strings.Join(parts, " + ") + ")", args
+}
+
+func (m *MemoryDdayStore) Search(userID string, q *Query) ([]SearchResult, error) {
+	if len(q.search) == 0 {
+		return nil, ErrInvalidQuery
+	}
+
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	ddays := m.filter(userID, q)
+	results := make([]SearchResult, len(ddays))
+	for i, dday := range ddays {
+		results[i] = newSearchResult(dday, memoryRelevance(dday, q.search), q.search)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	start, end := pageBounds(len(results), q.paging)
+	if start == end {
+		return nil, nil
+	}
+	return results[start:end], nil
+}
+
+// memoryRelevance counts term occurrences, weighting the title like the SQL
+// stores do.
+func memoryRelevance(d DDay, terms []string) float64 {
+	title, memo := strings.ToLower(d.Title), strings.ToLower(d.Memo)
+
+	score := 0
+	for _, term := range terms {
+		score += titleWeight*strings.Count(title, term) + strings.Count(memo, term)
+	}
+	return float64(score)
+}
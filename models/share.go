@@ -0,0 +1,234 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Share is a public link to one D-Day for people without an account. Only
+// hashes of its token and password are kept; PasswordHash is "" for a link
+// anyone holding the token may open.
+type Share struct {
+	ID           int        `json:"id" db:"sh_id"`
+	DdayID       string     `json:"dday_id" db:"sh_dday_id"`
+	TokenHash    string     `json:"-" db:"sh_token_hash"`
+	PasswordHash string     `json:"-" db:"sh_password_hash"`
+	HasPassword  bool       `json:"has_password" db:"-"`
+	ShowMemo     bool       `json:"show_memo" db:"sh_show_memo"`
+	ViewCount    int        `json:"view_count" db:"sh_view_count"`
+	ExpiresAt    *time.Time `json:"expires_at" db:"sh_expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at" db:"sh_revoked_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"sh_created_at"`
+}
+
+// Expired reports whether the share's expiry has passed at now.
+func (s *Share) Expired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// SharedDday is the read-only view of a D-Day that a share link shows. It
+// leaves out the owner, the ID and, unless the share allows it, the memo.
+type SharedDday struct {
+	Title              string     `json:"title"`
+	TargetDate         string     `json:"target_date"`
+	Category           string     `json:"category"`
+	Memo               string     `json:"memo,omitempty"`
+	IsImportant        bool       `json:"is_important"`
+	Recurrence         string     `json:"recurrence"`
+	RecurrenceInterval int        `json:"recurrence_interval"`
+	CalendarType       string     `json:"calendar_type"`
+	LunarDate          string     `json:"lunar_date,omitempty"`
+	IsLeapMonth        bool       `json:"is_leap_month"`
+	NextOccurrence     *string    `json:"next_occurrence"`
+	DaysRemaining      int        `json:"days_remaining"`
+	Label              string     `json:"label"`
+	Status             string     `json:"status"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+}
+
+// View builds what the share shows of dday, whose countdown must already be
+// set with SetToday.
+func (s *Share) View(dday *DDay) SharedDday {
+	view := SharedDday{
+		Title:              dday.Title,
+		TargetDate:         dday.TargetDate,
+		Category:           dday.Category,
+		IsImportant:        dday.IsImportant,
+		Recurrence:         dday.Recurrence,
+		RecurrenceInterval: dday.RecurrenceInterval,
+		CalendarType:       dday.CalendarType,
+		LunarDate:          dday.LunarDate,
+		IsLeapMonth:        dday.IsLeapMonth,
+		NextOccurrence:     dday.NextOccurrence,
+		DaysRemaining:      dday.DaysRemaining,
+		Label:              dday.Label,
+		Status:             dday.Status,
+		ExpiresAt:          s.ExpiresAt,
+	}
+	if s.ShowMemo {
+		view.Memo = dday.Memo
+	}
+	return view
+}
+
+// ShareStore keeps the share links of D-Days.
+//
+// The methods taking a ddayID act on behalf of userID and return
+// sql.ErrNoRows when userID cannot see ddayID or it is in the trash, exactly
+// like a missing share, and ErrForbidden unless userID is one of its owners.
+type ShareStore interface {
+	// List returns every share of the D-Day, newest first, including revoked
+	// and expired ones.
+	List(userID, ddayID string) ([]Share, error)
+	Create(userID, ddayID string, share *Share) error
+	// Revoke stops a share from being opened; revoking it again is a no-op.
+	Revoke(userID, ddayID string, id int) error
+
+	// GetByToken returns the share whose token hashes to tokenHash with its
+	// D-Day, for anyone holding the token. It returns sql.ErrNoRows when
+	// there is none or the D-Day is in the trash; expiry and revocation are
+	// for the caller to check.
+	GetByToken(tokenHash string) (*Share, *DDay, error)
+	// RecordView counts one view of share id.
+	RecordView(id int) error
+}
+
+// Shares is the ShareStore selected by InitDatabase.
+var Shares ShareStore
+
+func NewShareStore() ShareStore {
+	return Shares
+}
+
+// ShareManager is the SQL ShareStore.
+type ShareManager struct {
+	Conn  *Connection
+	ddays *DdayManager
+}
+
+func NewShareManager() *ShareManager {
+	return &ShareManager{Conn: DB, ddays: NewDdayManager()}
+}
+
+const shareColumns = "sh_id, sh_dday_id, sh_token_hash, sh_password_hash, sh_show_memo, sh_view_count, sh_expires_at, sh_revoked_at, sh_created_at"
+
+func scanShare(row rowScanner) (Share, error) {
+	var share Share
+	err := row.Scan(shareDest(&share)...)
+	share.HasPassword = share.PasswordHash != ""
+	return share, err
+}
+
+// shareDest lists where the shareColumns scan into.
+func shareDest(share *Share) []interface{} {
+	return []interface{}{&share.ID, &share.DdayID, &share.TokenHash, &share.PasswordHash, &share.ShowMemo,
+		&share.ViewCount, nullTimeColumn{&share.ExpiresAt}, nullTimeColumn{&share.RevokedAt}, &share.CreatedAt}
+}
+
+// nullTimeColumn scans a nullable TIMESTAMP column, leaving nil for NULL.
+type nullTimeColumn struct {
+	dest **time.Time
+}
+
+func (c nullTimeColumn) Scan(src interface{}) error {
+	var value sql.NullTime
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+	*c.dest = nil
+	if value.Valid {
+		*c.dest = &value.Time
+	}
+	return nil
+}
+
+func (m *ShareManager) List(userID, ddayID string) ([]Share, error) {
+	if _, err := m.ddays.authorize(m.Conn, userID, ddayID, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + shareColumns + " FROM shares_tb WHERE sh_dday_id = ? ORDER BY sh_id DESC"
+	rows, err := m.Conn.Query(query, ddayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []Share{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+func (m *ShareManager) Create(userID, ddayID string, share *Share) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if _, err := m.ddays.authorize(tx, userID, ddayID, RoleOwner); err != nil {
+			return err
+		}
+
+		share.DdayID = ddayID
+		share.HasPassword = share.PasswordHash != ""
+		share.CreatedAt = utcNow()
+		var expiresAt interface{}
+		if share.ExpiresAt != nil {
+			expiresAt = share.ExpiresAt.UTC()
+		}
+		query := `INSERT INTO shares_tb (sh_dday_id, sh_token_hash, sh_password_hash, sh_show_memo, sh_expires_at, sh_created_at)
+				  VALUES (?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, share.DdayID, share.TokenHash, share.PasswordHash, share.ShowMemo, expiresAt, share.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		share.ID = int(id)
+		return err
+	})
+}
+
+func (m *ShareManager) Revoke(userID, ddayID string, id int) error {
+	return m.ddays.inTx(func(tx *sql.Tx) error {
+		if _, err := m.ddays.authorize(tx, userID, ddayID, RoleOwner); err != nil {
+			return err
+		}
+
+		var exists int
+		err := tx.QueryRow("SELECT 1 FROM shares_tb WHERE sh_id = ? AND sh_dday_id = ?", id, ddayID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE shares_tb SET sh_revoked_at = ? WHERE sh_id = ? AND sh_revoked_at IS NULL", utcNow(), id)
+		return err
+	})
+}
+
+func (m *ShareManager) GetByToken(tokenHash string) (*Share, *DDay, error) {
+	query := `SELECT ` + ddayColumns + `, ` + shareColumns + `
+			  FROM shares_tb
+			  JOIN ddays_tb ON d_id = sh_dday_id
+			  WHERE sh_token_hash = ? AND d_deleted_at IS NULL`
+
+	var share Share
+	dday, err := scanDday(m.Conn.QueryRow(query, tokenHash), shareDest(&share)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	share.HasPassword = share.PasswordHash != ""
+
+	ddays := []DDay{dday}
+	if err := m.ddays.loadTags(m.Conn, ddays); err != nil {
+		return nil, nil, err
+	}
+	return &share, &ddays[0], nil
+}
+
+func (m *ShareManager) RecordView(id int) error {
+	_, err := m.Conn.Exec("UPDATE shares_tb SET sh_view_count = sh_view_count + 1 WHERE sh_id = ?", id)
+	return err
+}
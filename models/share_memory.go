@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+)
+
+// MemoryShareStore is the in-memory ShareStore. It shares its memoryDB with
+// the MemoryDdayStore that owns the D-Days.
+type MemoryShareStore struct {
+	db    *memoryDB
+	ddays *MemoryDdayStore
+}
+
+func newMemoryShareStore(db *memoryDB) *MemoryShareStore {
+	return &MemoryShareStore{db: db, ddays: &MemoryDdayStore{db: db}}
+}
+
+func (m *MemoryShareStore) List(userID, ddayID string) ([]Share, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, err := m.ddays.authorize(userID, ddayID, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	shares := []Share{}
+	for _, share := range m.db.shares {
+		if share.DdayID == ddayID {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].ID > shares[j].ID
+	})
+	return shares, nil
+}
+
+func (m *MemoryShareStore) Create(userID, ddayID string, share *Share) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	dday, err := m.ddays.authorize(userID, ddayID, RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	share.ID = m.db.nextID(&m.db.nextShareID)
+	share.DdayID = ddayID
+	share.HasPassword = share.PasswordHash != ""
+	share.CreatedAt = utcNow()
+	stored := *share
+	stored.DdayID = dday.ID
+	setRow(m.db, m.db.shares, stored.ID, stored)
+	return nil
+}
+
+func (m *MemoryShareStore) Revoke(userID, ddayID string, id int) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, err := m.ddays.authorize(userID, ddayID, RoleOwner); err != nil {
+		return err
+	}
+	share, ok := m.db.shares[id]
+	if !ok || share.DdayID != ddayID {
+		return sql.ErrNoRows
+	}
+	if share.RevokedAt == nil {
+		now := utcNow()
+		share.RevokedAt = &now
+		setRow(m.db, m.db.shares, id, share)
+	}
+	return nil
+}
+
+func (m *MemoryShareStore) GetByToken(tokenHash string) (*Share, *DDay, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, share := range m.db.shares {
+		if tokenHash == "" || share.TokenHash != tokenHash {
+			continue
+		}
+		dday, ok := m.db.ddays[share.DdayID]
+		if !ok || dday.DeletedAt != nil {
+			break
+		}
+		return &share, &dday, nil
+	}
+	return nil, nil, sql.ErrNoRows
+}
+
+func (m *MemoryShareStore) RecordView(id int) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if share, ok := m.db.shares[id]; ok {
+		share.ViewCount++
+		setRow(m.db, m.db.shares, id, share)
+	}
+	return nil
+}
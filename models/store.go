@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrVersionConflict is returned by conditional writes when the stored
+// version no longer matches the one the caller read.
+var ErrVersionConflict = errors.New("d-day version conflict")
+
+// Tx is the transaction handle shared by every DdayStore implementation.
+// SQL backends hand out *sql.Tx, the in-memory backend its own snapshot tx.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// DdayStore is the storage contract the controllers depend on.
+//
+// Every call acts on behalf of userID and only sees the D-Days userID
+// created or is a member of, with Role set to userID's role: any other D-Day
+// behaves exactly like a missing one. Create makes userID the creator and
+// owner. Update, Patch and Revert need the editor role, Delete and Restore
+// the owner role, and return ErrForbidden otherwise. Update, Patch and
+// Delete return sql.ErrNoRows when id is missing or trashed.
+//
+// Every write appends a Revision attributed to userID in the same
+// transaction as the change itself, and bumps the D-Day's Version. Update
+// with a non-zero dday.Version, Patch with a non-zero patch.Version, and
+// Delete with a non-zero version, only apply while the stored version still
+// matches and otherwise return ErrVersionConflict.
+type DdayStore interface {
+	Begin() (Tx, error)
+
+	GetAll(userID string, q *Query) ([]DDay, error)
+	GetByID(userID, id string) (*DDay, error)
+	Create(userID string, dday *DDay) error
+	Update(userID, id string, dday *DDay) error
+	// Patch writes only the columns of the fields patch sets. Like Update,
+	// it returns sql.ErrNoRows when id is missing or trashed.
+	Patch(userID, id string, patch *DdayPatch) error
+	Delete(userID, id string, version int) error
+	Count(userID string, q *Query) (int, error)
+
+	// Search ranks the D-Days matching q's search terms by relevance,
+	// applying q's filters and paging. It returns ErrInvalidQuery when q has
+	// no search terms.
+	Search(userID string, q *Query) ([]SearchResult, error)
+
+	GetByIDWithTx(tx Tx, userID, id string) (*DDay, error)
+	CreateWithTx(tx Tx, userID string, dday *DDay) error
+	UpdateWithTx(tx Tx, userID, id string, dday *DDay) error
+	DeleteWithTx(tx Tx, userID, id string, version int) error
+
+	// Delete moves a D-Day to the trash; Restore brings it back and returns
+	// sql.ErrNoRows when id is not in the trash. Purge permanently removes
+	// everything trashed before cutoff, revisions included, for all users.
+	Restore(userID, id string) error
+	Purge(cutoff time.Time) (int64, error)
+
+	GetRevisions(userID, id string) ([]Revision, error)
+	Revert(userID, id string, revision int) (*DDay, error)
+}
+
+// Store is the DdayStore selected by InitDatabase.
+var Store DdayStore
+
+func NewDdayStore() DdayStore {
+	return Store
+}
@@ -0,0 +1,302 @@
+package models
+
+import (
+	"database/sql"
+	"dday-backend/global/config"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testDrivers = []string{DriverMemory, DriverSQLite}
+
+// openTestStore connects a fresh store for driver with two users, u1 and
+// u2, and closes it when t ends.
+func openTestStore(t *testing.T, driver string) {
+	t.Helper()
+	config.AppConfig = &config.Config{Database: config.DatabaseConfig{
+		Driver:      driver,
+		Path:        filepath.Join(t.TempDir(), "dday.db"),
+		AutoMigrate: true,
+	}}
+	if err := InitDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		Close()
+		DB = nil
+	})
+
+	for _, id := range []string{"u1", "u2"} {
+		if err := Users.Create(&User{ID: id, Email: id + "@example.com", Name: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// forEachDriver runs test against a fresh store for every driver.
+func forEachDriver(t *testing.T, test func(t *testing.T)) {
+	for _, driver := range testDrivers {
+		t.Run(driver, func(t *testing.T) {
+			openTestStore(t, driver)
+			test(t)
+		})
+	}
+}
+
+func newTestDday(id, title string) *DDay {
+	return &DDay{
+		ID: id, Title: title, TargetDate: "2024-05-01", Category: "개인",
+		Recurrence: RecurrenceNone, RecurrenceInterval: 1, CalendarType: CalendarSolar,
+		Tags: []string{"family"}, CreatedAt: time.Now().UTC(),
+	}
+}
+
+func mustCreate(t *testing.T, userID string, dday *DDay) {
+	t.Helper()
+	if err := Store.Create(userID, dday); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listIDs(t *testing.T, userID string, trash Trash) []string {
+	t.Helper()
+	q, err := NewQuery().Trashed(trash).OrderBy(FieldID, false).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddays, err := Store.GetAll(userID, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, dday := range ddays {
+		ids = append(ids, dday.ID)
+	}
+	return ids
+}
+
+func TestStoreOwnership(t *testing.T) {
+	forEachDriver(t, func(t *testing.T) {
+		mustCreate(t, "u1", newTestDday("d1", "생일"))
+		mustCreate(t, "u2", newTestDday("d2", "기념일"))
+
+		dday, err := Store.GetByID("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dday.UserID != "u1" || dday.Role != RoleOwner || dday.Version != 1 {
+			t.Errorf("user, role, version = %s, %s, %d; want u1, %s, 1", dday.UserID, dday.Role, dday.Version, RoleOwner)
+		}
+
+		if _, err := Store.GetByID("u2", "d1"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetByID by another user error = %v, want sql.ErrNoRows", err)
+		}
+		if ids := listIDs(t, "u1", ExcludeTrashed); len(ids) != 1 || ids[0] != "d1" {
+			t.Errorf("u1 lists %v, want [d1]", ids)
+		}
+
+		update := newTestDday("d1", "훔친 생일")
+		if err := Store.Update("u2", "d1", update); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Update by another user error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Delete("u2", "d1", 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Delete by another user error = %v, want sql.ErrNoRows", err)
+		}
+		if revisions, err := Store.GetRevisions("u2", "d1"); err != nil || len(revisions) != 0 {
+			t.Errorf("GetRevisions by another user = %d revisions, %v; want none", len(revisions), err)
+		}
+
+		dday, err = Store.GetByID("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dday.Title != "생일" || dday.Version != 1 {
+			t.Errorf("title, version = %q, %d after another user's writes; want 생일, 1", dday.Title, dday.Version)
+		}
+	})
+}
+
+func TestStoreSoftDelete(t *testing.T) {
+	forEachDriver(t, func(t *testing.T) {
+		mustCreate(t, "u1", newTestDday("d1", "생일"))
+		mustCreate(t, "u1", newTestDday("d2", "기념일"))
+
+		if err := Store.Restore("u1", "d1"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Restore of a live D-Day error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Delete("u1", "d1", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Store.GetByID("u1", "d1"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetByID of a trashed D-Day error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Update("u1", "d1", newTestDday("d1", "생일")); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Update of a trashed D-Day error = %v, want sql.ErrNoRows", err)
+		}
+		memo := "x"
+		if err := Store.Patch("u1", "d1", &DdayPatch{Memo: &memo}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Patch of a trashed D-Day error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Delete("u1", "d1", 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("second Delete error = %v, want sql.ErrNoRows", err)
+		}
+		if ids := listIDs(t, "u1", ExcludeTrashed); len(ids) != 1 || ids[0] != "d2" {
+			t.Errorf("live D-Days = %v, want [d2]", ids)
+		}
+		if ids := listIDs(t, "u1", OnlyTrashed); len(ids) != 1 || ids[0] != "d1" {
+			t.Errorf("trashed D-Days = %v, want [d1]", ids)
+		}
+		if ids := listIDs(t, "u1", IncludeTrashed); len(ids) != 2 {
+			t.Errorf("all D-Days = %v, want both", ids)
+		}
+
+		if err := Store.Restore("u2", "d1"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Restore by another user error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Restore("u1", "d1"); err != nil {
+			t.Fatal(err)
+		}
+		dday, err := Store.GetByID("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dday.DeletedAt != nil || dday.Version != 3 {
+			t.Errorf("deleted at, version = %v, %d after restore; want nil, 3", dday.DeletedAt, dday.Version)
+		}
+		if len(dday.Tags) != 1 || dday.Tags[0] != "family" {
+			t.Errorf("tags = %v after restore, want [family]", dday.Tags)
+		}
+
+		revisions, err := Store.GetRevisions("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) != 3 {
+			t.Errorf("%d revisions, want create, delete and restore", len(revisions))
+		}
+
+		if err := Store.Delete("u1", "d2", 0); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := Store.Purge(time.Now().Add(time.Minute)); err != nil || n != 1 {
+			t.Errorf("Purge = %d, %v; want 1", n, err)
+		}
+		if ids := listIDs(t, "u1", IncludeTrashed); len(ids) != 1 || ids[0] != "d1" {
+			t.Errorf("D-Days after purge = %v, want [d1]", ids)
+		}
+	})
+}
+
+func TestStoreMissing(t *testing.T) {
+	forEachDriver(t, func(t *testing.T) {
+		if err := Store.Update("u1", "nope", newTestDday("nope", "생일")); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Update error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Delete("u1", "nope", 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Delete error = %v, want sql.ErrNoRows", err)
+		}
+		if err := Store.Delete("u1", "nope", 3); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("conditional Delete error = %v, want sql.ErrNoRows", err)
+		}
+		title := "생일"
+		if err := Store.Patch("u1", "nope", &DdayPatch{Title: &title}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("Patch error = %v, want sql.ErrNoRows", err)
+		}
+	})
+}
+
+func TestStoreVersionConflict(t *testing.T) {
+	forEachDriver(t, func(t *testing.T) {
+		mustCreate(t, "u1", newTestDday("d1", "생일"))
+
+		update := newTestDday("d1", "엄마 생일")
+		update.Version = 1
+		if err := Store.Update("u1", "d1", update); err != nil {
+			t.Fatal(err)
+		}
+
+		stale := newTestDday("d1", "아빠 생일")
+		stale.Version = 1
+		if err := Store.Update("u1", "d1", stale); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale Update error = %v, want ErrVersionConflict", err)
+		}
+		title := "아빠 생일"
+		if err := Store.Patch("u1", "d1", &DdayPatch{Title: &title, Version: 1}); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale Patch error = %v, want ErrVersionConflict", err)
+		}
+		if err := Store.Delete("u1", "d1", 1); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale Delete error = %v, want ErrVersionConflict", err)
+		}
+
+		dday, err := Store.GetByID("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dday.Title != "엄마 생일" || dday.Version != 2 {
+			t.Errorf("title, version = %q, %d; want 엄마 생일, 2", dday.Title, dday.Version)
+		}
+
+		if err := Store.Delete("u1", "d1", 2); err != nil {
+			t.Errorf("current Delete error = %v", err)
+		}
+	})
+}
+
+func TestStoreBatchRollback(t *testing.T) {
+	forEachDriver(t, func(t *testing.T) {
+		mustCreate(t, "u1", newTestDday("d1", "생일"))
+
+		tx, err := Store.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Store.CreateWithTx(tx, "u1", newTestDday("d2", "기념일")); err != nil {
+			t.Fatal(err)
+		}
+		if err := Store.UpdateWithTx(tx, "u1", "d1", newTestDday("d1", "엄마 생일")); err != nil {
+			t.Fatal(err)
+		}
+		if err := Store.DeleteWithTx(tx, "u1", "d1", 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Store.GetByID("u1", "d2"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetByID of a rolled back create error = %v, want sql.ErrNoRows", err)
+		}
+		dday, err := Store.GetByID("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dday.Title != "생일" || dday.Version != 1 || dday.DeletedAt != nil {
+			t.Errorf("title, version, deleted at = %q, %d, %v after rollback; want 생일, 1, nil", dday.Title, dday.Version, dday.DeletedAt)
+		}
+		revisions, err := Store.GetRevisions("u1", "d1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) != 1 {
+			t.Errorf("%d revisions after rollback, want 1", len(revisions))
+		}
+
+		// A committed batch keeps every write.
+		tx, err = Store.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Store.CreateWithTx(tx, "u1", newTestDday("d2", "기념일")); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Store.GetByID("u1", "d2"); err != nil {
+			t.Errorf("GetByID of a committed create error = %v", err)
+		}
+	})
+}
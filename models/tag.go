@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags is how many tags one D-Day may carry.
+	MaxTags = 20
+	// MaxTagLength is the longest tag t_name holds, in characters.
+	MaxTagLength = 50
+
+	// lookupBatchSize caps the D-Day IDs of one tag or role lookup, well
+	// under the placeholder limits of both dialects.
+	lookupBatchSize = 500
+)
+
+// Tag modes of QueryBuilder.Tags.
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+var ErrInvalidTags = fmt.Errorf("tags must be at most %d names of 1 to %d characters without commas", MaxTags, MaxTagLength)
+
+// Tag is a tag name with the number of live D-Days carrying it.
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TagStore lists the tags of a user's D-Days. Tags are set through the
+// DdayStore along with the rest of a D-Day, and belong to its creator.
+type TagStore interface {
+	// List returns the tags of the live D-Days userID can see, shared ones
+	// included, most used first.
+	List(userID string) ([]Tag, error)
+}
+
+// Tags is the TagStore selected by InitDatabase.
+var Tags TagStore
+
+func NewTagStore() TagStore {
+	return Tags
+}
+
+// NormalizeTags trims and lower-cases tags, drops duplicates and sorts them.
+// Commas are not allowed, as tag filters are comma-separated.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || strings.Contains(tag, ",") || utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, ErrInvalidTags
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, ErrInvalidTags
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// TagManager is the SQL TagStore.
+type TagManager struct {
+	Conn *Connection
+}
+
+func NewTagManager() *TagManager {
+	return &TagManager{Conn: DB}
+}
+
+func (m *TagManager) List(userID string) ([]Tag, error) {
+	query := `SELECT t_name, COUNT(*) AS usage_count FROM tags_tb
+			  JOIN dday_tags_tb ON dt_tag_id = t_id
+			  JOIN ddays_tb ON d_id = dt_dday_id
+			  WHERE ` + accessCondition + ` AND d_deleted_at IS NULL
+			  GROUP BY t_name
+			  ORDER BY usage_count DESC, t_name ASC`
+
+	rows, err := m.Conn.Query(query, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// setTags replaces the tags of ddayID, creating the tags of userID, its
+// creator, as needed and dropping those no D-Day uses any more.
+func (m *DdayManager) setTags(q querier, userID, ddayID string, tags []string) error {
+	if _, err := q.Exec("DELETE FROM dday_tags_tb WHERE dt_dday_id = ?", ddayID); err != nil {
+		return err
+	}
+
+	if len(tags) > 0 {
+		insert := "INSERT IGNORE INTO tags_tb (t_user_id, t_name) VALUES (?, ?)"
+		if m.Conn.Driver == DriverSQLite {
+			insert = "INSERT OR IGNORE INTO tags_tb (t_user_id, t_name) VALUES (?, ?)"
+		}
+		for _, tag := range tags {
+			if _, err := q.Exec(insert, userID, tag); err != nil {
+				return err
+			}
+		}
+
+		args := []interface{}{ddayID, userID}
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+		link := "INSERT INTO dday_tags_tb (dt_dday_id, dt_tag_id) SELECT ?, t_id FROM tags_tb WHERE t_user_id = ? AND t_name IN (" + placeholders(len(tags)) + ")"
+		if _, err := q.Exec(link, args...); err != nil {
+			return err
+		}
+	}
+
+	_, err := q.Exec("DELETE FROM tags_tb WHERE t_user_id = ? AND NOT EXISTS (SELECT 1 FROM dday_tags_tb WHERE dt_tag_id = t_id)", userID)
+	return err
+}
+
+// loadTags fills Tags on ddays with one query per lookupBatchSize D-Days.
+func (m *DdayManager) loadTags(q querier, ddays []DDay) error {
+	index := make(map[string]int, len(ddays))
+	for i := range ddays {
+		ddays[i].Tags = []string{}
+		index[ddays[i].ID] = i
+	}
+
+	for start := 0; start < len(ddays); start += lookupBatchSize {
+		batch := ddays[start:min(start+lookupBatchSize, len(ddays))]
+		args := make([]interface{}, len(batch))
+		for i := range batch {
+			args[i] = batch[i].ID
+		}
+
+		query := "SELECT dt_dday_id, t_name FROM dday_tags_tb JOIN tags_tb ON t_id = dt_tag_id WHERE dt_dday_id IN (" + placeholders(len(batch)) + ") ORDER BY t_name"
+		if err := scanPairs(q, query, args, func(id, tag string) {
+			if i, ok := index[id]; ok {
+				ddays[i].Tags = append(ddays[i].Tags, tag)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPairs calls fn with each D-Day ID and value, such as a tag name, that
+// query returns.
+func scanPairs(q querier, query string, args []interface{}, fn func(id, value string)) error {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return err
+		}
+		fn(id, value)
+	}
+	return rows.Err()
+}
+
+// placeholders is n comma-separated bind parameters.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
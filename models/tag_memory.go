@@ -0,0 +1,36 @@
+package models
+
+import "sort"
+
+// MemoryTagStore is the in-memory TagStore. Tags live on the D-Days of the
+// shared memoryDB, so there is nothing to keep in sync.
+type MemoryTagStore struct {
+	db *memoryDB
+}
+
+func (m *MemoryTagStore) List(userID string) ([]Tag, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, dday := range m.db.ddays {
+		if m.db.roleOf(dday, userID) == "" || dday.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range dday.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]Tag, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, Tag{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
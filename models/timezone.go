@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// locations caches loaded zones by name.
+var locations sync.Map
+
+// LoadLocation loads an IANA zone such as "Asia/Seoul". "" and "Local" are
+// rejected, since they would stand for the server's own zone.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	// Names from requests may point into fiber's reused buffers.
+	locations.Store(strings.Clone(name), loc)
+	return loc, nil
+}
+
+// utcNow is the current time in UTC, which stored timestamps are normalized
+// to whatever the server's zone.
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrEmailTaken          = errors.New("email already registered")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+type User struct {
+	ID           string `json:"id" db:"u_id"`
+	Email        string `json:"email" db:"u_email"`
+	Name         string `json:"name" db:"u_name"`
+	PasswordHash string `json:"-" db:"u_password_hash"`
+	// Timezone is the IANA zone the user counts days in, or "" for the
+	// server's.
+	Timezone string `json:"timezone" db:"u_timezone"`
+	// FeedTokenHash is the hash of the secret in the user's calendar feed
+	// URL, or "" without a feed.
+	FeedTokenHash string    `json:"-" db:"u_feed_token_hash"`
+	CreatedAt     time.Time `json:"created_at" db:"u_created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"u_updated_at"`
+}
+
+// Location returns the user's timezone, or fallback when they have not set
+// one or it no longer loads.
+func (u *User) Location(fallback *time.Location) *time.Location {
+	if u.Timezone == "" {
+		return fallback
+	}
+	loc, err := LoadLocation(u.Timezone)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// UserStore keeps accounts and their refresh tokens. Refresh tokens are only
+// ever handled by hash.
+type UserStore interface {
+	// Create returns ErrEmailTaken when the email is already registered.
+	Create(user *User) error
+	GetByID(id string) (*User, error)
+	GetByEmail(email string) (*User, error)
+	// Update saves the user's name and timezone.
+	Update(user *User) error
+	// SetFeedToken replaces the user's calendar feed token hash; "" removes
+	// the feed.
+	SetFeedToken(userID, tokenHash string) error
+	GetByFeedToken(tokenHash string) (*User, error)
+
+	SaveRefreshToken(userID, tokenHash string, expiresAt time.Time) error
+	// RotateRefreshToken revokes a live token, saves its replacement and
+	// returns the owner. Unknown, expired and revoked tokens give
+	// ErrInvalidRefreshToken; one already revoked when presented also
+	// revokes every token of its owner, since it has most likely been
+	// stolen. Of two concurrent rotations of one token, only one succeeds.
+	RotateRefreshToken(oldHash, newHash string, expiresAt time.Time) (string, error)
+	RevokeRefreshToken(tokenHash string) error
+}
+
+// Users is the UserStore selected by InitDatabase.
+var Users UserStore
+
+func NewUserStore() UserStore {
+	return Users
+}
+
+// UserManager is the SQL UserStore.
+type UserManager struct {
+	Conn *Connection
+}
+
+func NewUserManager() *UserManager {
+	return &UserManager{Conn: DB}
+}
+
+const userColumns = "u_id, u_email, u_name, u_password_hash, u_timezone, u_feed_token_hash, u_created_at, u_updated_at"
+
+func scanUser(row rowScanner) (*User, error) {
+	var user User
+	var feedTokenHash sql.NullString
+	err := row.Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.Timezone, &feedTokenHash, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.FeedTokenHash = feedTokenHash.String
+	return &user, nil
+}
+
+func (m *UserManager) Create(user *User) error {
+	user.CreatedAt = utcNow()
+	user.UpdatedAt = user.CreatedAt
+	query := `INSERT INTO users_tb (u_id, u_email, u_name, u_password_hash, u_timezone, u_created_at, u_updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	// The unique index on u_email settles concurrent signups for one email.
+	_, err := m.Conn.Exec(query, user.ID, user.Email, user.Name, user.PasswordHash, user.Timezone, user.CreatedAt, user.UpdatedAt)
+	if isDuplicateKey(err) {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+func (m *UserManager) GetByID(id string) (*User, error) {
+	return scanUser(m.Conn.QueryRow("SELECT "+userColumns+" FROM users_tb WHERE u_id = ?", id))
+}
+
+func (m *UserManager) GetByEmail(email string) (*User, error) {
+	return scanUser(m.Conn.QueryRow("SELECT "+userColumns+" FROM users_tb WHERE u_email = ?", email))
+}
+
+func (m *UserManager) Update(user *User) error {
+	user.UpdatedAt = utcNow()
+	query := "UPDATE users_tb SET u_name = ?, u_timezone = ?, u_updated_at = ? WHERE u_id = ?"
+	_, err := m.Conn.Exec(query, user.Name, user.Timezone, user.UpdatedAt, user.ID)
+	return err
+}
+
+func (m *UserManager) SetFeedToken(userID, tokenHash string) error {
+	_, err := m.Conn.Exec("UPDATE users_tb SET u_feed_token_hash = ? WHERE u_id = ?", nullString(tokenHash), userID)
+	return err
+}
+
+func (m *UserManager) GetByFeedToken(tokenHash string) (*User, error) {
+	return scanUser(m.Conn.QueryRow("SELECT "+userColumns+" FROM users_tb WHERE u_feed_token_hash = ?", tokenHash))
+}
+
+func (m *UserManager) SaveRefreshToken(userID, tokenHash string, expiresAt time.Time) error {
+	return m.saveRefreshToken(m.Conn, userID, tokenHash, expiresAt)
+}
+
+func (m *UserManager) saveRefreshToken(q querier, userID, tokenHash string, expiresAt time.Time) error {
+	query := "INSERT INTO refresh_tokens_tb (rt_token_hash, rt_user_id, rt_expires_at, rt_created_at) VALUES (?, ?, ?, ?)"
+	_, err := q.Exec(query, tokenHash, userID, expiresAt.UTC(), utcNow())
+	return err
+}
+
+func (m *UserManager) RotateRefreshToken(oldHash, newHash string, expiresAt time.Time) (string, error) {
+	tx, err := m.Conn.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var expires time.Time
+	var revokedAt sql.NullTime
+	query := "SELECT rt_user_id, rt_expires_at, rt_revoked_at FROM refresh_tokens_tb WHERE rt_token_hash = ?"
+	err = tx.QueryRow(query, oldHash).Scan(&userID, &expires, &revokedAt)
+	if err == sql.ErrNoRows {
+		return "", ErrInvalidRefreshToken
+	} else if err != nil {
+		return "", err
+	}
+
+	now := utcNow()
+	if revokedAt.Valid {
+		return "", revokeFamily(tx, userID, now)
+	}
+	if !expires.After(now) {
+		return "", ErrInvalidRefreshToken
+	}
+
+	// The row was read without a lock, so a concurrent refresh may have
+	// revoked it since; only the request whose UPDATE revokes it may rotate.
+	// The loser is a client refreshing twice at once, not a replay, so its
+	// token is refused without revoking the one the winner just issued.
+	query = "UPDATE refresh_tokens_tb SET rt_revoked_at = ? WHERE rt_token_hash = ? AND rt_revoked_at IS NULL"
+	result, err := tx.Exec(query, now, oldHash)
+	if err != nil {
+		return "", err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return "", err
+	} else if affected != 1 {
+		return "", ErrInvalidRefreshToken
+	}
+	if err := m.saveRefreshToken(tx, userID, newHash, expiresAt); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
+
+// revokeFamily treats a reused refresh token as stolen: it revokes all of the
+// user's refresh tokens, commits tx and returns ErrInvalidRefreshToken.
+func revokeFamily(tx *sql.Tx, userID string, now time.Time) error {
+	query := "UPDATE refresh_tokens_tb SET rt_revoked_at = ? WHERE rt_user_id = ? AND rt_revoked_at IS NULL"
+	if _, err := tx.Exec(query, now, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+func (m *UserManager) RevokeRefreshToken(tokenHash string) error {
+	query := "UPDATE refresh_tokens_tb SET rt_revoked_at = ? WHERE rt_token_hash = ? AND rt_revoked_at IS NULL"
+	_, err := m.Conn.Exec(query, utcNow(), tokenHash)
+	return err
+}
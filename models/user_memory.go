@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type refreshToken struct {
+	userID    string
+	expiresAt time.Time
+	revoked   bool
+}
+
+// MemoryUserStore is the in-memory UserStore.
+type MemoryUserStore struct {
+	db *memoryDB
+}
+
+func (m *MemoryUserStore) Create(user *User) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, existing := range m.db.users {
+		if existing.Email == user.Email {
+			return ErrEmailTaken
+		}
+	}
+
+	user.CreatedAt = utcNow()
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	stored.ID = strings.Clone(user.ID)
+	stored.Email = strings.Clone(user.Email)
+	stored.Name = strings.Clone(user.Name)
+	stored.Timezone = strings.Clone(user.Timezone)
+	setRow(m.db, m.db.users, stored.ID, stored)
+	return nil
+}
+
+func (m *MemoryUserStore) GetByID(id string) (*User, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &user, nil
+}
+
+func (m *MemoryUserStore) GetByEmail(email string) (*User, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, user := range m.db.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryUserStore) Update(user *User) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	stored, ok := m.db.users[user.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	user.UpdatedAt = utcNow()
+	stored.Name = strings.Clone(user.Name)
+	stored.Timezone = strings.Clone(user.Timezone)
+	stored.UpdatedAt = user.UpdatedAt
+	setRow(m.db, m.db.users, stored.ID, stored)
+	return nil
+}
+
+func (m *MemoryUserStore) SetFeedToken(userID, tokenHash string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	user, ok := m.db.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	user.FeedTokenHash = strings.Clone(tokenHash)
+	setRow(m.db, m.db.users, userID, user)
+	return nil
+}
+
+func (m *MemoryUserStore) GetByFeedToken(tokenHash string) (*User, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, user := range m.db.users {
+		if tokenHash != "" && user.FeedTokenHash == tokenHash {
+			return &user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryUserStore) SaveRefreshToken(userID, tokenHash string, expiresAt time.Time) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	setRow(m.db, m.db.refreshTokens, tokenHash, refreshToken{userID: strings.Clone(userID), expiresAt: expiresAt})
+	return nil
+}
+
+func (m *MemoryUserStore) RotateRefreshToken(oldHash, newHash string, expiresAt time.Time) (string, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	token, ok := m.db.refreshTokens[oldHash]
+	if !ok {
+		return "", ErrInvalidRefreshToken
+	}
+	if token.revoked {
+		for hash, other := range m.db.refreshTokens {
+			if other.userID == token.userID {
+				other.revoked = true
+				setRow(m.db, m.db.refreshTokens, hash, other)
+			}
+		}
+		return "", ErrInvalidRefreshToken
+	}
+	if !token.expiresAt.After(time.Now()) {
+		return "", ErrInvalidRefreshToken
+	}
+
+	token.revoked = true
+	setRow(m.db, m.db.refreshTokens, oldHash, token)
+	setRow(m.db, m.db.refreshTokens, newHash, refreshToken{userID: token.userID, expiresAt: expiresAt})
+	return token.userID, nil
+}
+
+func (m *MemoryUserStore) RevokeRefreshToken(tokenHash string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if token, ok := m.db.refreshTokens[tokenHash]; ok {
+		token.revoked = true
+		setRow(m.db, m.db.refreshTokens, tokenHash, token)
+	}
+	return nil
+}
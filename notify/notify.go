@@ -0,0 +1,104 @@
+// Package notify delivers D-Day reminders. Notifier is the extension point;
+// the server picks an implementation with the NOTIFIER setting.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	KindLog     = "log"
+	KindWebhook = "webhook"
+)
+
+// Notification is one reminder going out to a D-Day's owner.
+type Notification struct {
+	ReminderID int    `json:"reminder_id"`
+	UserID     string `json:"user_id"`
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	DdayID     string `json:"dday_id"`
+	Title      string `json:"title"`
+	TargetDate string `json:"target_date"`
+	DaysBefore int    `json:"days_before"`
+}
+
+// Label is how the D-Day is counted down on the day of the notification.
+func (n Notification) Label() string {
+	if n.DaysBefore == 0 {
+		return "D-Day"
+	}
+	return fmt.Sprintf("D-%d", n.DaysBefore)
+}
+
+// Notifier sends a notification. The scheduler calls it at most once per
+// reminder and day and does not retry a failed delivery.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// New returns the Notifier of the given kind.
+func New(kind, webhookURL string) (Notifier, error) {
+	switch kind {
+	case KindLog:
+		return LogNotifier{}, nil
+	case KindWebhook:
+		if webhookURL == "" {
+			return nil, fmt.Errorf("the webhook notifier needs NOTIFIER_WEBHOOK_URL")
+		}
+		return NewWebhookNotifier(webhookURL), nil
+	}
+	return nil, fmt.Errorf("unknown notifier: %s", kind)
+}
+
+// LogNotifier writes notifications to the server log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("Reminder for %s: %s is %s (%s)", n.Email, n.Title, n.Label(), n.TargetDate)
+	return nil
+}
+
+// WebhookNotifier POSTs each notification as JSON to URL and expects a 2xx
+// response.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(struct {
+		Notification
+		Label string `json:"label"`
+	}{n, n.Label()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
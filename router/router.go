@@ -3,6 +3,9 @@ package router
 import (
 	"dday-backend/controllers/api"
 	"dday-backend/controllers/rest"
+	"dday-backend/middleware"
+	"dday-backend/models"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -14,9 +17,10 @@ func SetupRoutes(app *fiber.App) {
 	app.Use(recover.New())
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,X-Timezone,X-Share-Password,If-Match,If-None-Match",
+		ExposeHeaders: "ETag",
 	}))
 
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -32,31 +36,109 @@ func SetupRoutes(app *fiber.App) {
 		})
 	})
 
-	apiV1 := app.Group("/api/v1")
+	apiV1 := app.Group("/api/v1", middleware.Timezone())
 	setupAPIRoutes(apiV1)
 
-	rest := app.Group("/rest")
+	rest := app.Group("/rest", middleware.Timezone())
 	setupRESTRoutes(rest)
+
+	// Share links are opened without logging in, so failed attempts at a
+	// password are throttled per link.
+	shareAPI := api.NewShareController(models.NewShareStore())
+	app.Get("/s/:token", middleware.ShareAttempts(10, 15*time.Minute), middleware.Timezone(), shareAPI.ViewShare)
 }
 
 func setupAPIRoutes(router fiber.Router) {
-	ddayAPI := &api.DdayController{}
+	ddayAPI := api.NewDdayController(models.NewDdayStore(), models.NewCategoryStore())
+	categoryAPI := api.NewCategoryController(models.NewCategoryStore())
+	reminderAPI := api.NewReminderController(models.NewReminderStore())
+	tagAPI := api.NewTagController(models.NewTagStore())
+	shareAPI := api.NewShareController(models.NewShareStore())
+	memberAPI := api.NewMemberController(models.NewMemberStore())
+	authAPI := api.NewAuthController(models.NewUserStore())
+	calendarAPI := api.NewCalendarController()
+	importAPI := api.NewImportController(models.NewDdayStore(), models.NewCategoryStore())
+	icalAPI := api.NewICalController(models.NewDdayStore(), models.NewReminderStore(), models.NewCategoryStore(), models.NewUserStore())
+	requireAuth := middleware.RequireAuth(models.NewUserStore())
+
+	authGroup := router.Group("/auth")
+	authGroup.Post("/signup", authAPI.Signup)
+	authGroup.Post("/login", authAPI.Login)
+	authGroup.Post("/refresh", authAPI.Refresh)
+	authGroup.Post("/logout", authAPI.Logout)
+
+	router.Get("/me", requireAuth, authAPI.Me)
+	router.Put("/me", requireAuth, authAPI.UpdateMe)
+	router.Post("/me/feed", requireAuth, icalAPI.CreateFeed)
+	router.Delete("/me/feed", requireAuth, icalAPI.DeleteFeed)
+	router.Get("/feed/:token.ics", icalAPI.Feed)
 
-	ddays := router.Group("/ddays")
+	router.Get("/calendar/convert", calendarAPI.Convert)
+
+	ddays := router.Group("/ddays", requireAuth)
 	ddays.Get("/", ddayAPI.GetDdays)
 	ddays.Post("/", ddayAPI.CreateDday)
+	ddays.Get("/search", ddayAPI.SearchDdays)
+	ddays.Get("/autocomplete", ddayAPI.Autocomplete)
+	ddays.Get("/export", ddayAPI.ExportDdays)
+	ddays.Post("/import", ddayAPI.ImportDdays)
+	ddays.Post("/batch", ddayAPI.BatchDdays)
+	ddays.Get("/export.ics", icalAPI.Export)
 	ddays.Get("/:id", ddayAPI.GetDday)
 	ddays.Put("/:id", ddayAPI.UpdateDday)
+	ddays.Patch("/:id", ddayAPI.PatchDday)
 	ddays.Delete("/:id", ddayAPI.DeleteDday)
+	ddays.Post("/:id/restore", ddayAPI.RestoreDday)
+	ddays.Get("/:id/occurrences", ddayAPI.GetOccurrences)
+	ddays.Get("/:id/revisions", ddayAPI.GetRevisions)
+	ddays.Post("/:id/revisions/:rev/revert", ddayAPI.RevertRevision)
+	ddays.Get("/:id/reminders", reminderAPI.GetReminders)
+	ddays.Post("/:id/reminders", reminderAPI.CreateReminder)
+	ddays.Get("/:id/reminders/:reminderId", reminderAPI.GetReminder)
+	ddays.Put("/:id/reminders/:reminderId", reminderAPI.UpdateReminder)
+	ddays.Delete("/:id/reminders/:reminderId", reminderAPI.DeleteReminder)
+	ddays.Get("/:id/shares", shareAPI.GetShares)
+	ddays.Post("/:id/shares", shareAPI.CreateShare)
+	ddays.Delete("/:id/shares/:shareId", shareAPI.RevokeShare)
+	ddays.Get("/:id/members", memberAPI.GetMembers)
+	ddays.Put("/:id/members/:userId", memberAPI.UpdateMember)
+	ddays.Delete("/:id/members/:userId", memberAPI.RemoveMember)
+	ddays.Get("/:id/invitations", memberAPI.GetInvitations)
+	ddays.Post("/:id/invitations", memberAPI.CreateInvitation)
+	ddays.Delete("/:id/invitations/:invitationId", memberAPI.CancelInvitation)
+
+	router.Get("/trash", requireAuth, ddayAPI.GetTrash)
+
+	imports := router.Group("/import", requireAuth)
+	imports.Post("/ics", importAPI.PreviewICS)
+	imports.Post("/ics/confirm", importAPI.ConfirmICS)
+
+	router.Get("/tags", requireAuth, tagAPI.GetTags)
+
+	invitations := router.Group("/invitations", requireAuth)
+	invitations.Get("/", memberAPI.GetMyInvitations)
+	invitations.Post("/accept", memberAPI.AcceptCode)
+	invitations.Post("/:invitationId/accept", memberAPI.AcceptInvitation)
+	invitations.Delete("/:invitationId", memberAPI.DeclineInvitation)
+
+	// Categories are shared by every user, so only admins change them.
+	requireAdmin := middleware.RequireAdmin()
+	categories := router.Group("/categories")
+	categories.Get("/", categoryAPI.GetCategories)
+	categories.Post("/", requireAuth, requireAdmin, categoryAPI.CreateCategory)
+	categories.Get("/:id", categoryAPI.GetCategory)
+	categories.Put("/:id", requireAuth, requireAdmin, categoryAPI.UpdateCategory)
+	categories.Delete("/:id", requireAuth, requireAdmin, categoryAPI.DeleteCategory)
 }
 
 func setupRESTRoutes(router fiber.Router) {
-	ddayREST := &rest.DdayController{}
+	ddayREST := rest.NewDdayController(models.NewDdayStore(), models.NewCategoryStore())
 
-	ddays := router.Group("/ddays")
+	ddays := router.Group("/ddays", middleware.RequireAuth(models.NewUserStore()))
 	ddays.Get("/", ddayREST.List)
 	ddays.Post("/", ddayREST.Create)
 	ddays.Get("/:id", ddayREST.Get)
 	ddays.Put("/:id", ddayREST.Update)
+	ddays.Patch("/:id", ddayREST.Patch)
 	ddays.Delete("/:id", ddayREST.Delete)
-}
\ No newline at end of file
+}